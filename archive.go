@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTar   = "tar"
+	archiveFormatTarGz = "tar.gz"
+)
+
+// archiveModTime is the modification time of all the entries, so that the same files make the same archive.
+// It is the earliest time zip can represent.
+var archiveModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveFormat returns the format of the archive by the extension of archivePath.
+func archiveFormat(archivePath string) (format string, err error) {
+	switch lower := strings.ToLower(archivePath); {
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveFormatZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveFormatTarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveFormatTar, nil
+	default:
+		return "", fmt.Errorf("%s: the extension is not supported. supported: .zip, .tar, .tar.gz, .tgz", archivePath)
+	}
+}
+
+// archiveEntryName returns the name of the entry of the file in the archive, which is the slash-separated clean path.
+func archiveEntryName(filePath string) (name string, err error) {
+	name = path.Clean(filepath.ToSlash(filePath))
+	if path.IsAbs(name) || filepath.IsAbs(filePath) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("%s: the path in the archive must be relative and inside the archive", filePath)
+	}
+	return name, nil
+}
+
+// generateArchive returns the archive in the format of the files with the contents, in the order of filePaths.
+func generateArchive(format string, filePaths []string, contents [][]byte) (archive []byte, err error) {
+	names := make([]string, len(filePaths))
+	for i, filePath := range filePaths {
+		names[i], err = archiveEntryName(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("archiveEntryName: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case archiveFormatZip:
+		if err = writeZip(&buf, names, contents); err != nil {
+			return nil, fmt.Errorf("writeZip: %w", err)
+		}
+	case archiveFormatTarGz:
+		gz := gzip.NewWriter(&buf)
+		if err = writeTar(gz, names, contents); err != nil {
+			return nil, fmt.Errorf("writeTar: %w", err)
+		}
+		if err = gz.Close(); err != nil {
+			return nil, fmt.Errorf("gz.Close: %w", err)
+		}
+	default:
+		if err = writeTar(&buf, names, contents); err != nil {
+			return nil, fmt.Errorf("writeTar: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeZip(w io.Writer, names []string, contents [][]byte) (err error) {
+	zw := zip.NewWriter(w)
+	for i, name := range names {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveModTime}
+		header.SetMode(0644)
+
+		var fw io.Writer
+		fw, err = zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("zw.CreateHeader: %s: %w", name, err)
+		}
+		if _, err = fw.Write(contents[i]); err != nil {
+			return fmt.Errorf("fw.Write: %s: %w", name, err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("zw.Close: %w", err)
+	}
+	return nil
+}
+
+func writeTar(w io.Writer, names []string, contents [][]byte) (err error) {
+	tw := tar.NewWriter(w)
+	for i, name := range names {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents[i])),
+			ModTime:  archiveModTime,
+			Format:   tar.FormatPAX,
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("tw.WriteHeader: %s: %w", name, err)
+		}
+		if _, err = tw.Write(contents[i]); err != nil {
+			return fmt.Errorf("tw.Write: %s: %w", name, err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return fmt.Errorf("tw.Close: %w", err)
+	}
+	return nil
+}
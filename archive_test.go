@@ -0,0 +1,135 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func Test_archiveFormat(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for archivePath, expect := range map[string]string{
+			"out.zip":      archiveFormatZip,
+			"out.ZIP":      archiveFormatZip,
+			"out.tar":      archiveFormatTar,
+			"dist/out.tgz": archiveFormatTarGz,
+			"out.tar.gz":   archiveFormatTarGz,
+		} {
+			format, err := archiveFormat(archivePath)
+			if err != nil {
+				t.Error(err)
+			}
+			if format != expect {
+				t.Error("archiveFormat: " + archivePath + ": want=" + expect + " current=" + format)
+			}
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, archivePath := range []string{"out.7z", "out", "out.gz"} {
+			if _, err := archiveFormat(archivePath); err == nil {
+				t.Error("archiveFormat: " + archivePath + ": err == nil")
+			}
+		}
+	})
+}
+
+func Test_generateArchive(t *testing.T) {
+	var (
+		filePaths = []string{"bqschema.generated.go", "./proto/../bqschema.proto"}
+		contents  = [][]byte{[]byte("package bqschema\n"), []byte("syntax = \"proto3\";\n")}
+		expect    = map[string]string{"bqschema.generated.go": "package bqschema\n", "bqschema.proto": "syntax = \"proto3\";\n"}
+	)
+
+	readTar := func(t *testing.T, r io.Reader) map[string]string {
+		files := make(map[string]string)
+		tr := tar.NewReader(r)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return files
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[header.Name] = string(content)
+		}
+	}
+
+	t.Run("正常系_zip", func(t *testing.T) {
+		archive, err := generateArchive(archiveFormatZip, filePaths, contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make(map[string]string)
+		for _, file := range zr.File {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[file.Name] = string(content)
+		}
+		if !reflect.DeepEqual(files, expect) {
+			t.Errorf("generateArchive: want=%v current=%v", expect, files)
+		}
+
+		// Reproducible
+		again, err := generateArchive(archiveFormatZip, filePaths, contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(archive, again) {
+			t.Error("generateArchive: the archives of the same files differ")
+		}
+	})
+
+	t.Run("正常系_tar", func(t *testing.T) {
+		archive, err := generateArchive(archiveFormatTar, filePaths, contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files := readTar(t, bytes.NewReader(archive)); !reflect.DeepEqual(files, expect) {
+			t.Errorf("generateArchive: want=%v current=%v", expect, files)
+		}
+	})
+
+	t.Run("正常系_tar.gz", func(t *testing.T) {
+		archive, err := generateArchive(archiveFormatTarGz, filePaths, contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(archive))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files := readTar(t, gz); !reflect.DeepEqual(files, expect) {
+			t.Errorf("generateArchive: want=%v current=%v", expect, files)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, filePath := range []string{"/tmp/bqschema.generated.go", "../bqschema.generated.go"} {
+			if _, err := generateArchive(archiveFormatZip, []string{filePath}, [][]byte{nil}); err == nil {
+				t.Error("generateArchive: " + filePath + ": err == nil")
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Package bqmeta provides the types and functions referenced by the code generated by bqschema-gen-go.
+package bqmeta
+
+import "cloud.google.com/go/bigquery"
+
+// ColumnMeta describes a column of a BigQuery table and the struct field generated for it.
+type ColumnMeta struct {
+	// Go is the name of the struct field.
+	Go string
+	// BQ is the name of the BigQuery column.
+	BQ string
+	// Type is the BigQuery field type of the column. e.g. `INTEGER`
+	Type string
+	// Nullable is true if the mode of the column is NULLABLE.
+	Nullable bool
+	// Repeated is true if the mode of the column is REPEATED.
+	Repeated bool
+}
+
+// SchemaDiffKind is the kind of a SchemaDiff.
+type SchemaDiffKind string
+
+const (
+	// SchemaDiffAdded is a column that exists only in the live table.
+	SchemaDiffAdded SchemaDiffKind = "added"
+	// SchemaDiffRemoved is a column that exists only in the generated struct.
+	SchemaDiffRemoved SchemaDiffKind = "removed"
+	// SchemaDiffChanged is a column whose type or mode differs between the generated struct and the live table.
+	SchemaDiffChanged SchemaDiffKind = "changed"
+)
+
+// SchemaDiff is a difference between the columns a struct was generated for and the live table schema.
+type SchemaDiff struct {
+	// Column is the name of the BigQuery column.
+	Column string
+	// Kind is the kind of the difference.
+	Kind SchemaDiffKind
+	// Generated is the column the struct was generated for. It is nil for SchemaDiffAdded.
+	Generated *ColumnMeta
+	// Live is the column in the live table schema. Go is always empty. It is nil for SchemaDiffRemoved.
+	Live *ColumnMeta
+}
+
+// CompareSchema returns the differences between the columns a struct was generated for and the live table schema,
+// in the order of generated, followed by the columns added to the live table.
+// Only the top-level columns are compared.
+func CompareSchema(generated []ColumnMeta, live bigquery.Schema) (diffs []SchemaDiff) {
+	liveByName := make(map[string]ColumnMeta)
+	for _, schema := range live {
+		liveByName[schema.Name] = ColumnMeta{
+			BQ:       schema.Name,
+			Type:     string(schema.Type),
+			Nullable: !schema.Required && !schema.Repeated,
+			Repeated: schema.Repeated,
+		}
+	}
+
+	generatedByName := make(map[string]bool)
+	for i := range generated {
+		g := generated[i]
+		generatedByName[g.BQ] = true
+
+		l, exist := liveByName[g.BQ]
+		switch {
+		case !exist:
+			diffs = append(diffs, SchemaDiff{Column: g.BQ, Kind: SchemaDiffRemoved, Generated: &g})
+		case l.Type != g.Type || l.Nullable != g.Nullable || l.Repeated != g.Repeated:
+			diffs = append(diffs, SchemaDiff{Column: g.BQ, Kind: SchemaDiffChanged, Generated: &g, Live: &l})
+		}
+	}
+
+	for _, schema := range live {
+		if generatedByName[schema.Name] {
+			continue
+		}
+		l := liveByName[schema.Name]
+		diffs = append(diffs, SchemaDiff{Column: schema.Name, Kind: SchemaDiffAdded, Live: &l})
+	}
+
+	return diffs
+}
@@ -0,0 +1,56 @@
+package bqmeta
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func TestCompareSchema(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			generated = []ColumnMeta{
+				{Go: "Id", BQ: "id", Type: "INTEGER", Nullable: false, Repeated: false},
+				{Go: "Name", BQ: "name", Type: "STRING", Nullable: true, Repeated: false},
+				{Go: "Deleted", BQ: "deleted", Type: "BOOLEAN", Nullable: true, Repeated: false},
+			}
+			live = bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "name", Type: bigquery.StringFieldType, Repeated: true},
+				{Name: "created_at", Type: bigquery.TimestampFieldType},
+			}
+			want = []SchemaDiff{
+				{
+					Column:    "name",
+					Kind:      SchemaDiffChanged,
+					Generated: &ColumnMeta{Go: "Name", BQ: "name", Type: "STRING", Nullable: true},
+					Live:      &ColumnMeta{BQ: "name", Type: "STRING", Repeated: true},
+				},
+				{
+					Column:    "deleted",
+					Kind:      SchemaDiffRemoved,
+					Generated: &ColumnMeta{Go: "Deleted", BQ: "deleted", Type: "BOOLEAN", Nullable: true},
+				},
+				{
+					Column: "created_at",
+					Kind:   SchemaDiffAdded,
+					Live:   &ColumnMeta{BQ: "created_at", Type: "TIMESTAMP", Nullable: true},
+				},
+			}
+		)
+		if diffs := CompareSchema(generated, live); !reflect.DeepEqual(diffs, want) {
+			t.Errorf("CompareSchema: want=%+v current=%+v", want, diffs)
+		}
+	})
+
+	t.Run("正常系_no_diff", func(t *testing.T) {
+		var (
+			generated = []ColumnMeta{{Go: "Id", BQ: "id", Type: "INTEGER"}}
+			live      = bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}}
+		)
+		if diffs := CompareSchema(generated, live); len(diffs) != 0 {
+			t.Errorf("CompareSchema: want=[] current=%+v", diffs)
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type structField struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+func (f structField) String() string {
+	s := f.Type
+	if f.Name != "" {
+		s = f.Name + " " + s
+	}
+	if f.Tag != "" {
+		s = s + " " + f.Tag
+	}
+	return s
+}
+
+type structDecl struct {
+	Name   string
+	Fields []structField
+}
+
+// parseStructs returns the struct type declarations in the Go source, in the order they appear.
+func parseStructs(src []byte) (structs []structDecl, err error) {
+	fset := token.NewFileSet()
+
+	var file *ast.File
+	file, err = parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			s := structDecl{Name: typeSpec.Name.Name}
+			for _, field := range structType.Fields.List {
+				typeStr := types.ExprString(field.Type)
+				var tag string
+				if field.Tag != nil {
+					tag = field.Tag.Value
+				}
+				// Embedded field
+				if len(field.Names) == 0 {
+					s.Fields = append(s.Fields, structField{Type: typeStr, Tag: tag})
+					continue
+				}
+				for _, name := range field.Names {
+					s.Fields = append(s.Fields, structField{Name: name.Name, Type: typeStr, Tag: tag})
+				}
+			}
+			structs = append(structs, s)
+		}
+	}
+
+	return structs, nil
+}
+
+// parseStructPair parses the struct declarations in oldSrc and newSrc, and indexes each of them by struct name.
+func parseStructPair(oldSrc, newSrc []byte) (oldStructs, newStructs []structDecl, oldByName, newByName map[string]structDecl, err error) {
+	oldStructs, err = parseStructs(oldSrc)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("parseStructs: %w", err)
+	}
+
+	newStructs, err = parseStructs(newSrc)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("parseStructs: %w", err)
+	}
+
+	oldByName = make(map[string]structDecl)
+	for _, s := range oldStructs {
+		oldByName[s.Name] = s
+	}
+	newByName = make(map[string]structDecl)
+	for _, s := range newStructs {
+		newByName[s.Name] = s
+	}
+
+	return oldStructs, newStructs, oldByName, newByName, nil
+}
+
+// diffStructs returns a unified diff of the struct definitions in oldSrc and newSrc.
+// It returns an empty string if the struct definitions are identical.
+func diffStructs(oldSrc, newSrc []byte) (diff string, err error) {
+	oldStructs, newStructs, oldByName, newByName, err := parseStructPair(oldSrc, newSrc)
+	if err != nil {
+		return "", fmt.Errorf("parseStructPair: %w", err)
+	}
+
+	var b strings.Builder
+
+	// Added or changed structs, in the generated order
+	for _, newStruct := range newStructs {
+		lines := diffLines(structLines(oldByName[newStruct.Name]), structLines(newStruct))
+		if !hasChange(lines) {
+			continue
+		}
+		b.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+
+	// Removed structs, in the committed order
+	for _, oldStruct := range oldStructs {
+		if _, exist := newByName[oldStruct.Name]; exist {
+			continue
+		}
+		b.WriteString(strings.Join(diffLines(structLines(oldStruct), nil), "\n") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+func structLines(s structDecl) (lines []string) {
+	if s.Name == "" {
+		return nil
+	}
+	lines = append(lines, "type "+s.Name+" struct {")
+	for _, field := range s.Fields {
+		lines = append(lines, "\t"+field.String())
+	}
+	lines = append(lines, "}")
+	return lines
+}
+
+func hasChange(lines []string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffLines returns the lines of a and b prefixed with " " (common), "-" (only in a) or "+" (only in b),
+// based on the longest common subsequence.
+func diffLines(a, b []string) (lines []string) {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+
+	return lines
+}
+
+// schemaChangelogFile is the changes of a Go output in the JSON array written by -check-changelog.
+type schemaChangelogFile struct {
+	File    string         `json:"file"`
+	Structs []structChange `json:"structs"`
+}
+
+// structChange is the column changes of a struct.
+type structChange struct {
+	Struct  string         `json:"struct"`
+	Added   []columnChange `json:"added,omitempty"`
+	Removed []columnChange `json:"removed,omitempty"`
+	Retyped []columnChange `json:"retyped,omitempty"`
+}
+
+// columnChange is a change of a column. Type is the Go type in the generated code, or in the committed code for a removed column.
+type columnChange struct {
+	Column  string `json:"column"`
+	Type    string `json:"type"`
+	OldType string `json:"old_type,omitempty"`
+}
+
+// schemaChangelog returns the added, removed and retyped columns per struct between oldSrc and newSrc.
+// Columns are identified by the `bigquery` tags of the fields. Structs without changes are omitted.
+func schemaChangelog(oldSrc, newSrc []byte) (changes []structChange, err error) {
+	oldStructs, newStructs, oldByName, newByName, err := parseStructPair(oldSrc, newSrc)
+	if err != nil {
+		return nil, fmt.Errorf("parseStructPair: %w", err)
+	}
+
+	// Added or changed structs, in the generated order
+	for _, newStruct := range newStructs {
+		change := structChange{Struct: newStruct.Name}
+		oldColumns := structColumns(oldByName[newStruct.Name])
+		newColumns := structColumns(newStruct)
+		for _, field := range newStruct.Fields {
+			column := fieldColumn(field)
+			if column == "" {
+				continue
+			}
+			oldType, exist := oldColumns[column]
+			switch {
+			case !exist:
+				change.Added = append(change.Added, columnChange{Column: column, Type: field.Type})
+			case oldType != field.Type:
+				change.Retyped = append(change.Retyped, columnChange{Column: column, Type: field.Type, OldType: oldType})
+			}
+		}
+		for _, field := range oldByName[newStruct.Name].Fields {
+			column := fieldColumn(field)
+			if _, exist := newColumns[column]; column != "" && !exist {
+				change.Removed = append(change.Removed, columnChange{Column: column, Type: field.Type})
+			}
+		}
+		if len(change.Added)+len(change.Removed)+len(change.Retyped) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	// Removed structs, in the committed order
+	for _, oldStruct := range oldStructs {
+		if _, exist := newByName[oldStruct.Name]; exist {
+			continue
+		}
+		change := structChange{Struct: oldStruct.Name}
+		for _, field := range oldStruct.Fields {
+			if column := fieldColumn(field); column != "" {
+				change.Removed = append(change.Removed, columnChange{Column: column, Type: field.Type})
+			}
+		}
+		if len(change.Removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// exitCodeColumnsRetyped is the exit code of -check when columns have changed type.
+const exitCodeColumnsRetyped = 3
+
+// errColumnsRetyped is returned by -check when columns have changed type, which breaks decoding the rows
+// with the committed structs, unlike added or removed columns.
+var errColumnsRetyped = errors.New("columns have changed type")
+
+// retypedColumns returns a line per retyped column in the changes. e.g. `~ Users.age: int64 -> string`
+// It returns an empty string if no column has changed type.
+func retypedColumns(changes []structChange) (lines string) {
+	for _, change := range changes {
+		for _, column := range change.Retyped {
+			lines = lines + "~ " + change.Struct + "." + column.Column + ": " + column.OldType + " -> " + column.Type + "\n"
+		}
+	}
+	return lines
+}
+
+// structColumns returns the Go types of the fields by column name.
+func structColumns(s structDecl) (columns map[string]string) {
+	columns = make(map[string]string)
+	for _, field := range s.Fields {
+		if column := fieldColumn(field); column != "" {
+			columns[column] = field.Type
+		}
+	}
+	return columns
+}
+
+// fieldColumn returns the column name in the `bigquery` tag of the field, or an empty string if there is none.
+func fieldColumn(f structField) (column string) {
+	if f.Tag == "" {
+		return ""
+	}
+	tag, err := strconv.Unquote(f.Tag)
+	if err != nil {
+		return ""
+	}
+	// Drop the options. e.g. `name,nullable`
+	return strings.SplitN(reflect.StructTag(tag).Get("bigquery"), ",", 2)[0]
+}
+
+// structSummary returns the names of the structs created, changed and removed in newSrc compared with oldSrc.
+func structSummary(oldSrc, newSrc []byte) (created, changed, removed []string, err error) {
+	oldStructs, newStructs, oldByName, newByName, err := parseStructPair(oldSrc, newSrc)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("parseStructPair: %w", err)
+	}
+
+	for _, newStruct := range newStructs {
+		oldStruct, exist := oldByName[newStruct.Name]
+		switch {
+		case !exist:
+			created = append(created, newStruct.Name)
+		case hasChange(diffLines(structLines(oldStruct), structLines(newStruct))):
+			changed = append(changed, newStruct.Name)
+		}
+	}
+	for _, oldStruct := range oldStructs {
+		if _, exist := newByName[oldStruct.Name]; !exist {
+			removed = append(removed, oldStruct.Name)
+		}
+	}
+
+	return created, changed, removed, nil
+}
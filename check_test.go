@@ -0,0 +1,204 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	// parseStructs, diffStructs
+	testCommittedCode = `package bqschema
+
+import "time"
+
+// Users is BigQuery Table schema struct.
+type Users struct {
+	Id        int64     ` + "`bigquery:\"id\"`" + `
+	Age       int64     ` + "`bigquery:\"age\"`" + `
+	CreatedAt time.Time ` + "`bigquery:\"createdAt\"`" + `
+}
+
+// Removed is BigQuery Table schema struct.
+type Removed struct {
+	Id int64 ` + "`bigquery:\"id\"`" + `
+}
+`
+	testGeneratedCode = `package bqschema
+
+import "time"
+
+// Users is BigQuery Table schema struct.
+type Users struct {
+	Id        int64     ` + "`bigquery:\"id\"`" + `
+	Age       string    ` + "`bigquery:\"age\"`" + `
+	CreatedAt time.Time ` + "`bigquery:\"createdAt\"`" + `
+	Name      string    ` + "`bigquery:\"name\"`" + `
+}
+
+// Added is BigQuery Table schema struct.
+type Added struct {
+	Id int64 ` + "`bigquery:\"id\"`" + `
+}
+`
+	testNotGoCode = "not go code"
+)
+
+func Test_parseStructs(t *testing.T) {
+	t.Run("正常系_testCommittedCode", func(t *testing.T) {
+		structs, err := parseStructs([]byte(testCommittedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		if len(structs) != 2 {
+			t.Errorf("parseStructs: want=2 current=%d", len(structs))
+			return
+		}
+		if structs[0].Name != "Users" || len(structs[0].Fields) != 3 {
+			t.Errorf("parseStructs: current=%#v", structs[0])
+		}
+		if structs[0].Fields[2].String() != "CreatedAt time.Time `bigquery:\"createdAt\"`" {
+			t.Error("parseStructs: current=`" + structs[0].Fields[2].String() + "`")
+		}
+	})
+
+	t.Run("異常系_testNotGoCode", func(t *testing.T) {
+		if _, err := parseStructs([]byte(testNotGoCode)); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_diffStructs(t *testing.T) {
+	t.Run("正常系_no_diff", func(t *testing.T) {
+		diff, err := diffStructs([]byte(testCommittedCode), []byte(testCommittedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		if diff != "" {
+			t.Error("diffStructs: want=`` current=`" + diff + "`")
+		}
+	})
+
+	t.Run("正常系_diff", func(t *testing.T) {
+		diff, err := diffStructs([]byte(testCommittedCode), []byte(testGeneratedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			" type Users struct {\n",
+			"-\tAge int64 `bigquery:\"age\"`\n",
+			"+\tAge string `bigquery:\"age\"`\n",
+			"+\tName string `bigquery:\"name\"`\n",
+			"+type Added struct {\n",
+			"-type Removed struct {\n",
+		} {
+			if !strings.Contains(diff, want) {
+				t.Error("diffStructs: `" + want + "` not found in `" + diff + "`")
+			}
+		}
+	})
+
+	t.Run("異常系_testNotGoCode", func(t *testing.T) {
+		if _, err := diffStructs([]byte(testNotGoCode), []byte(testGeneratedCode)); err == nil {
+			t.Error(err)
+		}
+		if _, err := diffStructs([]byte(testCommittedCode), []byte(testNotGoCode)); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_diffLines(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			a    = []string{"a", "b", "c"}
+			b    = []string{"a", "c", "d"}
+			want = []string{" a", "-b", " c", "+d"}
+		)
+		lines := diffLines(a, b)
+		if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+			t.Errorf("diffLines: want=%q current=%q", want, lines)
+		}
+	})
+}
+
+func Test_schemaChangelog(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			// 正しい出力
+			want = []structChange{
+				{Struct: "Users", Added: []columnChange{{Column: "name", Type: "string"}}, Retyped: []columnChange{{Column: "age", Type: "string", OldType: "int64"}}},
+				{Struct: "Added", Added: []columnChange{{Column: "id", Type: "int64"}}},
+				{Struct: "Removed", Removed: []columnChange{{Column: "id", Type: "int64"}}},
+			}
+		)
+		changes, err := schemaChangelog([]byte(testCommittedCode), []byte(testGeneratedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("schemaChangelog: want=%#v current=%#v", want, changes)
+		}
+	})
+
+	t.Run("正常系_no_changes", func(t *testing.T) {
+		changes, err := schemaChangelog([]byte(testCommittedCode), []byte(testCommittedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("schemaChangelog: current=%#v", changes)
+		}
+	})
+
+	t.Run("異常系_testNotGoCode", func(t *testing.T) {
+		if _, err := schemaChangelog([]byte(testNotGoCode), []byte(testGeneratedCode)); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_fieldColumn(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for tag, column := range map[string]string{
+			"`bigquery:\"id\"`":          "id",
+			"`bigquery:\"id,nullable\"`": "id",
+			"\"bigquery:\\\"id\\\"\"":    "id",
+			"`json:\"id\"`":              "",
+			"":                           "",
+		} {
+			if v := fieldColumn(structField{Tag: tag}); v != column {
+				t.Error("fieldColumn: tag=" + tag + " want=" + column + " current=" + v)
+			}
+		}
+	})
+}
+
+func Test_structSummary(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		created, changed, removed, err := structSummary([]byte(testCommittedCode), []byte(testGeneratedCode))
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(created, []string{"Added"}) || !reflect.DeepEqual(changed, []string{"Users"}) || !reflect.DeepEqual(removed, []string{"Removed"}) {
+			t.Errorf("structSummary: current=%v,%v,%v", created, changed, removed)
+		}
+	})
+}
+
+func Test_retypedColumns(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		changes := []structChange{
+			{Struct: "Users", Added: []columnChange{{Column: "name", Type: "string"}}, Retyped: []columnChange{{Column: "age", Type: "string", OldType: "int64"}}},
+			{Struct: "Added", Added: []columnChange{{Column: "id", Type: "int64"}}},
+		}
+		const expect = "~ Users.age: int64 -> string\n"
+		if lines := retypedColumns(changes); lines != expect {
+			t.Error("retypedColumns: want=`" + expect + "` current=`" + lines + "`")
+		}
+		if lines := retypedColumns(changes[1:]); lines != "" {
+			t.Error("retypedColumns: want=`` current=`" + lines + "`")
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"strings"
+)
+
+// fakeFilePath returns the path of the fakes file generated next to the Go file of filePath.
+// e.g. `bqschema_fake.generated.go` for `bqschema.generated.go`
+func fakeFilePath(filePath string) string {
+	if strings.HasSuffix(filePath, ".generated.go") {
+		return strings.TrimSuffix(filePath, ".generated.go") + "_fake.generated.go"
+	}
+	return strings.TrimSuffix(filePath, ".go") + "_fake.go"
+}
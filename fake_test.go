@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_fakeFilePath(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for filePath, expect := range map[string]string{
+			"bqschema/bqschema.generated.go": "bqschema/bqschema_fake.generated.go",
+			"bqschema.go":                    "bqschema_fake.go",
+			"models.generated/bqschema.go":   "models.generated/bqschema_fake.go",
+		} {
+			if fakePath := fakeFilePath(filePath); fakePath != expect {
+				t.Errorf("fakeFilePath: %s: want=%s current=%s", filePath, expect, fakePath)
+			}
+		}
+	})
+}
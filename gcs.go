@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/ginokent/bqschema-gen-go/generator"
+	"google.golang.org/api/iterator"
+)
+
+var errInvalidGCSURL = errors.New("invalid GCS URL")
+
+// parseGCSURL splits a URL like `gs://bucket/path/to/` into the bucket and the object name prefix.
+func parseGCSURL(gcsURL string) (bucket string, prefix string, err error) {
+	const scheme = "gs://"
+	if !strings.HasPrefix(gcsURL, scheme) {
+		return "", "", fmt.Errorf("%w: %s does not start with %s", errInvalidGCSURL, gcsURL, scheme)
+	}
+
+	bucketAndPrefix := strings.SplitN(strings.TrimPrefix(gcsURL, scheme), "/", 2)
+	if bucketAndPrefix[0] == "" {
+		return "", "", fmt.Errorf("%w: %s has no bucket", errInvalidGCSURL, gcsURL)
+	}
+	if len(bucketAndPrefix) == 1 {
+		return bucketAndPrefix[0], "", nil
+	}
+
+	return bucketAndPrefix[0], bucketAndPrefix[1], nil
+}
+
+// getTableSchemasFromGCS is the same as getAllTableSchemas, but reads the schemas from the JSON files under gcsURL
+// instead of the metadata of the tables, e.g. exported by `bq show --schema --format=json`.
+// The table ID is the base name of the object without the `.json` extension. Objects in sub-directories are ignored.
+func getTableSchemasFromGCS(ctx context.Context, client *storage.Client, gcsURL, projectID, datasetID string) (schemas []generator.TableSchema, err error) {
+	var bucket, prefix string
+	bucket, prefix, err = parseGCSURL(gcsURL)
+	if err != nil {
+		return nil, fmt.Errorf("parseGCSURL: %w", err)
+	}
+
+	bucketHandle := client.Bucket(bucket)
+	objectIterator := bucketHandle.Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: "/"})
+	for {
+		var attrs *storage.ObjectAttrs
+		attrs, err = objectIterator.Next()
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("objectIterator.Next: %w", err)
+		}
+
+		// attrs.Name is empty for a sub-directory
+		if attrs.Name == "" || !strings.HasSuffix(attrs.Name, ".json") {
+			continue
+		}
+
+		var content []byte
+		content, err = readGCSObject(ctx, bucketHandle.Object(attrs.Name))
+		if err != nil {
+			return nil, fmt.Errorf("readGCSObject: gs://%s/%s: %w", bucket, attrs.Name, err)
+		}
+
+		tableID := strings.TrimSuffix(path.Base(attrs.Name), ".json")
+
+		var schema generator.TableSchema
+		schema, err = tableSchemaFromJSON(content, projectID, datasetID, tableID)
+		if err != nil {
+			return nil, fmt.Errorf("tableSchemaFromJSON: gs://%s/%s: %w", bucket, attrs.Name, err)
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+func readGCSObject(ctx context.Context, object *storage.ObjectHandle) (content []byte, err error) {
+	var reader *storage.Reader
+	reader, err = object.NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("object.NewReader: %w", err)
+	}
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			warnln("reader.Close: " + closeErr.Error())
+		}
+	}()
+
+	content, err = ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadAll: %w", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_parseGCSURL(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		gcsURL string
+		bucket string
+		prefix string
+	}{
+		{"正常系_prefix", "gs://bucket/path/to/", "bucket", "path/to/"},
+		{"正常系_bucket_only", "gs://bucket", "bucket", ""},
+		{"正常系_bucket_slash", "gs://bucket/", "bucket", ""},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, prefix, err := parseGCSURL(tt.gcsURL)
+			if err != nil {
+				t.Error(err)
+			}
+			if bucket != tt.bucket || prefix != tt.prefix {
+				t.Error("parseGCSURL: want=" + tt.bucket + "," + tt.prefix + " current=" + bucket + "," + prefix)
+			}
+		})
+	}
+
+	for _, gcsURL := range []string{"bucket/path/to/", "https://storage.googleapis.com/bucket/", "gs:///path/to/"} {
+		gcsURL := gcsURL
+		t.Run("異常系_"+gcsURL, func(t *testing.T) {
+			if _, _, err := parseGCSURL(gcsURL); !errors.Is(err, errInvalidGCSURL) {
+				t.Errorf("parseGCSURL: want=errInvalidGCSURL current=%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// OutputFormatGoFake is the Options.OutputFormat of the fakes of the structs.
+const OutputFormatGoFake = "go-fake"
+
+// generateFakeCode is the same as generateGoCode, but generates `Fake<Struct>` and `Fake<Struct>Slice` funcs that
+// return the schema structs populated with deterministic values, for tests.
+// The fields of types other than the default ones (e.g. the bigquery Null* types and type overrides) are left zero.
+func generateFakeCode(schemas []TableSchema, opts Options) (generatedCode []byte, err error) {
+	const head = `// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.
+
+package bqschema
+
+`
+
+	var tail string
+	var importPackages []string
+	for _, schema := range schemas {
+		table := schema.Table
+
+		var fakeCode string
+		var pkgs []string
+		fakeCode, pkgs, err = generateFakeStructCode(table, schema.Metadata, opts)
+		if err != nil {
+			if opts.FailOnUnsupported && errors.Is(err, ErrFieldTypeNotSupported) {
+				return nil, fmt.Errorf("generateFakeStructCode: table=%s.%s.%s: %w", table.ProjectID, table.DatasetID, table.TableID, err)
+			}
+			// The struct is not generated either, which generateGoCode warns.
+			continue
+		}
+
+		importPackages = append(importPackages, pkgs...)
+		tail = tail + fakeCode
+	}
+
+	return formatGoCode(head+generateImportPackagesCode(importPackages)+tail, opts)
+}
+
+func generateFakeStructCode(table *bigquery.Table, md *bigquery.TableMetadata, opts Options) (generatedCode string, importPackages []string, err error) {
+	structName := goStructName(table.TableID, opts)
+	embeddedStructName := structName + "Metadata"
+
+	var fieldsCode, embeddedFieldsCode string
+	for _, schema := range md.Schema {
+		var value string
+		var pkgs []string
+		value, pkgs, err = fakeValue(structName, schema, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("fakeValue: %w", err)
+		}
+		if value == "" {
+			continue
+		}
+		importPackages = append(importPackages, pkgs...)
+
+		fieldCode := "\t\t" + goFieldName(schema.Name, opts) + ": " + value + ",\n"
+		if matchEmbedPatterns(schema, opts.EmbedPatterns) {
+			embeddedFieldsCode = embeddedFieldsCode + "\t" + fieldCode
+		} else {
+			fieldsCode = fieldsCode + fieldCode
+		}
+	}
+	if embeddedFieldsCode != "" {
+		fieldsCode = "\t\t" + embeddedStructName + ": " + embeddedStructName + "{\n" + embeddedFieldsCode + "\t\t},\n" + fieldsCode
+	}
+
+	fakeName := "fake" + structName
+	generatedCode = "\n" +
+		"// Fake" + structName + " returns " + structName + " populated with deterministic values, for tests.\n" +
+		"func Fake" + structName + "() " + structName + " {\n" +
+		"\treturn " + fakeName + "(0)\n" +
+		"}\n" +
+		"\n" +
+		"// Fake" + structName + "Slice returns n " + structName + " populated with deterministic values that differ by the index, for tests.\n" +
+		"func Fake" + structName + "Slice(n int) []" + structName + " {\n" +
+		"\ts := make([]" + structName + ", n)\n" +
+		"\tfor i := range s {\n" +
+		"\t\ts[i] = " + fakeName + "(i)\n" +
+		"\t}\n" +
+		"\treturn s\n" +
+		"}\n" +
+		"\n" +
+		"func " + fakeName + "(i int) " + structName + " {\n" +
+		"\treturn " + structName + "{\n" +
+		fieldsCode +
+		"\t}\n" +
+		"}\n"
+
+	return generatedCode, importPackages, nil
+}
+
+// fakeValue returns an expression of a deterministic value of the field generated for the column, which depends on `i`.
+// It returns an empty string if the field is not of the default type of the column.
+func fakeValue(parentName string, schema *bigquery.FieldSchema, opts Options) (value string, importPackages []string, err error) {
+	if schema.Type == bigquery.RecordFieldType {
+		var structName string
+		structName, err = nestedStructName(parentName, schema.Name, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("nestedStructName: column=%s: %w", schema.Name, err)
+		}
+
+		var fieldsCode string
+		for _, field := range schema.Schema {
+			var fieldValue string
+			var pkgs []string
+			fieldValue, pkgs, err = fakeValue(structName, field, opts)
+			if err != nil {
+				return "", nil, fmt.Errorf("fakeValue: column=%s: %w", schema.Name, err)
+			}
+			if fieldValue == "" {
+				continue
+			}
+			importPackages = append(importPackages, pkgs...)
+			fieldsCode = fieldsCode + goFieldName(field.Name, opts) + ": " + fieldValue + ", "
+		}
+		value = structName + "{" + strings.TrimSuffix(fieldsCode, ", ") + "}"
+
+		switch {
+		case schema.Repeated:
+			return "[]" + structName + "{" + value + "}", importPackages, nil
+		case !schema.Required && (opts.NullTypes || opts.NullTypesFor[schema.Type]):
+			return "", nil, nil
+		default:
+			return value, importPackages, nil
+		}
+	}
+
+	var goType, defaultGoType string
+	goType, _, err = fieldSchemaToGoType(schema, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("fieldSchemaToGoType: column=%s: %w", schema.Name, err)
+	}
+	defaultGoType, _, err = BigQueryFieldTypeToGoType(schema.Type)
+	if err != nil {
+		// interface{} with opts.UnsupportedAsAny
+		return "", nil, nil
+	}
+
+	value, importPackages = fakeScalarValue(schema)
+	switch goType {
+	case defaultGoType:
+		return value, importPackages, nil
+	case "[]" + defaultGoType:
+		return goType + "{" + value + "}", importPackages, nil
+	default:
+		return "", nil, nil
+	}
+}
+
+// fakeScalarValue returns an expression of a deterministic value of the default Go type of the column, which depends on `i`.
+func fakeScalarValue(schema *bigquery.FieldSchema) (value string, importPackages []string) {
+	const date = "civil.Date{Year: 2020, Month: time.January, Day: 1 + i%28}"
+	const timeOfDay = "civil.Time{Hour: i % 24}"
+	switch schema.Type {
+	case bigquery.BytesFieldType:
+		return "[]byte(" + strconv.Quote(schema.Name+"_") + " + strconv.Itoa(i))", []string{"strconv"}
+	case bigquery.DateFieldType:
+		return date, []string{typeOfDate.PkgPath(), "time"}
+	case bigquery.TimeFieldType:
+		return timeOfDay, []string{typeOfTime.PkgPath()}
+	case bigquery.DateTimeFieldType:
+		return "civil.DateTime{Date: " + date + ", Time: " + timeOfDay + "}", []string{typeOfDateTime.PkgPath(), "time"}
+	case bigquery.TimestampFieldType:
+		return "time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Hour)", []string{"time"}
+	case bigquery.NumericFieldType:
+		return "big.NewRat(int64(i+1), 100)", []string{"math/big"}
+	case bigquery.IntegerFieldType:
+		return "int64(i + 1)", nil
+	case bigquery.GeographyFieldType:
+		return "\"POINT(\" + strconv.Itoa(i) + \" 0)\"", []string{"strconv"}
+	case bigquery.BooleanFieldType:
+		return "i%2 == 0", nil
+	case bigquery.FloatFieldType:
+		return "float64(i) + 0.5", nil
+	default:
+		return strconv.Quote(schema.Name+"_") + " + strconv.Itoa(i)", []string{"strconv"}
+	}
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func Test_generateFakeStructCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testFakeCode = "\n" +
+				"// FakeUsers returns Users populated with deterministic values, for tests.\n" +
+				"func FakeUsers() Users {\n" +
+				"\treturn fakeUsers(0)\n" +
+				"}\n" +
+				"\n" +
+				"// FakeUsersSlice returns n Users populated with deterministic values that differ by the index, for tests.\n" +
+				"func FakeUsersSlice(n int) []Users {\n" +
+				"\ts := make([]Users, n)\n" +
+				"\tfor i := range s {\n" +
+				"\t\ts[i] = fakeUsers(i)\n" +
+				"\t}\n" +
+				"\treturn s\n" +
+				"}\n" +
+				"\n" +
+				"func fakeUsers(i int) Users {\n" +
+				"\treturn Users{\n" +
+				"\t\tId: int64(i + 1),\n" +
+				"\t\tTags: []string{\"tags_\" + strconv.Itoa(i)},\n" +
+				"\t\tAddress: UsersAddress{Zip: \"zip_\" + strconv.Itoa(i)},\n" +
+				"\t}\n" +
+				"}\n"
+		)
+		var (
+			table = &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+			md    = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+					{Name: "address", Type: bigquery.RecordFieldType, Required: true, Schema: bigquery.Schema{
+						{Name: "zip", Type: bigquery.StringFieldType, Required: true},
+					}},
+				},
+			}
+		)
+		// NOTE: name is a *string, which is left zero
+		generatedCode, importPackages, err := generateFakeStructCode(table, md, Options{NullTypes: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if generatedCode != testFakeCode {
+			t.Error("generateFakeStructCode: want=`" + testFakeCode + "` current=`" + generatedCode + "`")
+		}
+		if len(importPackages) != 2 || importPackages[0] != "strconv" {
+			t.Errorf("generateFakeStructCode: want=[strconv strconv] current=%v", importPackages)
+		}
+	})
+}
@@ -0,0 +1,2197 @@
+// Package generator generates the Go structs, and the other code of the options, of BigQuery table schemas.
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"hash/fnv"
+	"io"
+	"log"
+	"math/big"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"golang.org/x/tools/imports"
+	"google.golang.org/api/iterator"
+)
+
+// GeneratedFileHeader is the first line of the Go files generated by this command.
+const GeneratedFileHeader = "// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT."
+
+// StructTableRegexp matches the doc comments of the generated structs, and captures the project, the dataset and the table.
+var StructTableRegexp = regexp.MustCompile("(?m)^// \\w+ is BigQuery Table `([^`:]+):([^`.]+)\\.([^`]+)` schema struct\\.\r?$")
+
+const (
+	// The package of the runtime helper types referenced by the generated code
+	bqmetaPkgPath = "github.com/ginokent/bqschema-gen-go/bqmeta"
+	// The package of bigquery.Value referenced by the generated code
+	bigqueryPkgPath = "cloud.google.com/go/bigquery"
+
+	// OutputFormatGo and OutputFormatProto are the values of Options.OutputFormat.
+	OutputFormatGo    = "go"
+	OutputFormatProto = "proto"
+
+	// FieldGroupMode is the Options.FieldGroup that groups the fields by mode.
+	FieldGroupMode = "mode"
+
+	// ReceiverStyleShort and ReceiverStyleFull are the values of Options.ReceiverStyle.
+	ReceiverStyleShort = "short"
+	ReceiverStyleFull  = "full"
+)
+
+// Options is the set of options that change the generated code.
+type Options struct {
+	// AnnotateNullable adds a `nullable` comment to the fields of NULLABLE columns, whether or not they are pointers or the bigquery Null* types.
+	AnnotateNullable bool
+	// AnnotateUTC comments the fields of TIMESTAMP columns with `UTC`.
+	AnnotateUTC bool
+	// AvroTags adds `avro:"<name>"` tags. Names that are not valid Avro names are sanitized.
+	AvroTags bool
+	// BSONTags adds `bson` tags with the column names to the fields.
+	BSONTags bool
+	// BSONIDColumn is the top-level column whose `bson` tag is `_id`, with BSONTags. e.g. `id`
+	BSONIDColumn string
+	// DatasetProject is the GCP Project ID that owns the dataset. If empty, the project of the client is used.
+	DatasetProject string
+	// Debug prints the generated code before and after formatting.
+	Debug bool
+	// EmitAllColumns generates a package-level const block of the column names of all tables.
+	EmitAllColumns bool
+	// EmitBQTypeComment comments each field with the BigQuery type and mode of the column. e.g. `// BQ: INTEGER NULLABLE`
+	EmitBQTypeComment bool
+	// EmitClustered annotates fields that are part of the clustering key with a `// clustered` comment.
+	EmitClustered bool
+	// EmitColumnMeta generates a `<Struct>Columns` slice of bqmeta.ColumnMeta per struct.
+	EmitColumnMeta bool
+	// EmitCompareSchema generates a `CompareSchema` method per struct that fetches the live table metadata and returns the differences as []bqmeta.SchemaDiff.
+	EmitCompareSchema bool
+	// EmitConsoleLinks adds a link to the table in the BigQuery console to the struct comment.
+	EmitConsoleLinks bool
+	// EmitFieldTypes generates a `<Struct>FieldTypes` map from field name to bigquery.FieldType per struct.
+	EmitFieldTypes bool
+	// EmitGeneratedFrom generates a `GeneratedFrom` var of the project and the dataset of the generated tables, and GeneratedAt.
+	EmitGeneratedFrom bool
+	// EmitInUTC generates an `InUTC` method per struct that sets the location of the TIMESTAMP columns to UTC.
+	EmitInUTC bool
+	// EmitInserter generates an `Insert<Struct>` function per struct that streams rows into the table by bigquery.Inserter.
+	EmitInserter bool
+	// EmitModeTags adds a `mode` tag of REQUIRED, NULLABLE or REPEATED to the fields, for custom loaders that enforce nullability.
+	EmitModeTags bool
+	// EmitOrdinal adds `ordinal:"<N>"` tags, the zero-based position of the column in the table schema regardless of FieldGroup.
+	EmitOrdinal bool
+	// EmitRowInterface generates a `Row` interface implemented by all the structs, and a `RowDecoders` map from table ID to the decoder of the rows into the struct.
+	EmitRowInterface bool
+	// EmitSelect generates a `SelectQuery` method per struct that returns a SELECT of the columns of the struct.
+	EmitSelect bool
+	// EmitStructID generates a `<Struct>StructID` const per struct, a stable short ID derived from the dataset and table IDs.
+	EmitStructID bool
+	// EmitTypeRegistry generates a `TableTypes` map from table ID to reflect.Type of the struct.
+	EmitTypeRegistry bool
+	// EmbedPatterns selects the columns to factor into an embedded `<Struct>Metadata` struct.
+	EmbedPatterns []EmbedPattern
+	// EmitValidate generates a `Validate` method per struct (including nested ones) that returns an error naming the REQUIRED columns whose fields are nil.
+	EmitValidate bool
+	// EmitValueMap generates a `ToValueMap` method and a `<Struct>FromValueMap` func per struct (including nested ones),
+	// to convert between the struct and map[string]bigquery.Value.
+	EmitValueMap bool
+	// EmitVersion generates a `SchemaVersion` const, a hash of the schemas of all generated tables.
+	EmitVersion bool
+	// EmitViewQuery comments the structs of views with the defining queries.
+	EmitViewQuery bool
+	// FirestoreTags adds `firestore` tags with the column names to the fields. Pseudo columns get `firestore:"-"`.
+	FirestoreTags bool
+	// ViewQueryMaxLines is the max number of lines of the defining query with EmitViewQuery. 0 is unlimited.
+	ViewQueryMaxLines int
+	// ExtraImports is the import specs added to the import block. e.g. `_ "github.com/lib/pq"`
+	ExtraImports []string
+	// FailOnUnsupported makes Generate fail instead of skipping the table when a column of an unsupported type is found.
+	FailOnUnsupported bool
+	// Immutable also generates a `<Struct>Immutable` type per struct, with unexported fields, getters, a constructor and a conversion from the struct.
+	// The struct itself is kept as the DTO that the bigquery client decodes rows into.
+	Immutable bool
+	// InitNumericZero initializes the nil `*big.Rat` arguments of the `New<Struct>Immutable` constructors to zero,
+	// so that arithmetic on the values of the getters does not panic.
+	InitNumericZero bool
+	// Implements is the interfaces that the generated structs must implement. A compile-time assertion is generated per struct.
+	Implements []GoType
+	// NoGoimports skips the goimports pass (imports.Process), which resolves packages and is relatively slow, and uses the generated import block as it is.
+	NoGoimports bool
+	// OutputFormat is the format of the generated code. OutputFormatGo or OutputFormatProto.
+	OutputFormat string
+	// FieldGroup groups the struct fields. FieldGroupMode or empty (schema order).
+	FieldGroup string
+	// GeneratedAt is the time in the `GeneratedFrom` var of EmitGeneratedFrom. The zero value is left zero.
+	GeneratedAt time.Time
+	// GormTags adds `gorm:"column:<name>"` tags.
+	GormTags bool
+	// Initialisms converts snake_case column names to CamelCase field names with Go initialisms. e.g. `customer_id` to `CustomerID`
+	// If false, only the initial letter of the column name is capitalized. e.g. `customer_id` to `Customer_id`
+	Initialisms bool
+	// MaxNameLength is the maximum length of the struct and field names. Longer names are cut and suffixed with a hash of the full name.
+	// The `bigquery` tags keep the column names. 0 means no limit.
+	MaxNameLength int
+	// NestedNameTemplate is the template of the names of the structs generated for RECORD columns.
+	// If nil, the name is `<Parent><Field>`. See nestedNameTemplateData for the data passed to it.
+	NestedNameTemplate *template.Template
+	// NameExceptions maps lower-cased snake_case segments to the casings of the field names with Initialisms,
+	// which take precedence over the initialisms. e.g. `ios` to `IOS`
+	NameExceptions map[string]string
+	// NullTypes generates the bigquery Null* types (e.g. bigquery.NullTimestamp) for NULLABLE scalar columns, and pointers
+	// for NULLABLE RECORD columns, which are the types the bigquery client loads NULL into. It does not generate pointers
+	// to the scalar types, because the bigquery client does not load into them. []byte and *big.Rat stay as they are,
+	// since they are nil for NULL.
+	NullTypes bool
+	// NullTypesFor is the same as NullTypes, but only for the BigQuery types in it. e.g. the bigquery.NullTimestamp of
+	// NULLABLE TIMESTAMP columns for TIMESTAMP, and the pointers of NULLABLE RECORD columns for RECORD
+	NullTypesFor map[bigquery.FieldType]bool
+	// ProtoNumbers is the protobuf field numbers of the columns, which are added as
+	// `protobuf` tags. The columns not in it have no `protobuf` tags.
+	ProtoNumbers map[*bigquery.FieldSchema]int
+	// PreservedStructs is the code of the tables kept as it is in the Go output instead of being generated,
+	// keyed by table ID. e.g. the tables not modified since the last generation
+	PreservedStructs map[string]PreservedStruct
+	// ReceiverStyle is the style of the receiver names of the generated methods. ReceiverStyleFull or
+	// ReceiverStyleShort (empty is the same).
+	ReceiverStyle string
+	// RegisterFunc is the function each struct is registered with in a generated init(). e.g. `mypkg.Register`
+	// It is called as `mypkg.Register("users", Users{})`. The zero value disables the init().
+	RegisterFunc GoType
+	// SpannerTags adds `spanner` tags with the PascalCase column names of Cloud Spanner conventions to the fields.
+	SpannerTags bool
+	// StrictCase documents in the struct comments that the `bigquery` tags are the exact-case column names.
+	StrictCase bool
+	// TableOverrides is the per-table overrides keyed by table ID.
+	TableOverrides map[string]TableOverride
+	// TableColumns is the number of the top-level columns of each table before TableOverrides filter them, keyed by
+	// table ID. With Debug, the structs with fewer fields are warned about. The tables not in it are not checked.
+	TableColumns map[string]int
+	// ExcludeAsIgnored keeps the columns TableOverrides filter out in the structs as fields tagged `bigquery:"-"`,
+	// which the bigquery client does not load. The other generated code does not refer to them.
+	ExcludeAsIgnored bool
+	// IgnoredColumns is the top-level columns TableOverrides filter out of each table, keyed by table ID.
+	// PrepareSchemas sets it if ExcludeAsIgnored is set.
+	IgnoredColumns map[string]bigquery.Schema
+	// TypeAliases maps the upper-cased type names of non-standard sources to the BigQuery field types, which replace
+	// them in the schemas before the types are mapped to Go types. e.g. `DATETIME2` to `DATETIME`
+	TypeAliases map[bigquery.FieldType]bigquery.FieldType
+	// TypeOverrides maps BigQuery field types to the Go types to generate instead of the default ones.
+	TypeOverrides map[bigquery.FieldType]GoType
+	// NullableTypeOverrides is the same as TypeOverrides, but is applied only to NULLABLE columns.
+	// It takes precedence over TypeOverrides and NullTypes. The types must be the ones the bigquery client
+	// loads NULL into, e.g. bigquery.NullTimestamp for TIMESTAMP.
+	NullableTypeOverrides map[bigquery.FieldType]GoType
+	// UnsupportedAsAny generates `interface{}` fields for columns of unsupported types instead of skipping the table.
+	UnsupportedAsAny bool
+	// YAMLTags adds `yaml` tags with the column names to the fields.
+	YAMLTags bool
+}
+
+// EmbedPattern matches columns by type and name.
+type EmbedPattern struct {
+	// Type is the BigQuery field type of the columns. If empty, columns of any type match.
+	Type bigquery.FieldType
+	// Pattern is a glob pattern of the column names. e.g. `*_at`
+	Pattern string
+}
+
+// GoType is a Go type referenced by the generated code.
+type GoType struct {
+	// Name is the type as written in the generated code. e.g. `bigquery.NullTimestamp`
+	Name string
+	// PkgPath is the import path of the package that declares the type. e.g. `cloud.google.com/go/bigquery`
+	PkgPath string
+}
+
+// importSpec returns PkgPath to be imported, with the package name if the package name in Name differs from
+// the last element of PkgPath. e.g. `geom "github.com/twpayne/go-geom"` for `geom.T`
+func (t GoType) importSpec() (spec string) {
+	if t.PkgPath == "" {
+		return ""
+	}
+	qualified := strings.TrimLeft(t.Name, "*[]")
+	idx := strings.Index(qualified, ".")
+	if idx < 0 || qualified[:idx] == path.Base(t.PkgPath) {
+		return t.PkgPath
+	}
+	return qualified[:idx] + " " + strconv.Quote(t.PkgPath)
+}
+
+// TableSchema is a table and its metadata.
+type TableSchema struct {
+	Table    *bigquery.Table
+	Metadata *bigquery.TableMetadata
+}
+
+// Generate generates the Go code for all tables in the dataset. debug enables the debug logs.
+// The other options are the defaults. Use GenerateWithOptions for them.
+func Generate(ctx context.Context, client *bigquery.Client, dataset string, debug bool) (generatedCode []byte, err error) {
+	return GenerateWithOptions(ctx, client, dataset, Options{Debug: debug})
+}
+
+// GenerateWithOptions generates the code in opts.OutputFormat for all tables in the dataset.
+func GenerateWithOptions(ctx context.Context, client *bigquery.Client, dataset string, opts Options) (generatedCode []byte, err error) {
+	var schemas []TableSchema
+	schemas, err = getAllTableSchemas(ctx, client, opts.DatasetProject, dataset)
+	if err != nil {
+		return nil, fmt.Errorf("getAllTableSchemas: %w", err)
+	}
+
+	return GenerateCode(PrepareSchemas(schemas, &opts), opts)
+}
+
+// GenerateStruct generates the Go struct named name for the schema, and the code opts enables for it,
+// without the package clause and the import block. It does not access BigQuery.
+// The type aliases in schema (e.g. INT64), and the ones in opts.TypeAliases, are normalized in place.
+func GenerateStruct(name string, schema bigquery.Schema, opts Options) (generatedCode []byte, err error) {
+	NormalizeSchema(schema)
+	applyTypeAliases(schema, opts.TypeAliases)
+
+	table := &bigquery.Table{TableID: name}
+	md := &bigquery.TableMetadata{Name: name, FullID: name, Schema: schema}
+
+	var structCode string
+	structCode, _, err = generateNamedStructCode(name, table, md, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateNamedStructCode: %w", err)
+	}
+
+	generatedCode, err = format.Source([]byte(structCode))
+	if err != nil {
+		return nil, fmt.Errorf("format.Source: %w", err)
+	}
+
+	return generatedCode, nil
+}
+
+// GenerateCode renders the schemas in opts.OutputFormat.
+func GenerateCode(schemas []TableSchema, opts Options) (generatedCode []byte, err error) {
+	switch opts.OutputFormat {
+	case OutputFormatProto:
+		return generateProtoCode(schemas, opts)
+	case OutputFormatGoFake:
+		return generateFakeCode(schemas, opts)
+	default:
+		return generateGoCode(schemas, opts)
+	}
+}
+
+func generateGoCode(schemas []TableSchema, opts Options) (generatedCode []byte, err error) {
+	var files map[string][]byte
+	files, err = GenerateGoFiles(schemas, "", nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateGoFiles: %w", err)
+	}
+
+	return files[""], nil
+}
+
+// GenerateGoFiles is the same as generateGoCode, but generates the structs of the tables in tableOutputs
+// (table ID to file path) into the files of the paths instead of the file of mainPath.
+// The package-level declarations (e.g. the type registry) are generated into the file of mainPath for all the tables.
+// It returns the generated code by file path.
+func GenerateGoFiles(schemas []TableSchema, mainPath string, tableOutputs map[string]string, opts Options) (files map[string][]byte, err error) {
+	const head = `// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.
+
+`
+	// go generate runs the directive of every file, so only the main file has it.
+	const generateDirective = `//go:generate go run github.com/ginokent/bqschema-gen-go
+
+`
+	const packageClause = `package bqschema
+
+`
+
+	tails := map[string]string{mainPath: ""}
+	importPackages := map[string][]string{mainPath: nil}
+	for _, filePath := range tableOutputs {
+		tails[filePath] = ""
+		importPackages[filePath] = nil
+	}
+
+	var generatedTables []*bigquery.Table
+	var generatedSchemas []TableSchema
+	for _, schema := range schemas {
+		table := schema.Table
+
+		var structCode string
+		var pkgs []string
+		if preserved, exist := opts.PreservedStructs[table.TableID]; exist {
+			// The blank lines around the code are reduced to one by gofmt
+			structCode, pkgs = "\n"+preserved.Code+"\n", preserved.ImportPackages
+		} else {
+			structCode, pkgs, err = generateStructCode(table, schema.Metadata, opts)
+		}
+		if err != nil {
+			if opts.FailOnUnsupported && errors.Is(err, ErrFieldTypeNotSupported) {
+				return nil, fmt.Errorf("generateStructCode: table=%s.%s.%s: %w", table.ProjectID, table.DatasetID, table.TableID, err)
+			}
+			warnln("generateStructCode: " + err.Error())
+			continue
+		}
+
+		filePath, exist := tableOutputs[table.TableID]
+		if !exist {
+			filePath = mainPath
+		}
+		if len(pkgs) > 0 {
+			importPackages[filePath] = append(importPackages[filePath], pkgs...)
+		}
+		tails[filePath] = tails[filePath] + structCode
+		generatedTables = append(generatedTables, table)
+		generatedSchemas = append(generatedSchemas, schema)
+	}
+
+	tail := tails[mainPath]
+	mainImportPackages := importPackages[mainPath]
+
+	if opts.EmitTypeRegistry {
+		tail = tail + generateTypeRegistryCode(generatedTables, opts)
+		mainImportPackages = append(mainImportPackages, "reflect")
+	}
+
+	if opts.EmitRowInterface {
+		tail = tail + generateRowInterfaceCode(generatedTables, opts)
+		mainImportPackages = append(mainImportPackages, bigqueryPkgPath)
+	}
+
+	if opts.RegisterFunc.Name != "" && len(generatedTables) > 0 {
+		tail = tail + generateRegisterFuncCode(generatedTables, opts)
+		if spec := opts.RegisterFunc.importSpec(); spec != "" {
+			mainImportPackages = append(mainImportPackages, spec)
+		}
+	}
+
+	if opts.EmitAllColumns && len(generatedSchemas) > 0 {
+		tail = tail + generateAllColumnsCode(generatedSchemas, opts)
+	}
+
+	if opts.EmitVersion {
+		tail = tail + generateSchemaVersionCode(generatedSchemas)
+	}
+
+	if opts.EmitGeneratedFrom && len(generatedTables) > 0 {
+		tail = tail + generateGeneratedFromCode(generatedTables[0], opts.GeneratedAt)
+		mainImportPackages = append(mainImportPackages, "time")
+	}
+
+	tails[mainPath] = tail
+	importPackages[mainPath] = mainImportPackages
+
+	files = make(map[string][]byte, len(tails))
+	for filePath, tail := range tails {
+		header := head
+		if filePath == mainPath {
+			header = header + generateDirective
+		}
+
+		// NOTE(ginokent): combine
+		code := header + packageClause + generateImportPackagesCode(append(importPackages[filePath], opts.ExtraImports...)) + tail
+
+		files[filePath], err = formatGoCode(code, opts)
+		if err != nil {
+			return nil, fmt.Errorf("formatGoCode: %s: %w", filePath, err)
+		}
+	}
+
+	return files, nil
+}
+
+// formatGoCode formats the generated Go code, and processes the imports by goimports unless opts.NoGoimports.
+func formatGoCode(code string, opts Options) (generatedCode []byte, err error) {
+	if opts.Debug {
+		fmt.Println(">>>> DEBUG >>>>>>>>>>>>>>>>")
+		fmt.Println(code)
+		fmt.Println("<<<< DEBUG <<<<<<<<<<<<<<<<")
+	}
+
+	gen := []byte(code)
+
+	genFmt, err := format.Source(gen)
+	if err != nil {
+		return nil, fmt.Errorf("format.Source: %w", err)
+	}
+
+	if opts.Debug {
+		fmt.Println(">>>> DEBUG >>>>>>>>>>>>>>>>")
+		fmt.Println(string(genFmt))
+		fmt.Println("<<<< DEBUG <<<<<<<<<<<<<<<<")
+	}
+
+	// The import block is built from the packages the generated code refers to,
+	// so goimports only sorts and groups it.
+	if opts.NoGoimports {
+		return genFmt, nil
+	}
+
+	genImports, err := imports.Process("", genFmt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("imports.Process: %w", err)
+	}
+
+	return genImports, nil
+}
+
+func generateImportPackagesCode(importPackages []string) (generatedCode string) {
+	importPackagesUniq := make(map[string]bool)
+	for _, pkg := range importPackages {
+		importPackagesUniq[pkg] = true
+	}
+
+	// NOTE(ginokent): fix order
+	importPackagesUniqSort := make([]string, len(importPackagesUniq))
+	idx := 0
+	for _, pkg := range importPackages {
+		if importPackagesUniq[pkg] {
+			importPackagesUniq[pkg] = false
+			importPackagesUniqSort[idx] = pkg
+			idx++
+		}
+	}
+
+	switch {
+	case len(importPackagesUniq) == 0:
+		generatedCode = ""
+	case len(importPackagesUniq) == 1:
+		for pkg := range importPackagesUniq {
+			generatedCode = "import " + importSpecCode(pkg) + "\n"
+		}
+		generatedCode = generatedCode + "\n"
+	case len(importPackagesUniq) >= 2:
+		generatedCode = "import (\n"
+		for _, pkg := range importPackagesUniqSort {
+			generatedCode = generatedCode + "\t" + importSpecCode(pkg) + "\n"
+		}
+		generatedCode = generatedCode + ")\n\n"
+	}
+
+	return generatedCode
+}
+
+// importSpecCode quotes the import path, unless pkg is already an import spec with the package name returned by GoType.importSpec.
+func importSpecCode(pkg string) (code string) {
+	if strings.Contains(pkg, "\"") {
+		return pkg
+	}
+	return "\"" + pkg + "\""
+}
+
+func getTableMetadata(ctx context.Context, table *bigquery.Table) (md *bigquery.TableMetadata, err error) {
+	if len(table.TableID) == 0 {
+		return nil, fmt.Errorf("*bigquery.Table.TableID is empty. *bigquery.Table struct dump: %#v", table)
+	}
+
+	md, err = table.Metadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("table.Metadata: %w", err)
+	}
+
+	NormalizeSchema(md.Schema)
+
+	return md, nil
+}
+
+// fieldTypeAliases maps the alternative spellings of the types accepted by BigQuery, including the standard SQL names,
+// to the bigquery.FieldType constants.
+// ref. https://cloud.google.com/bigquery/docs/reference/standard-sql/data-types
+var fieldTypeAliases = map[bigquery.FieldType]bigquery.FieldType{
+	"BOOL":     bigquery.BooleanFieldType,
+	"INT64":    bigquery.IntegerFieldType,
+	"INT":      bigquery.IntegerFieldType,
+	"SMALLINT": bigquery.IntegerFieldType,
+	"BIGINT":   bigquery.IntegerFieldType,
+	"TINYINT":  bigquery.IntegerFieldType,
+	"BYTEINT":  bigquery.IntegerFieldType,
+	"FLOAT64":  bigquery.FloatFieldType,
+	"DECIMAL":  bigquery.NumericFieldType,
+	"STRUCT":   bigquery.RecordFieldType,
+	// cloud.google.com/go/bigquery has no constant for BIGNUMERIC yet, so it stays unsupported.
+	"BIGDECIMAL": "BIGNUMERIC",
+}
+
+// NormalizeFieldType returns the bigquery.FieldType constant for an alternative spelling of the type,
+// case-insensitively. e.g. `BOOL` or `bool` to `BOOLEAN`
+// Other types are returned as they are, except for the case.
+func NormalizeFieldType(fieldType bigquery.FieldType) (normalized bigquery.FieldType) {
+	fieldType = bigquery.FieldType(strings.ToUpper(string(fieldType)))
+	if normalized, ok := fieldTypeAliases[fieldType]; ok {
+		return normalized
+	}
+	return fieldType
+}
+
+// NormalizeSchema replaces the alternative spellings of the types in the schema, including in RECORD columns, in place.
+func NormalizeSchema(schema bigquery.Schema) {
+	for _, field := range schema {
+		field.Type = NormalizeFieldType(field.Type)
+		NormalizeSchema(field.Schema)
+	}
+}
+
+func generateStructCode(table *bigquery.Table, md *bigquery.TableMetadata, opts Options) (generatedCode string, importPackages []string, err error) {
+	tableID := table.TableID
+
+	if strings.Contains(tableID, "-") {
+		replaced := strings.ReplaceAll(tableID, "-", "_")
+		warnln(fmt.Sprintf("tableID `%s` contains invalid character `-`. replacing `%s` to `%s`", tableID, tableID, replaced))
+	}
+
+	structName := goStructName(tableID, opts)
+	if fullName := tableIDToStructName(tableID); opts.TableOverrides[tableID].StructName == "" && structName != fullName {
+		warnln(fmt.Sprintf("struct name `%s` is longer than the max name length %d. truncating to `%s`", fullName, opts.MaxNameLength, structName))
+	}
+
+	return generateNamedStructCode(structName, table, md, opts)
+}
+
+// generateNamedStructCode is the same as generateStructCode, but generates the struct named structName.
+func generateNamedStructCode(structName string, table *bigquery.Table, md *bigquery.TableMetadata, opts Options) (generatedCode string, importPackages []string, err error) {
+	tableID := table.TableID
+
+	clusteringFields := make(map[string]bool)
+	if md.Clustering != nil {
+		for _, field := range md.Clustering.Fields {
+			// BigQuery column names are case-insensitive.
+			clusteringFields[strings.ToLower(field)] = true
+		}
+	}
+
+	// NOTE(ginokent): structs
+	generatedCode = "// " + structName + " is BigQuery Table `" + md.FullID + "` schema struct.\n" +
+		descriptionComment(md.Description)
+	if opts.EmitConsoleLinks {
+		generatedCode = generatedCode + "// Console: " + consoleLink(table) + "\n"
+	}
+	if opts.EmitViewQuery && md.ViewQuery != "" {
+		generatedCode = generatedCode + viewQueryComment(md.ViewQuery, opts.ViewQueryMaxLines)
+	}
+	// The bigquery client matches the fields case-insensitively, but some loaders do not.
+	if opts.StrictCase {
+		generatedCode = generatedCode + "// The `bigquery` tags are the exact-case column names. Match them case-sensitively.\n"
+	}
+	generatedCode = generatedCode + "type " + structName + " struct {\n"
+
+	embeddedStructName := structName + "Metadata"
+	var fieldsCode, embeddedFieldsCode string
+
+	// The position in the table schema, which is kept even if the fields are reordered
+	ordinals := make(map[*bigquery.FieldSchema]int)
+	for i, schema := range md.Schema {
+		ordinals[schema] = i
+	}
+
+	schemas := []*bigquery.FieldSchema(md.Schema)
+	if opts.FieldGroup == FieldGroupMode {
+		schemas = groupFieldsByMode(schemas)
+	}
+
+	var fields []goField
+	var nestedStructsCode string
+	for _, schema := range schemas {
+		var goTypeStr, nestedCode string
+		var pkgs []string
+		goTypeStr, nestedCode, pkgs, err = fieldGoType(structName, schema, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("fieldGoType: %w", err)
+		}
+		importPackages = append(importPackages, pkgs...)
+		nestedStructsCode = nestedStructsCode + nestedCode
+
+		var comments []string
+		if opts.EmitClustered && clusteringFields[strings.ToLower(schema.Name)] {
+			comments = append(comments, "clustered")
+		}
+
+		warnTruncatedFieldName(schema.Name, opts)
+		if sanitizeIdentifier(schema.Name) != schema.Name {
+			warnln(fmt.Sprintf("column `%s` of table `%s` contains characters that are invalid in Go identifiers. replacing them with `_` in the field name", schema.Name, tableID))
+		}
+		if strconv.Quote(schema.Name) != "\""+schema.Name+"\"" || strings.Contains(schema.Name, "`") {
+			warnln(fmt.Sprintf("column `%s` of table `%s` contains characters that need escaping in struct tags", schema.Name, tableID))
+		}
+
+		tags, tagComments := fieldTags(schema, ordinals[schema], opts)
+		tags = overrideColumnTags(tags, schema.Name, opts.TableOverrides[tableID])
+		comments = append(comments, tagComments...)
+
+		fields = append(fields, goField{Name: goFieldName(schema.Name, opts), Type: goTypeStr, Column: schema.Name, Schema: schema})
+
+		fieldCode := fieldDocComment(schema, opts) +
+			"\t" + goFieldName(schema.Name, opts) + " " + goTypeStr + " " + structTagLiteral(tags)
+		if len(comments) > 0 {
+			fieldCode = fieldCode + " // " + strings.Join(comments, ", ")
+		}
+		fieldCode = fieldCode + "\n"
+
+		if matchEmbedPatterns(schema, opts.EmbedPatterns) {
+			embeddedFieldsCode = embeddedFieldsCode + fieldCode
+		} else {
+			fieldsCode = fieldsCode + fieldCode
+		}
+	}
+
+	// The ignored columns are only declared. Select, ValueMap etc. never see them.
+	ignoredColumns := opts.IgnoredColumns[tableID]
+	if len(ignoredColumns) > 0 {
+		fieldsCode = fieldsCode + "\n" +
+			"\t// The columns excluded by the overrides, which are not loaded.\n"
+	}
+	for _, schema := range ignoredColumns {
+		var goTypeStr, nestedCode string
+		var pkgs []string
+		goTypeStr, nestedCode, pkgs, err = fieldGoType(structName, schema, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("fieldGoType: %w", err)
+		}
+		importPackages = append(importPackages, pkgs...)
+		nestedStructsCode = nestedStructsCode + nestedCode
+
+		fieldsCode = fieldsCode + fieldDocComment(schema, opts) +
+			"\t" + goFieldName(schema.Name, opts) + " " + goTypeStr + " `bigquery:\"-\"` // column " + schema.Name + "\n"
+	}
+
+	if embeddedFieldsCode != "" {
+		generatedCode = generatedCode + "\t" + embeddedStructName + "\n"
+	}
+	generatedCode = generatedCode + fieldsCode + "}\n"
+
+	if embeddedFieldsCode != "" {
+		generatedCode = generatedCode + "\n" +
+			"// " + embeddedStructName + " is the metadata columns of BigQuery Table `" + md.FullID + "` embedded in " + structName + ".\n" +
+			"type " + embeddedStructName + " struct {\n" +
+			embeddedFieldsCode +
+			"}\n"
+	}
+
+	generatedCode = generatedCode + nestedStructsCode
+
+	if opts.Immutable {
+		generatedCode = generatedCode + "\n" + generateImmutableCode(structName, fields, opts)
+	}
+
+	if opts.EmitValueMap {
+		valueMapCode, pkgs := generateValueMapCode(structName, fields, opts)
+		generatedCode = generatedCode + "\n" + valueMapCode
+		importPackages = append(importPackages, pkgs...)
+	}
+
+	if opts.EmitValidate {
+		validateCode, pkgs := generateValidateCode(structName, fields, opts)
+		generatedCode = generatedCode + "\n" + validateCode
+		importPackages = append(importPackages, pkgs...)
+	}
+
+	if opts.EmitStructID {
+		generatedCode = generatedCode + "\n" +
+			"// " + structName + "StructID is a stable short ID of BigQuery Table `" + md.FullID + "`.\n" +
+			"const " + structName + "StructID = " + strconv.Quote(structID(table.DatasetID, tableID)) + "\n"
+	}
+
+	if opts.EmitColumnMeta {
+		generatedCode = generatedCode + "\n" + generateColumnMetaCode(structName, md, opts)
+		importPackages = append(importPackages, bqmetaPkgPath)
+	}
+
+	if opts.EmitFieldTypes {
+		generatedCode = generatedCode + "\n" + generateFieldTypesCode(structName, md, opts)
+		importPackages = append(importPackages, bigqueryPkgPath)
+	}
+
+	if opts.EmitCompareSchema {
+		generatedCode = generatedCode + "\n" + generateCompareSchemaCode(structName, table, md, opts)
+		importPackages = append(importPackages, "context", "fmt", bigqueryPkgPath, bqmetaPkgPath)
+	}
+
+	if opts.EmitInUTC {
+		if inUTCCode := generateInUTCCode(structName, fields, opts); inUTCCode != "" {
+			generatedCode = generatedCode + "\n" + inUTCCode
+		}
+	}
+
+	if setColumns := opts.TableOverrides[tableID].SetColumns; len(setColumns) > 0 {
+		if setCode := generateSetCode(tableID, structName, fields, setColumns, opts); setCode != "" {
+			generatedCode = generatedCode + setCode
+			importPackages = append(importPackages, "sort")
+		}
+	}
+
+	if opts.EmitSelect {
+		generatedCode = generatedCode + "\n" + generateSelectQueryCode(structName, table, md)
+	}
+
+	if opts.EmitInserter {
+		generatedCode = generatedCode + "\n" + generateInserterCode(structName, table, md)
+		importPackages = append(importPackages, "context", "fmt", bigqueryPkgPath)
+	}
+
+	if opts.EmitRowInterface {
+		generatedCode = generatedCode + "\n" +
+			"// isBQRow implements Row.\n" +
+			"func (" + structName + ") isBQRow() {}\n"
+	}
+
+	if len(opts.Implements) > 0 {
+		generatedCode = generatedCode + "\n" + generateImplementsCode(structName, opts.Implements)
+		for _, iface := range opts.Implements {
+			if spec := iface.importSpec(); spec != "" {
+				importPackages = append(importPackages, spec)
+			}
+		}
+	}
+
+	// Sanity check that no column has been dropped from the struct, e.g. by TableOverrides.
+	if columns, ok := opts.TableColumns[tableID]; ok && opts.Debug && len(fields)+len(ignoredColumns) < columns {
+		warnln(fmt.Sprintf("struct `%s` has %d fields, but BigQuery Table `%s` has %d columns", structName, len(fields)+len(ignoredColumns), md.FullID, columns))
+	}
+
+	return generatedCode, importPackages, nil
+}
+
+// descriptionComment returns the Description line of the doc comment, or an empty string if the description is empty.
+func descriptionComment(description string) (comment string) {
+	if strings.TrimSpace(description) == "" {
+		return ""
+	}
+	return "// Description: " + description + "\n"
+}
+
+// viewQueryComment returns the defining query of the view as a comment, truncated to maxLines lines unless maxLines is 0.
+func viewQueryComment(query string, maxLines int) (comment string) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(query, "\r\n", "\n")), "\n")
+	var truncated bool
+	if maxLines > 0 && len(lines) > maxLines {
+		lines, truncated = lines[:maxLines], true
+	}
+
+	comment = "// View query:\n"
+	for _, line := range lines {
+		comment = comment + "//\t" + strings.TrimRight(line, " \t") + "\n"
+	}
+	if truncated {
+		comment = comment + "//\t...\n"
+	}
+
+	return comment
+}
+
+// fieldTags returns the struct tags of the field generated for the column, and the comments on the field.
+// ordinal is the position of the column in the table schema, or in the RECORD column.
+func fieldTags(schema *bigquery.FieldSchema, ordinal int, opts Options) (tags []string, comments []string) {
+	if isPseudoColumn(schema.Name) {
+		comments = append(comments, "pseudo column")
+	}
+	// REPEATED columns are not NULL, but empty.
+	if opts.AnnotateNullable && !schema.Required && !schema.Repeated {
+		comments = append(comments, "nullable")
+	}
+	if opts.AnnotateUTC && schema.Type == bigquery.TimestampFieldType {
+		comments = append(comments, "UTC")
+	}
+	if _, _, err := BigQueryFieldTypeToGoType(schema.Type); opts.UnsupportedAsAny && schema.Type != bigquery.RecordFieldType && errors.Is(err, ErrFieldTypeNotSupported) {
+		comments = append(comments, "unsupported BigQuery type: "+string(schema.Type))
+	}
+
+	tags = []string{"bigquery:" + strconv.Quote(schema.Name)}
+	if opts.GormTags {
+		tags = append(tags, "gorm:"+strconv.Quote("column:"+schema.Name))
+	}
+	if opts.AvroTags {
+		name, sanitized := avroName(schema.Name)
+		if sanitized {
+			comments = append(comments, "avro: `"+schema.Name+"` is not a valid Avro name")
+		}
+		tags = append(tags, "avro:\""+name+"\"")
+	}
+	if opts.YAMLTags {
+		tags = append(tags, "yaml:"+strconv.Quote(schema.Name))
+	}
+	if opts.BSONTags {
+		name := schema.Name
+		// BigQuery column names are case-insensitive.
+		if opts.BSONIDColumn != "" && strings.EqualFold(schema.Name, opts.BSONIDColumn) {
+			name = "_id"
+		}
+		tags = append(tags, "bson:"+strconv.Quote(name))
+	}
+	if opts.FirestoreTags {
+		name := schema.Name
+		// Pseudo columns are not stored in the table, so they are not mirrored either.
+		if isPseudoColumn(schema.Name) {
+			name = "-"
+		}
+		tags = append(tags, "firestore:"+strconv.Quote(name))
+	}
+	if opts.SpannerTags {
+		tags = append(tags, "spanner:"+strconv.Quote(spannerColumnName(schema.Name, opts)))
+	}
+	if opts.EmitOrdinal {
+		tags = append(tags, "ordinal:\""+strconv.Itoa(ordinal)+"\"")
+	}
+	// A separate tag, because cloud.google.com/go/bigquery rejects unknown options in the bigquery tag.
+	if opts.EmitModeTags {
+		tags = append(tags, "mode:\""+fieldMode(schema)+"\"")
+	}
+	if number, exist := opts.ProtoNumbers[schema]; exist {
+		tags = append(tags, "protobuf:\""+strconv.Itoa(number)+"\"")
+	}
+	return tags, comments
+}
+
+// pseudoColumnPrefixes is the prefixes reserved for the pseudo columns, which regular columns cannot have.
+// ref. https://cloud.google.com/bigquery/docs/schemas#column_names
+var pseudoColumnPrefixes = []string{"_PARTITION", "_TABLE_", "_FILE_", "_ROW_TIMESTAMP"}
+
+// isPseudoColumn returns true if the column is a pseudo column, e.g. `_PARTITIONTIME`, which is not stored in the table
+// but derived by BigQuery.
+func isPseudoColumn(name string) bool {
+	// BigQuery column names are case-insensitive.
+	upper := strings.ToUpper(name)
+	for _, prefix := range pseudoColumnPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldMode returns the mode of the column as in the BigQuery table schema.
+func fieldMode(schema *bigquery.FieldSchema) (mode string) {
+	switch {
+	case schema.Repeated:
+		return "REPEATED"
+	case schema.Required:
+		return "REQUIRED"
+	default:
+		return "NULLABLE"
+	}
+}
+
+// fieldDocComment returns the doc comment of the field, or an empty string if it has none.
+// The deprecation comment is a separate paragraph, as Go requires.
+func fieldDocComment(schema *bigquery.FieldSchema, opts Options) (comment string) {
+	if opts.EmitBQTypeComment {
+		comment = "\t// BQ: " + string(schema.Type) + " " + fieldMode(schema) + "\n"
+	}
+	if deprecation := deprecationComment(schema.Description); deprecation != "" {
+		if comment != "" {
+			comment = comment + "\t//\n"
+		}
+		comment = comment + deprecation
+	}
+	return comment
+}
+
+// deprecationComment returns the Go deprecation comment of the field, if a line of the column description
+// starts with `Deprecated:` (case-insensitive), so that staticcheck reports the use of the field. Otherwise, it returns an empty string.
+func deprecationComment(description string) (comment string) {
+	const marker = "deprecated:"
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(strings.ToLower(line), marker) {
+			continue
+		}
+		if reason := strings.TrimSpace(line[len(marker):]); reason != "" {
+			return "\t// Deprecated: " + reason + "\n"
+		}
+		return "\t// Deprecated: the column is deprecated.\n"
+	}
+	return ""
+}
+
+// fieldGoType returns the Go type of the field generated for the column.
+// For a RECORD column, the type is a struct named by nestedStructName, and the code of the struct
+// (and of the structs of the RECORD columns in it) is returned as nestedCode.
+func fieldGoType(parentName string, schema *bigquery.FieldSchema, opts Options) (goTypeStr string, nestedCode string, importPackages []string, err error) {
+	if schema.Type != bigquery.RecordFieldType {
+		var pkg string
+		goTypeStr, pkg, err = fieldSchemaToGoType(schema, opts)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("fieldSchemaToGoType: column=%s: %w", schema.Name, err)
+		}
+		if pkg != "" {
+			importPackages = append(importPackages, pkg)
+		}
+		return goTypeStr, "", importPackages, nil
+	}
+
+	var structName string
+	structName, err = nestedStructName(parentName, schema.Name, opts)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("nestedStructName: column=%s: %w", schema.Name, err)
+	}
+
+	// `_id` is the primary key of the top-level document only.
+	recordOpts := opts
+	recordOpts.BSONIDColumn = ""
+
+	var fieldsCode, innerCode string
+	var fields []goField
+	for i, field := range schema.Schema {
+		var fieldType, code string
+		var pkgs []string
+		fieldType, code, pkgs, err = fieldGoType(structName, field, opts)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("fieldGoType: column=%s: %w", schema.Name, err)
+		}
+		importPackages = append(importPackages, pkgs...)
+		innerCode = innerCode + code
+
+		fields = append(fields, goField{Name: goFieldName(field.Name, opts), Type: fieldType, Column: field.Name, Schema: field})
+
+		warnTruncatedFieldName(field.Name, opts)
+		tags, comments := fieldTags(field, i, recordOpts)
+		fieldCode := fieldDocComment(field, opts) +
+			"\t" + goFieldName(field.Name, opts) + " " + fieldType + " " + structTagLiteral(tags)
+		if len(comments) > 0 {
+			fieldCode = fieldCode + " // " + strings.Join(comments, ", ")
+		}
+		fieldsCode = fieldsCode + fieldCode + "\n"
+	}
+
+	nestedCode = "\n" +
+		"// " + structName + " is the RECORD column `" + schema.Name + "` of " + parentName + ".\n" +
+		"type " + structName + " struct {\n" +
+		fieldsCode +
+		"}\n"
+	if opts.EmitValueMap {
+		valueMapCode, pkgs := generateValueMapCode(structName, fields, opts)
+		nestedCode = nestedCode + "\n" + valueMapCode
+		importPackages = append(importPackages, pkgs...)
+	}
+	if opts.EmitValidate {
+		validateCode, pkgs := generateValidateCode(structName, fields, opts)
+		nestedCode = nestedCode + "\n" + validateCode
+		importPackages = append(importPackages, pkgs...)
+	}
+	nestedCode = nestedCode + innerCode
+
+	goTypeStr = structName
+	switch {
+	case schema.Repeated:
+		goTypeStr = "[]" + goTypeStr
+	case !schema.Required && (opts.NullTypes || opts.NullTypesFor[schema.Type]):
+		goTypeStr = "*" + goTypeStr
+	}
+
+	return goTypeStr, nestedCode, importPackages, nil
+}
+
+// nestedStructName returns the name of the struct generated for the RECORD column.
+// It is `<Parent><Field>` unless opts.NestedNameTemplate is set.
+func nestedStructName(parentName, columnName string, opts Options) (name string, err error) {
+	fieldName := goFieldName(columnName, opts)
+	if opts.NestedNameTemplate == nil {
+		return truncateName(parentName+fieldName, opts.MaxNameLength), nil
+	}
+
+	var b strings.Builder
+	if err = opts.NestedNameTemplate.Execute(&b, nestedNameTemplateData{Parent: parentName, Field: fieldName, Column: columnName}); err != nil {
+		return "", fmt.Errorf("NestedNameTemplate.Execute: %w", err)
+	}
+	name = b.String()
+	if name == "" || sanitizeIdentifier(name) != name || !unicode.IsUpper([]rune(name)[0]) {
+		return "", fmt.Errorf("`%s` is not a valid exported Go type name", name)
+	}
+
+	return truncateName(name, opts.MaxNameLength), nil
+}
+
+// nestedNameTemplateData is the data passed to Options.NestedNameTemplate.
+type nestedNameTemplateData struct {
+	// Parent is the name of the struct that has the field. e.g. `Orders`
+	Parent string
+	// Field is the name of the field. e.g. `LineItems`
+	Field string
+	// Column is the name of the RECORD column. e.g. `line_items`
+	Column string
+}
+
+// ParseNestedNameTemplate parses the text of Options.NestedNameTemplate. It returns nil for an empty text.
+func ParseNestedNameTemplate(text string) (tmpl *template.Template, err error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err = template.New("NestedNameTemplate").Funcs(template.FuncMap{"singular": singular}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template.Parse: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// singular returns the naive English singular form of the plural word. e.g. `LineItems` to `LineItem`, `Categories` to `Category`
+func singular(plural string) (s string) {
+	switch {
+	case strings.HasSuffix(plural, "ies") && len(plural) > 3:
+		return strings.TrimSuffix(plural, "ies") + "y"
+	case strings.HasSuffix(plural, "sses"), strings.HasSuffix(plural, "xes"), strings.HasSuffix(plural, "ches"), strings.HasSuffix(plural, "shes"):
+		return strings.TrimSuffix(plural, "es")
+	case strings.HasSuffix(plural, "ss"), strings.HasSuffix(plural, "us"):
+		return plural
+	case strings.HasSuffix(plural, "s"):
+		return strings.TrimSuffix(plural, "s")
+	default:
+		return plural
+	}
+}
+
+// goField is a field of a generated struct.
+type goField struct {
+	Name   string
+	Type   string
+	Column string
+	Schema *bigquery.FieldSchema
+}
+
+// generateValueMapCode generates the `ToValueMap` method of the struct and the `<Struct>FromValueMap` func.
+// ToValueMap converts TIME, DATETIME and NUMERIC values to the strings the bigquery client uploads them as,
+// and omits NULL values. <Struct>FromValueMap takes the values as loaded by the bigquery client into
+// map[string]bigquery.Value, where a RECORD is a map[string]bigquery.Value and a REPEATED column is a []bigquery.Value.
+func generateValueMapCode(structName string, fields []goField, opts Options) (generatedCode string, importPackages []string) {
+	recv := receiverName(structName, opts.ReceiverStyle)
+
+	importPackages = []string{bigqueryPkgPath}
+	var toCode, fromCode string
+	for _, field := range fields {
+		// The type the value is loaded into may differ from the field type, e.g. time.Time for bigquery.NullTimestamp
+		if _, pkg, err := BigQueryFieldTypeToGoType(field.Schema.Type); err == nil && pkg != "" {
+			importPackages = append(importPackages, pkg)
+		}
+		toCode = toCode + valueMapToCode(field, recv+"."+field.Name)
+		fromCode = fromCode + valueMapFromCode(field, recv+"."+field.Name)
+	}
+
+	generatedCode = "// ToValueMap returns the values of the columns of " + structName + " to be inserted by the bigquery client.\n" +
+		"// NULL values are omitted.\n" +
+		"func (" + recv + " " + structName + ") ToValueMap() map[string]bigquery.Value {\n" +
+		"\tvalues := make(map[string]bigquery.Value)\n" +
+		toCode +
+		"\treturn values\n" +
+		"}\n" +
+		"\n" +
+		"// " + structName + "FromValueMap returns the " + structName + " of the values loaded by the bigquery client,\n" +
+		"// e.g. by RowIterator.Next(&map[string]bigquery.Value{}). Values of unexpected types are ignored.\n" +
+		"func " + structName + "FromValueMap(values map[string]bigquery.Value) " + structName + " {\n" +
+		"\tvar " + recv + " " + structName + "\n" +
+		fromCode +
+		"\treturn " + recv + "\n" +
+		"}\n"
+
+	return generatedCode, importPackages
+}
+
+// valueMapBaseType returns the Go type the bigquery client loads the column into,
+// or the nested struct for a RECORD column.
+func valueMapBaseType(field goField) (baseType string) {
+	if field.Schema.Type == bigquery.RecordFieldType {
+		return strings.TrimLeft(field.Type, "[]*")
+	}
+	baseType, _, _ = BigQueryFieldTypeToGoType(field.Schema.Type)
+	return baseType
+}
+
+// valueMapElemTo returns the expression that converts the element x of the field to a bigquery.Value,
+// and the condition under which x is not NULL, if any.
+func valueMapElemTo(field goField, elemType, x string) (cond string, expr string) {
+	baseType := valueMapBaseType(field)
+
+	convert := func(x string) string {
+		switch field.Schema.Type {
+		case bigquery.TimeFieldType:
+			return "bigquery.CivilTimeString(" + x + ")"
+		case bigquery.DateTimeFieldType:
+			return "bigquery.CivilDateTimeString(" + x + ")"
+		case bigquery.NumericFieldType:
+			return "bigquery.NumericString(" + x + ")"
+		case bigquery.RecordFieldType:
+			return x + ".ToValueMap()"
+		default:
+			return x
+		}
+	}
+
+	switch {
+	case elemType == baseType && strings.HasPrefix(baseType, "*"):
+		return x + " != nil", convert(x)
+	case elemType == baseType:
+		return "", convert(x)
+	case elemType == "*"+baseType && field.Schema.Type == bigquery.RecordFieldType:
+		return x + " != nil", convert(x)
+	case elemType == "*"+baseType:
+		return x + " != nil", convert("*" + x)
+	case nullTypes[field.Schema.Type] != nil && elemType == nullTypes[field.Schema.Type].String():
+		return x + ".Valid", convert(x + "." + nullTypeValueField(nullTypes[field.Schema.Type]))
+	default:
+		// Type overrides
+		return "", x
+	}
+}
+
+// valueMapToCode generates the statements that set the value of the field x to `values` in ToValueMap.
+func valueMapToCode(field goField, x string) (generatedCode string) {
+	key := strconv.Quote(field.Column)
+
+	if !field.Schema.Repeated {
+		cond, expr := valueMapElemTo(field, field.Type, x)
+		if cond == "" {
+			return "\tvalues[" + key + "] = " + expr + "\n"
+		}
+		return "\tif " + cond + " {\n" +
+			"\t\tvalues[" + key + "] = " + expr + "\n" +
+			"\t}\n"
+	}
+
+	cond, expr := valueMapElemTo(field, strings.TrimPrefix(field.Type, "[]"), "elem")
+	if cond == "" && expr == "elem" {
+		return "\tvalues[" + key + "] = " + x + "\n"
+	}
+	appendCode := "\t\t\telems = append(elems, " + expr + ")\n"
+	if cond != "" {
+		appendCode = "\t\t\tif " + cond + " {\n" +
+			"\t" + appendCode +
+			"\t\t\t}\n"
+	}
+	return "\tif " + x + " != nil {\n" +
+		"\t\telems := make([]bigquery.Value, 0, len(" + x + "))\n" +
+		"\t\tfor _, elem := range " + x + " {\n" +
+		appendCode +
+		"\t\t}\n" +
+		"\t\tvalues[" + key + "] = elems\n" +
+		"\t}\n"
+}
+
+// valueMapElemFrom returns the type to assert a loaded bigquery.Value to, and the statement that sets it to x.
+func valueMapElemFrom(field goField, elemType, x string) (assertType string, assignCode string) {
+	baseType := valueMapBaseType(field)
+
+	if field.Schema.Type == bigquery.RecordFieldType {
+		value := baseType + "FromValueMap(value)"
+		if elemType == "*"+baseType {
+			return "map[string]bigquery.Value", "nested := " + value + "\n" + x + " = &nested"
+		}
+		return "map[string]bigquery.Value", x + " = " + value
+	}
+
+	switch {
+	case elemType == baseType:
+		return baseType, x + " = value"
+	case elemType == "*"+baseType:
+		return baseType, x + " = &value"
+	case nullTypes[field.Schema.Type] != nil && elemType == nullTypes[field.Schema.Type].String():
+		return baseType, x + " = " + elemType + "{" + nullTypeValueField(nullTypes[field.Schema.Type]) + ": value, Valid: true}"
+	default:
+		// Type overrides
+		return elemType, x + " = value"
+	}
+}
+
+// valueMapFromCode generates the statements that set the field x from `values` in <Struct>FromValueMap.
+func valueMapFromCode(field goField, x string) (generatedCode string) {
+	key := strconv.Quote(field.Column)
+
+	if !field.Schema.Repeated {
+		assertType, assignCode := valueMapElemFrom(field, field.Type, x)
+		return "\tif value, ok := values[" + key + "].(" + assertType + "); ok {\n" +
+			"\t\t" + strings.ReplaceAll(assignCode, "\n", "\n\t\t") + "\n" +
+			"\t}\n"
+	}
+
+	elemType := strings.TrimPrefix(field.Type, "[]")
+	assertType, assignCode := valueMapElemFrom(field, elemType, "elem")
+	assignCode = strings.Replace(assignCode, "elem = ", x+" = append("+x+", ", 1) + ")"
+	return "\tif elems, ok := values[" + key + "].([]bigquery.Value); ok {\n" +
+		"\t\t" + x + " = make(" + field.Type + ", 0, len(elems))\n" +
+		"\t\tfor _, elem := range elems {\n" +
+		"\t\t\tif value, ok := elem.(" + assertType + "); ok {\n" +
+		"\t\t\t\t" + assignCode + "\n" +
+		"\t\t\t}\n" +
+		"\t\t}\n" +
+		"\t}\n"
+}
+
+// generateImmutableCode generates `<Struct>Immutable`, which has the same fields as the struct but unexported,
+// with a getter per field, a constructor taking all fields, and a `ToImmutable` method of the struct.
+// Slices are copied by the constructor and the getters, so that they cannot be modified through the struct.
+func generateImmutableCode(structName string, fields []goField, opts Options) (generatedCode string) {
+	immutableName := structName + "Immutable"
+	recv := receiverName(immutableName, opts.ReceiverStyle)
+	structRecv := receiverName(structName, opts.ReceiverStyle)
+
+	// The getters are named after the fields. A field that is not exported, e.g. `_PARTITIONTIME`, keeps its name
+	// as the unexported one, so the unexported names are suffixed with `_` until they collide with nothing.
+	names := make(map[string]bool)
+	for _, field := range fields {
+		names[field.Name] = true
+	}
+
+	var fieldsCode, paramsCode, initCode, assignCode, gettersCode, argsCode string
+	for i, field := range fields {
+		name := unexportedName(field.Name)
+		for names[name] {
+			name = name + "_"
+		}
+		names[name] = true
+		if opts.InitNumericZero && field.Type == typeOfRat.String() {
+			// Arithmetic on a nil *big.Rat panics
+			initCode = initCode + "\tif " + name + " == nil {\n" +
+				"\t\t" + name + " = big.NewRat(0, 1)\n" +
+				"\t}\n"
+		}
+		value := name
+		if strings.HasPrefix(field.Type, "[]") {
+			value = "append(" + field.Type + "(nil), " + name + "...)"
+		}
+		getterValue := recv + "." + name
+		if strings.HasPrefix(field.Type, "[]") {
+			getterValue = "append(" + field.Type + "(nil), " + recv + "." + name + "...)"
+		}
+
+		if i > 0 {
+			paramsCode = paramsCode + ", "
+			argsCode = argsCode + ", "
+		}
+		fieldsCode = fieldsCode + "\t" + name + " " + field.Type + "\n"
+		paramsCode = paramsCode + name + " " + field.Type
+		assignCode = assignCode + "\t\t" + name + ": " + value + ",\n"
+		argsCode = argsCode + structRecv + "." + field.Name
+		gettersCode = gettersCode + "\n" +
+			"// " + field.Name + " returns the value of the column `" + field.Column + "`.\n" +
+			"func (" + recv + " " + immutableName + ") " + field.Name + "() " + field.Type + " {\n" +
+			"\treturn " + getterValue + "\n" +
+			"}\n"
+	}
+
+	generatedCode = "// " + immutableName + " is the immutable version of " + structName + ".\n" +
+		"type " + immutableName + " struct {\n" +
+		fieldsCode +
+		"}\n" +
+		"\n" +
+		"// New" + immutableName + " returns a new " + immutableName + ".\n" +
+		"func New" + immutableName + "(" + paramsCode + ") " + immutableName + " {\n" +
+		initCode +
+		"\treturn " + immutableName + "{\n" +
+		assignCode +
+		"\t}\n" +
+		"}\n" +
+		gettersCode +
+		"\n" +
+		"// ToImmutable returns the " + immutableName + " of " + structName + ".\n" +
+		"func (" + structRecv + " *" + structName + ") ToImmutable() " + immutableName + " {\n" +
+		"\treturn New" + immutableName + "(" + argsCode + ")\n" +
+		"}\n"
+
+	return generatedCode
+}
+
+// receiverName returns the receiver name of the methods of the type in the style.
+// e.g. `u` for `Users` in ReceiverStyleShort, `users` for `Users` in ReceiverStyleFull
+func receiverName(typeName, style string) (name string) {
+	if style == ReceiverStyleFull {
+		return unexportedName(typeName)
+	}
+	for _, r := range typeName {
+		return string(unicode.ToLower(r))
+	}
+	return ""
+}
+
+// unexportedName returns the unexported version of the exported name. e.g. `ID` to `id`, `URLPath` to `urlPath`
+// A name that would be a Go keyword gets `_` appended. e.g. `Type` to `type_`
+func unexportedName(exported string) (unexported string) {
+	runes := []rune(exported)
+	// Lower-case the leading upper-case run, but keep the last one if it starts the next word
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	unexported = string(runes)
+	if token.IsKeyword(unexported) {
+		unexported = unexported + "_"
+	}
+	return unexported
+}
+
+// structTagLiteral returns the struct tag literal of the tags.
+// A raw string literal cannot contain a backquote, so an interpreted string literal is used in that case.
+func structTagLiteral(tags []string) (literal string) {
+	tag := strings.Join(tags, " ")
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// structID returns a stable short ID derived from the dataset and table IDs.
+// The project ID is not included, so that the ID is the same across environments (e.g. dev and prod projects).
+func structID(datasetID, tableID string) (id string) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(datasetID + "." + tableID))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// generateSchemaVersionCode generates a const of a hash of the schemas of the tables.
+func generateSchemaVersionCode(schemas []TableSchema) (generatedCode string) {
+	return "// SchemaVersion is a hash of the schemas of all BigQuery Tables the structs have been generated for.\n" +
+		"const SchemaVersion = " + strconv.Quote(schemaVersion(schemas)) + "\n"
+}
+
+// generateGeneratedFromCode generates a var of the project and the dataset of the table, and generatedAt in UTC
+// to the second. The zero generatedAt is left zero.
+func generateGeneratedFromCode(table *bigquery.Table, generatedAt time.Time) (generatedCode string) {
+	generatedAtCode := "time.Time{}"
+	if !generatedAt.IsZero() {
+		t := generatedAt.UTC()
+		generatedAtCode = fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, 0, time.UTC)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+	}
+
+	return "\n" +
+		"// GeneratedFrom is the BigQuery dataset the structs have been generated from, and when. GeneratedAt is zero if omitted.\n" +
+		"var GeneratedFrom = struct {\n" +
+		"\tProject     string\n" +
+		"\tDataset     string\n" +
+		"\tGeneratedAt time.Time\n" +
+		"}{\n" +
+		"\tProject:     " + strconv.Quote(table.ProjectID) + ",\n" +
+		"\tDataset:     " + strconv.Quote(table.DatasetID) + ",\n" +
+		"\tGeneratedAt: " + generatedAtCode + ",\n" +
+		"}\n"
+}
+
+// schemaVersion returns a hash of the table IDs and the columns of the tables, regardless of the order of the tables.
+// The project and the dataset are not included, so that the same schemas in different environments have the same version.
+func schemaVersion(schemas []TableSchema) (version string) {
+	tableHashes := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		h := sha256.New()
+		_, _ = h.Write([]byte(schema.Table.TableID + "\n"))
+		writeSchemaCanonical(h, schema.Metadata.Schema, "")
+		tableHashes = append(tableHashes, hex.EncodeToString(h.Sum(nil)))
+	}
+	sort.Strings(tableHashes)
+
+	h := sha256.New()
+	for _, tableHash := range tableHashes {
+		_, _ = h.Write([]byte(tableHash + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// writeSchemaCanonical writes a line of the name, type and mode per column to w, in schema order.
+func writeSchemaCanonical(w io.Writer, schema bigquery.Schema, columnPrefix string) {
+	for _, field := range schema {
+		column := columnPrefix + field.Name
+		_, _ = fmt.Fprintf(w, "%s %s %s\n", column, field.Type, fieldMode(field))
+		writeSchemaCanonical(w, field.Schema, column+".")
+	}
+}
+
+// generateColumnMetaCode generates a slice of bqmeta.ColumnMeta that describes the columns of the table, in schema order.
+func generateColumnMetaCode(structName string, md *bigquery.TableMetadata, opts Options) (generatedCode string) {
+	generatedCode = "// " + structName + "Columns describes the columns of BigQuery Table `" + md.FullID + "`.\n" +
+		"var " + structName + "Columns = []bqmeta.ColumnMeta{\n"
+	for _, schema := range md.Schema {
+		generatedCode = generatedCode + "\t" + columnMetaLiteral(schema, opts) + ",\n"
+	}
+	generatedCode = generatedCode + "}\n"
+
+	return generatedCode
+}
+
+// generateFieldTypesCode generates a map from the name of the struct field to the BigQuery field type of the column.
+func generateFieldTypesCode(structName string, md *bigquery.TableMetadata, opts Options) (generatedCode string) {
+	generatedCode = "// " + structName + "FieldTypes maps the fields of " + structName + " to the field types of the columns of BigQuery Table `" + md.FullID + "`.\n" +
+		"var " + structName + "FieldTypes = map[string]bigquery.FieldType{\n"
+	for _, schema := range md.Schema {
+		generatedCode = generatedCode + "\t" + strconv.Quote(goFieldName(schema.Name, opts)) + ": " + fieldTypeExpr(schema.Type) + ",\n"
+	}
+	generatedCode = generatedCode + "}\n"
+
+	return generatedCode
+}
+
+// fieldTypeConstNames maps the bigquery.FieldType constants to their names.
+var fieldTypeConstNames = map[bigquery.FieldType]string{
+	bigquery.StringFieldType:    "StringFieldType",
+	bigquery.BytesFieldType:     "BytesFieldType",
+	bigquery.IntegerFieldType:   "IntegerFieldType",
+	bigquery.FloatFieldType:     "FloatFieldType",
+	bigquery.BooleanFieldType:   "BooleanFieldType",
+	bigquery.TimestampFieldType: "TimestampFieldType",
+	bigquery.RecordFieldType:    "RecordFieldType",
+	bigquery.DateFieldType:      "DateFieldType",
+	bigquery.TimeFieldType:      "TimeFieldType",
+	bigquery.DateTimeFieldType:  "DateTimeFieldType",
+	bigquery.NumericFieldType:   "NumericFieldType",
+	bigquery.GeographyFieldType: "GeographyFieldType",
+}
+
+// fieldTypeExpr returns the Go expression of the bigquery.FieldType. e.g. `bigquery.IntegerFieldType`
+func fieldTypeExpr(fieldType bigquery.FieldType) (expr string) {
+	if name, ok := fieldTypeConstNames[fieldType]; ok {
+		return "bigquery." + name
+	}
+	// e.g. a type generated with UnsupportedAsAny
+	return "bigquery.FieldType(" + strconv.Quote(string(fieldType)) + ")"
+}
+
+// columnMetaLiteral returns the bqmeta.ColumnMeta composite literal (without the type) of the column.
+func columnMetaLiteral(schema *bigquery.FieldSchema, opts Options) (literal string) {
+	return "{" +
+		"Go: " + strconv.Quote(goFieldName(schema.Name, opts)) + ", " +
+		"BQ: " + strconv.Quote(schema.Name) + ", " +
+		"Type: " + strconv.Quote(string(schema.Type)) + ", " +
+		"Nullable: " + strconv.FormatBool(!schema.Required && !schema.Repeated) + ", " +
+		"Repeated: " + strconv.FormatBool(schema.Repeated) + "}"
+}
+
+// generateCompareSchemaCode generates a method that fetches the metadata of the table
+// and compares the live schema with the columns the struct has been generated for.
+func generateCompareSchemaCode(structName string, table *bigquery.Table, md *bigquery.TableMetadata, opts Options) (generatedCode string) {
+	generatedCode = "// CompareSchema fetches the metadata of BigQuery Table `" + md.FullID + "`\n" +
+		"// and returns the differences from the columns " + structName + " has been generated for.\n" +
+		"func (" + structName + ") CompareSchema(ctx context.Context, client *bigquery.Client) ([]bqmeta.SchemaDiff, error) {\n" +
+		"\tmd, err := client.DatasetInProject(" + strconv.Quote(table.ProjectID) + ", " + strconv.Quote(table.DatasetID) + ").Table(" + strconv.Quote(table.TableID) + ").Metadata(ctx)\n" +
+		"\tif err != nil {\n" +
+		"\t\treturn nil, fmt.Errorf(\"Metadata: %w\", err)\n" +
+		"\t}\n" +
+		"\treturn bqmeta.CompareSchema([]bqmeta.ColumnMeta{\n"
+	for _, schema := range md.Schema {
+		generatedCode = generatedCode + "\t\t" + columnMetaLiteral(schema, opts) + ",\n"
+	}
+	generatedCode = generatedCode + "\t}, md.Schema), nil\n" +
+		"}\n"
+
+	return generatedCode
+}
+
+// generateImplementsCode generates compile-time assertions that the struct implements the interfaces.
+func generateImplementsCode(structName string, interfaces []GoType) (generatedCode string) {
+	generatedCode = "// " + structName + " must implement the following interfaces.\n"
+	for _, iface := range interfaces {
+		generatedCode = generatedCode + "var _ " + iface.Name + " = (*" + structName + ")(nil)\n"
+	}
+	return generatedCode
+}
+
+// generateInUTCCode generates the `InUTC` method of the struct, which sets the location of the time.Time values
+// of the TIMESTAMP columns to UTC. The TIMESTAMP columns in RECORD columns and the fields of overridden types are left as is.
+// It returns an empty string if the struct has no such fields.
+func generateInUTCCode(structName string, fields []goField, opts Options) (generatedCode string) {
+	recv := receiverName(structName, opts.ReceiverStyle)
+
+	var bodyCode string
+	for _, field := range fields {
+		if field.Schema.Type != bigquery.TimestampFieldType {
+			continue
+		}
+		value := recv + "." + field.Name
+		switch field.Type {
+		case "time.Time":
+			bodyCode = bodyCode + "\t" + value + " = " + value + ".UTC()\n"
+		case typeOfNullTimestamp.String():
+			bodyCode = bodyCode + "\tif " + value + ".Valid {\n" +
+				"\t\t" + value + ".Timestamp = " + value + ".Timestamp.UTC()\n" +
+				"\t}\n"
+		case "[]time.Time":
+			bodyCode = bodyCode + "\tfor i := range " + value + " {\n" +
+				"\t\t" + value + "[i] = " + value + "[i].UTC()\n" +
+				"\t}\n"
+		}
+	}
+	if bodyCode == "" {
+		return ""
+	}
+
+	generatedCode = "// InUTC sets the location of the TIMESTAMP columns of " + structName + " to UTC.\n" +
+		"// BigQuery stores TIMESTAMP in UTC, but the loaded time.Time values may be in another location.\n" +
+		"func (" + recv + " *" + structName + ") InUTC() {\n" +
+		bodyCode +
+		"}\n"
+
+	return generatedCode
+}
+
+// generateValidateCode generates the `Validate` method of the struct, which returns an error naming the REQUIRED columns
+// whose fields are nil, and validates the structs of the RECORD columns. The fields of value types, e.g. int64,
+// cannot be told from the zero values, so they are not checked. Neither are the structs of NULLABLE RECORD columns
+// that are not pointers, which are zero values for NULL.
+func generateValidateCode(structName string, fields []goField, opts Options) (generatedCode string, importPackages []string) {
+	recv := receiverName(structName, opts.ReceiverStyle)
+
+	var nullCode, recordCode string
+	for _, field := range fields {
+		value := recv + "." + field.Name
+		if field.Schema.Type == bigquery.RecordFieldType {
+			validateCode := "if err := " + value + ".Validate(); err != nil {\n"
+			switch {
+			case field.Schema.Repeated:
+				recordCode = recordCode + "\tfor i := range " + value + " {\n" +
+					"\t\tif err := " + value + "[i].Validate(); err != nil {\n" +
+					"\t\t\treturn fmt.Errorf(" + strconv.Quote(field.Column+"[%d]: %w") + ", i, err)\n" +
+					"\t\t}\n" +
+					"\t}\n"
+				continue
+			case strings.HasPrefix(field.Type, "*"):
+				recordCode = recordCode + "\tif " + value + " != nil {\n" +
+					"\t\t" + validateCode +
+					"\t\t\treturn fmt.Errorf(" + strconv.Quote(field.Column+": %w") + ", err)\n" +
+					"\t\t}\n" +
+					"\t}\n"
+			case field.Schema.Required:
+				recordCode = recordCode + "\t" + validateCode +
+					"\t\treturn fmt.Errorf(" + strconv.Quote(field.Column+": %w") + ", err)\n" +
+					"\t}\n"
+			}
+		}
+		// A REQUIRED RECORD can be a pointer by TypeOverrides, and a REQUIRED NUMERIC is *big.Rat
+		if !field.Schema.Required || field.Schema.Repeated || !isNilableGoType(field.Type) {
+			continue
+		}
+		nullCode = nullCode + "\tif " + value + " == nil {\n" +
+			"\t\tnullColumns = append(nullColumns, " + strconv.Quote(field.Column) + ")\n" +
+			"\t}\n"
+	}
+
+	generatedCode = "// Validate returns an error naming the REQUIRED columns of " + structName + " whose fields are nil,\n" +
+		"// or the error of the structs of the RECORD columns.\n" +
+		"func (" + recv + " " + structName + ") Validate() error {\n"
+	if nullCode != "" {
+		generatedCode = generatedCode + "\tvar nullColumns []string\n" +
+			nullCode +
+			"\tif len(nullColumns) > 0 {\n" +
+			"\t\treturn fmt.Errorf(" + strconv.Quote(structName+": REQUIRED columns are NULL: %s") + ", strings.Join(nullColumns, \", \"))\n" +
+			"\t}\n"
+		importPackages = append(importPackages, "fmt", "strings")
+	}
+	if recordCode != "" {
+		generatedCode = generatedCode + recordCode
+		importPackages = append(importPackages, "fmt")
+	}
+	generatedCode = generatedCode + "\treturn nil\n" +
+		"}\n"
+
+	return generatedCode, importPackages
+}
+
+// isNilableGoType returns true if the values of the Go type can be nil.
+func isNilableGoType(goType string) bool {
+	for _, prefix := range []string{"*", "[]", "map[", "chan ", "func(", "interface{"} {
+		if strings.HasPrefix(goType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateSetCode generates the methods that convert the fields of the REPEATED STRING columns to and from
+// `map[T]struct{}` sets, for the columns whose order does not matter.
+// The fields stay slices, because cloud.google.com/go/bigquery does not load into maps.
+func generateSetCode(tableID, structName string, fields []goField, setColumns []string, opts Options) (generatedCode string) {
+	recv := receiverName(structName, opts.ReceiverStyle)
+
+	for _, column := range setColumns {
+		var field *goField
+		for i := range fields {
+			// BigQuery column names are case-insensitive.
+			if strings.EqualFold(fields[i].Column, column) {
+				field = &fields[i]
+				break
+			}
+		}
+		if field == nil {
+			warnln(fmt.Sprintf("table overrides: set column %s of table %s is not found", column, tableID))
+			continue
+		}
+		if field.Schema.Type != bigquery.StringFieldType || !field.Schema.Repeated {
+			warnln(fmt.Sprintf("table overrides: set column %s of table %s is not REPEATED STRING. skipping", column, tableID))
+			continue
+		}
+
+		elemType := strings.TrimPrefix(field.Type, "[]")
+		setType := "map[" + elemType + "]struct{}"
+		value := recv + "." + field.Name
+		generatedCode = generatedCode + "\n" +
+			"// " + field.Name + "Set returns the values of " + field.Name + " as a set.\n" +
+			"func (" + recv + " *" + structName + ") " + field.Name + "Set() " + setType + " {\n" +
+			"\tset := make(" + setType + ", len(" + value + "))\n" +
+			"\tfor _, v := range " + value + " {\n" +
+			"\t\tset[v] = struct{}{}\n" +
+			"\t}\n" +
+			"\treturn set\n" +
+			"}\n" +
+			"\n" +
+			"// Set" + field.Name + "Set sets the values of the set to " + field.Name + " in sorted order, so that the rows are deterministic.\n" +
+			"func (" + recv + " *" + structName + ") Set" + field.Name + "Set(set " + setType + ") {\n" +
+			"\t" + value + " = make(" + field.Type + ", 0, len(set))\n" +
+			"\tfor v := range set {\n" +
+			"\t\t" + value + " = append(" + value + ", v)\n" +
+			"\t}\n" +
+			"\tsort.Slice(" + value + ", func(i, j int) bool { return " + value + "[i] < " + value + "[j] })\n" +
+			"}\n"
+	}
+
+	return generatedCode
+}
+
+// generateSelectQueryCode generates a method that returns a query selecting the columns the struct has been generated for.
+func generateSelectQueryCode(structName string, table *bigquery.Table, md *bigquery.TableMetadata) (generatedCode string) {
+	columns := make([]string, 0, len(md.Schema))
+	for _, schema := range md.Schema {
+		columns = append(columns, quoteSQLIdentifier(schema.Name))
+	}
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + quoteSQLIdentifier(table.ProjectID+"."+table.DatasetID+"."+table.TableID)
+
+	generatedCode = "// SelectQuery returns a query that selects the columns of " + structName + " from BigQuery Table `" + md.FullID + "`.\n" +
+		"// where is the condition of the WHERE clause, which is omitted if where is empty.\n" +
+		"func (" + structName + ") SelectQuery(where string) string {\n" +
+		"\tconst query = " + strconv.Quote(query) + "\n" +
+		"\tif where == \"\" {\n" +
+		"\t\treturn query\n" +
+		"\t}\n" +
+		"\treturn query + \" WHERE \" + where\n" +
+		"}\n"
+
+	return generatedCode
+}
+
+// quoteSQLIdentifier quotes the identifier with backquotes for standard SQL.
+func quoteSQLIdentifier(identifier string) (quoted string) {
+	return "`" + strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(identifier) + "`"
+}
+
+// generateInserterCode generates a function that streams rows into the table.
+func generateInserterCode(structName string, table *bigquery.Table, md *bigquery.TableMetadata) (generatedCode string) {
+	generatedCode = "// Insert" + structName + " streams rows into BigQuery Table `" + md.FullID + "`.\n" +
+		"func Insert" + structName + "(ctx context.Context, client *bigquery.Client, rows []" + structName + ") error {\n" +
+		"\tinserter := client.DatasetInProject(" + strconv.Quote(table.ProjectID) + ", " + strconv.Quote(table.DatasetID) + ").Table(" + strconv.Quote(table.TableID) + ").Inserter()\n" +
+		"\tif err := inserter.Put(ctx, rows); err != nil {\n" +
+		"\t\treturn fmt.Errorf(\"Put: %w\", err)\n" +
+		"\t}\n" +
+		"\treturn nil\n" +
+		"}\n"
+
+	return generatedCode
+}
+
+// avroName returns a valid Avro name for the column name, and whether the column name has been sanitized.
+// ref. https://avro.apache.org/docs/current/spec.html#names
+func avroName(name string) (sanitizedName string, sanitized bool) {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			// Names must not start with a digit
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	sanitizedName = b.String()
+	if sanitizedName == "" {
+		sanitizedName = "_"
+	}
+
+	return sanitizedName, sanitizedName != name
+}
+
+func matchEmbedPatterns(schema *bigquery.FieldSchema, embedPatterns []EmbedPattern) bool {
+	for _, embedPattern := range embedPatterns {
+		if embedPattern.Type != "" && embedPattern.Type != schema.Type {
+			continue
+		}
+		// The pattern has been validated by parseEmbedPatterns.
+		if matched, _ := path.Match(embedPattern.Pattern, schema.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// groupFieldsByMode returns the fields with REQUIRED fields first, then NULLABLE, then REPEATED, each group in schema order.
+func groupFieldsByMode(schemas []*bigquery.FieldSchema) (grouped []*bigquery.FieldSchema) {
+	var required, nullable, repeated []*bigquery.FieldSchema
+	for _, schema := range schemas {
+		switch {
+		case schema.Repeated:
+			repeated = append(repeated, schema)
+		case schema.Required:
+			required = append(required, schema)
+		default:
+			nullable = append(nullable, schema)
+		}
+	}
+
+	grouped = make([]*bigquery.FieldSchema, 0, len(schemas))
+	grouped = append(grouped, required...)
+	grouped = append(grouped, nullable...)
+	grouped = append(grouped, repeated...)
+
+	return grouped
+}
+
+// tableIDToStructName returns the name of the struct generated for the table.
+func tableIDToStructName(tableID string) (structName string) {
+	return capitalizeInitial(strings.ReplaceAll(tableID, "-", "_"))
+}
+
+// goStructName is the same as tableIDToStructName, but truncates the name by opts.MaxNameLength.
+// The struct name in opts.TableOverrides takes precedence, and is not truncated.
+func goStructName(tableID string, opts Options) (structName string) {
+	if structName = opts.TableOverrides[tableID].StructName; structName != "" {
+		return structName
+	}
+	return truncateName(tableIDToStructName(tableID), opts.MaxNameLength)
+}
+
+// NameHashLength is the length of the hash truncateName appends.
+const NameHashLength = 8
+
+// truncateName cuts name to maxLength characters including a hash of the full name, so that the truncated names
+// of different names that share a prefix do not collide. It returns name as it is if it is not longer than maxLength,
+// or if maxLength is 0.
+func truncateName(name string, maxLength int) (truncated string) {
+	runes := []rune(name)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return name
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return string(runes[:maxLength-NameHashLength]) + fmt.Sprintf("%08x", h.Sum32())
+}
+
+// generateTypeRegistryCode generates a map from table ID to reflect.Type of the struct generated for the table.
+func generateTypeRegistryCode(tables []*bigquery.Table, opts Options) (generatedCode string) {
+	generatedCode = "// TableTypes maps BigQuery Table IDs to the schema struct types.\n" +
+		"var TableTypes = map[string]reflect.Type{\n"
+	for _, table := range tables {
+		generatedCode = generatedCode + "\t" + strconv.Quote(table.TableID) + ": reflect.TypeOf(" + goStructName(table.TableID, opts) + "{}),\n"
+	}
+	generatedCode = generatedCode + "}\n"
+
+	return generatedCode
+}
+
+// generateRowInterfaceCode generates the `Row` interface implemented by the schema structs, and a map from table ID
+// to the decoder of the rows into the struct generated for the table, so that the rows of the tables are decoded in one loop.
+func generateRowInterfaceCode(tables []*bigquery.Table, opts Options) (generatedCode string) {
+	generatedCode = "\n" +
+		"// Row is implemented by the schema structs of all BigQuery Tables.\n" +
+		"type Row interface {\n" +
+		"\tisBQRow()\n" +
+		"}\n" +
+		"\n" +
+		"// RowDecoders maps BigQuery Table IDs to the decoders that load the next row of the iterator into a new schema struct.\n" +
+		"// The decoders return the errors of the iterator as they are, e.g. iterator.Done.\n" +
+		"var RowDecoders = map[string]func(it *bigquery.RowIterator) (Row, error){\n"
+	for _, table := range tables {
+		generatedCode = generatedCode + "\t" + strconv.Quote(table.TableID) + ": func(it *bigquery.RowIterator) (Row, error) {\n" +
+			"\t\tvar row " + goStructName(table.TableID, opts) + "\n" +
+			"\t\tif err := it.Next(&row); err != nil {\n" +
+			"\t\t\treturn nil, err\n" +
+			"\t\t}\n" +
+			"\t\treturn &row, nil\n" +
+			"\t},\n"
+	}
+	generatedCode = generatedCode + "}\n"
+
+	return generatedCode
+}
+
+// generateRegisterFuncCode generates an init() that registers the schema struct of each table with opts.RegisterFunc.
+func generateRegisterFuncCode(tables []*bigquery.Table, opts Options) (generatedCode string) {
+	generatedCode = "\nfunc init() {\n"
+	for _, table := range tables {
+		generatedCode = generatedCode + "\t" + opts.RegisterFunc.Name + "(" + strconv.Quote(table.TableID) + ", " + goStructName(table.TableID, opts) + "{})\n"
+	}
+	generatedCode = generatedCode + "}\n"
+
+	return generatedCode
+}
+
+// generateAllColumnsCode generates a const block of the column names of all tables, grouped by table.
+// The columns of RECORD columns are named by the dotted path. e.g. `UsersColumnAddressCity = "address.city"`
+func generateAllColumnsCode(schemas []TableSchema, opts Options) (generatedCode string) {
+	generatedCode = "// Column names of all BigQuery Tables.\n" +
+		"const (\n"
+	for i, schema := range schemas {
+		if i > 0 {
+			generatedCode = generatedCode + "\n"
+		}
+		structName := goStructName(schema.Table.TableID, opts)
+		generatedCode = generatedCode + "\t// " + structName + " is BigQuery Table `" + schema.Metadata.FullID + "`.\n" +
+			generateColumnConstsCode(structName+"Column", "", schema.Metadata.Schema, opts)
+	}
+	generatedCode = generatedCode + ")\n"
+
+	return generatedCode
+}
+
+func generateColumnConstsCode(constPrefix, columnPrefix string, schema bigquery.Schema, opts Options) (generatedCode string) {
+	for _, field := range schema {
+		constName := constPrefix + goFieldName(field.Name, opts)
+		column := columnPrefix + field.Name
+		generatedCode = generatedCode + "\t" + constName + " = " + strconv.Quote(column) + "\n"
+		if field.Type == bigquery.RecordFieldType {
+			generatedCode = generatedCode + generateColumnConstsCode(constName, column+".", field.Schema, opts)
+		}
+	}
+	return generatedCode
+}
+
+// consoleLink returns the URL of the table in the BigQuery console.
+func consoleLink(table *bigquery.Table) (link string) {
+	return "https://console.cloud.google.com/bigquery?p=" + url.QueryEscape(table.ProjectID) +
+		"&d=" + url.QueryEscape(table.DatasetID) +
+		"&t=" + url.QueryEscape(table.TableID)
+}
+
+// GetAllTables returns all tables in the dataset.
+// If projectID is empty, the dataset is looked up in the project of the client.
+func GetAllTables(ctx context.Context, client *bigquery.Client, projectID, datasetID string) (tables []*bigquery.Table, err error) {
+	ds := client.Dataset(datasetID)
+	if projectID != "" {
+		ds = client.DatasetInProject(projectID, datasetID)
+	}
+
+	tableIterator := ds.Tables(ctx)
+	for {
+		var table *bigquery.Table
+		table, err = tableIterator.Next()
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("tableIterator.Next: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// getAllTableSchemas returns all tables in the dataset with their metadata.
+// Tables whose metadata cannot be fetched are skipped with a warning.
+func getAllTableSchemas(ctx context.Context, client *bigquery.Client, projectID, datasetID string) (schemas []TableSchema, err error) {
+	var tables []*bigquery.Table
+	tables, err = GetAllTables(ctx, client, projectID, datasetID)
+	if err != nil {
+		return nil, fmt.Errorf("getAllTables: %w", err)
+	}
+
+	schemas, err = GetTableSchemas(ctx, tables)
+	if err != nil {
+		return nil, fmt.Errorf("getTableSchemas: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// GetTableSchemas returns the tables with their metadata.
+// Tables whose metadata cannot be fetched are skipped with a warning.
+// It stops when ctx is cancelled.
+func GetTableSchemas(ctx context.Context, tables []*bigquery.Table) (schemas []TableSchema, err error) {
+	for _, table := range tables {
+		if err = ctx.Err(); err != nil {
+			return nil, fmt.Errorf("ctx.Err: %w", err)
+		}
+
+		var md *bigquery.TableMetadata
+		md, err = getTableMetadata(ctx, table)
+		if err != nil {
+			warnln("getTableMetadata: " + err.Error())
+			continue
+		}
+		schemas = append(schemas, TableSchema{Table: table, Metadata: md})
+	}
+
+	return schemas, nil
+}
+
+// The same list as commonInitialisms of golang.org/x/lint
+var commonInitialisms = map[string]bool{
+	"ACL":   true,
+	"API":   true,
+	"ASCII": true,
+	"CPU":   true,
+	"CSS":   true,
+	"DNS":   true,
+	"EOF":   true,
+	"GUID":  true,
+	"HTML":  true,
+	"HTTP":  true,
+	"HTTPS": true,
+	"ID":    true,
+	"IP":    true,
+	"JSON":  true,
+	"LHS":   true,
+	"QPS":   true,
+	"RAM":   true,
+	"RHS":   true,
+	"RPC":   true,
+	"SLA":   true,
+	"SMTP":  true,
+	"SQL":   true,
+	"SSH":   true,
+	"TCP":   true,
+	"TLS":   true,
+	"TTL":   true,
+	"UDP":   true,
+	"UI":    true,
+	"UID":   true,
+	"UUID":  true,
+	"URI":   true,
+	"URL":   true,
+	"UTF8":  true,
+	"VM":    true,
+	"XML":   true,
+	"XMPP":  true,
+	"XSRF":  true,
+	"XSS":   true,
+}
+
+// goFieldName returns the name of the struct field generated for the column, truncated by opts.MaxNameLength.
+func goFieldName(columnName string, opts Options) (fieldName string) {
+	return truncateName(fullGoFieldName(columnName, opts), opts.MaxNameLength)
+}
+
+// fullGoFieldName is the same as goFieldName, but does not truncate the name.
+func fullGoFieldName(columnName string, opts Options) (fieldName string) {
+	columnName = sanitizeIdentifier(columnName)
+	if !opts.Initialisms {
+		return capitalizeInitial(columnName)
+	}
+	return snakeToCamelWithInitialisms(columnName, opts.NameExceptions)
+}
+
+func warnTruncatedFieldName(columnName string, opts Options) {
+	if fieldName, fullName := goFieldName(columnName, opts), fullGoFieldName(columnName, opts); fieldName != fullName {
+		warnln(fmt.Sprintf("field name `%s` is longer than the max name length %d. truncating to `%s`", fullName, opts.MaxNameLength, fieldName))
+	}
+}
+
+// sanitizeIdentifier replaces the characters that are invalid in Go identifiers (e.g. spaces in flexible column names) with `_`.
+func sanitizeIdentifier(s string) (sanitized string) {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// snakeToCamelWithInitialisms converts snake_case to CamelCase, and upper-cases the segments that are common initialisms
+// wherever they appear. e.g. `customer_id` to `CustomerID`, `id_customer` to `IDCustomer`
+// The casings in exceptions keyed by lower-cased segment take precedence over the initialisms. e.g. `ios` to `IOS`
+func snakeToCamelWithInitialisms(s string, exceptions map[string]string) (camel string) {
+	var b strings.Builder
+	for _, segment := range strings.Split(s, "_") {
+		if segment == "" {
+			continue
+		}
+		if casing, exist := exceptions[strings.ToLower(segment)]; exist {
+			b.WriteString(casing)
+			continue
+		}
+		if upper := strings.ToUpper(segment); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(capitalizeInitial(segment))
+	}
+
+	// e.g. `_` or `__`
+	if b.Len() == 0 {
+		return capitalizeInitial(s)
+	}
+
+	return b.String()
+}
+
+// spannerColumnName returns the PascalCase column name of Cloud Spanner conventions for the column.
+// It is the same as the Go field name with Options.Initialisms. e.g. `customer_id` to `CustomerID`
+// Otherwise, initialisms are not upper-cased. e.g. `customer_id` to `CustomerId`
+func spannerColumnName(columnName string, opts Options) (spannerName string) {
+	columnName = sanitizeIdentifier(columnName)
+	if opts.Initialisms {
+		return snakeToCamelWithInitialisms(columnName, opts.NameExceptions)
+	}
+
+	var b strings.Builder
+	for _, segment := range strings.Split(columnName, "_") {
+		b.WriteString(capitalizeInitial(segment))
+	}
+
+	// e.g. `_` or `__`
+	if b.Len() == 0 {
+		return capitalizeInitial(columnName)
+	}
+
+	return b.String()
+}
+
+func capitalizeInitial(s string) (capitalized string) {
+	if len(s) == 0 {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func warnln(content string) {
+	log.Println("WARN: " + content)
+}
+
+// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L216
+var typeOfByteSlice = reflect.TypeOf([]byte{})
+
+// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/params.go#L81-L87
+var (
+	typeOfDate     = reflect.TypeOf(civil.Date{})
+	typeOfTime     = reflect.TypeOf(civil.Time{})
+	typeOfDateTime = reflect.TypeOf(civil.DateTime{})
+	typeOfGoTime   = reflect.TypeOf(time.Time{})
+	typeOfRat      = reflect.TypeOf(&big.Rat{})
+)
+
+// fieldSchemaToGoType returns the Go type for the column, taking the mode of the column and the options into account.
+func fieldSchemaToGoType(schema *bigquery.FieldSchema, opts Options) (goType string, pkg string, err error) {
+	nullable := !schema.Required && !schema.Repeated
+
+	if override, ok := opts.NullableTypeOverrides[schema.Type]; ok && nullable {
+		return override.Name, override.importSpec(), nil
+	}
+
+	if override, ok := opts.TypeOverrides[schema.Type]; ok {
+		// The overridden types are generated as they are even if NULLABLE. See NullableTypeOverrides.
+		if schema.Repeated {
+			return "[]" + override.Name, override.importSpec(), nil
+		}
+		return override.Name, override.importSpec(), nil
+	}
+
+	goType, pkg, err = BigQueryFieldTypeToGoType(schema.Type)
+	if err != nil {
+		if !opts.UnsupportedAsAny || !errors.Is(err, ErrFieldTypeNotSupported) {
+			return "", "", fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+		}
+		// The bigquery client can load any value into interface{}
+		goType, pkg = "interface{}", ""
+	}
+
+	if schema.Repeated {
+		return "[]" + goType, pkg, nil
+	}
+
+	// cloud.google.com/go/bigquery does not load into pointers to the scalar types, but into the Null* types.
+	// []byte, *big.Rat and interface{} can already represent NULL as nil.
+	if nullType, ok := nullTypes[schema.Type]; ok && nullable && (opts.NullTypes || opts.NullTypesFor[schema.Type]) {
+		return nullType.String(), nullType.PkgPath(), nil
+	}
+
+	return goType, pkg, nil
+}
+
+var (
+	typeOfNullString    = reflect.TypeOf(bigquery.NullString{})
+	typeOfNullGeography = reflect.TypeOf(bigquery.NullGeography{})
+	typeOfNullInt64     = reflect.TypeOf(bigquery.NullInt64{})
+	typeOfNullFloat64   = reflect.TypeOf(bigquery.NullFloat64{})
+	typeOfNullBool      = reflect.TypeOf(bigquery.NullBool{})
+	typeOfNullTimestamp = reflect.TypeOf(bigquery.NullTimestamp{})
+	typeOfNullDate      = reflect.TypeOf(bigquery.NullDate{})
+	typeOfNullTime      = reflect.TypeOf(bigquery.NullTime{})
+	typeOfNullDateTime  = reflect.TypeOf(bigquery.NullDateTime{})
+
+	// cloud.google.com/go/bigquery loads NULLABLE columns into its own Null* types, but not into the database/sql ones.
+	// ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/value.go#L284-L407
+	nullTypes = map[bigquery.FieldType]reflect.Type{
+		bigquery.StringFieldType:    typeOfNullString,
+		bigquery.GeographyFieldType: typeOfNullGeography,
+		bigquery.IntegerFieldType:   typeOfNullInt64,
+		bigquery.FloatFieldType:     typeOfNullFloat64,
+		bigquery.BooleanFieldType:   typeOfNullBool,
+		bigquery.TimestampFieldType: typeOfNullTimestamp,
+		bigquery.DateFieldType:      typeOfNullDate,
+		bigquery.TimeFieldType:      typeOfNullTime,
+		bigquery.DateTimeFieldType:  typeOfNullDateTime,
+	}
+)
+
+// nullTypeValueField returns the name of the field of the bigquery Null* type that holds the value. e.g. `StringVal` of bigquery.NullString
+func nullTypeValueField(nullType reflect.Type) string {
+	return nullType.Field(0).Name
+}
+
+// ErrFieldTypeNotSupported is returned for the BigQuery field types that have no Go type.
+var ErrFieldTypeNotSupported = errors.New("bigquery.FieldType not supported")
+
+// BigQueryFieldTypeToGoType returns the Go type of the BigQuery field type, and the import path of the package of the type if any.
+func BigQueryFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType string, pkg string, err error) {
+	switch NormalizeFieldType(bigqueryFieldType) {
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L342-L343
+	case bigquery.BytesFieldType:
+		return typeOfByteSlice.String(), "", nil
+
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L344-L358
+	case bigquery.DateFieldType:
+		return typeOfDate.String(), typeOfDate.PkgPath(), nil
+	case bigquery.TimeFieldType:
+		return typeOfTime.String(), typeOfTime.PkgPath(), nil
+	case bigquery.DateTimeFieldType:
+		return typeOfDateTime.String(), typeOfDateTime.PkgPath(), nil
+	case bigquery.TimestampFieldType:
+		return typeOfGoTime.String(), typeOfGoTime.PkgPath(), nil
+	case bigquery.NumericFieldType:
+		// NOTE(ginokent): The *T (pointer type) does not return the package path.
+		//               ref. https://github.com/golang/go/blob/f0ff6d4a67ec9a956aa655d487543da034cf576b/src/reflect/type.go#L83
+		return typeOfRat.String(), reflect.TypeOf(big.Rat{}).PkgPath(), nil
+
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L362-L364
+	case bigquery.IntegerFieldType:
+		return reflect.Int64.String(), "", nil
+
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L368-L371
+	case bigquery.RecordFieldType:
+		// RECORD is generated as a nested struct by fieldGoType.
+		return "", "", fmt.Errorf("%w. bigquery.FieldType=%s", ErrFieldTypeNotSupported, bigqueryFieldType)
+
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L394-L399
+	case bigquery.StringFieldType, bigquery.GeographyFieldType:
+		return reflect.String.String(), "", nil
+	case bigquery.BooleanFieldType:
+		return reflect.Bool.String(), "", nil
+	case bigquery.FloatFieldType:
+		return reflect.Float64.String(), "", nil
+
+	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L400-L401
+	default:
+		return "", "", fmt.Errorf("%w. bigquery.FieldType=%s", ErrFieldTypeNotSupported, bigqueryFieldType)
+	}
+}
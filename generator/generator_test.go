@@ -0,0 +1,2633 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+)
+
+const (
+	// all
+	testEmptyString                = ""
+	GOOGLE_APPLICATION_CREDENTIALS = "GOOGLE_APPLICATION_CREDENTIALS"
+
+	// getAllTableSchemas, getTableMetadata, GetAllTables
+	testPublicDataProjectID         = "bigquery-public-data"
+	testSupportedDatasetID          = "hacker_news"
+	testNotSupportedDatasetID       = "samples"
+	testProjectNotFound             = "projectnotfound"
+	testDatasetNotFound             = "datasetnotfound"
+	testSubStrFieldTypeNotSupported = "bigquery.FieldType not supported."
+
+	// generateStructCode
+	testTableID = "test_table"
+
+	// GetAllTables
+	testGoogleApplicationCredentials = "../test/serviceaccountnotfound@projectnotfound.iam.gserviceaccount.com.json"
+
+	// capitalizeInitial
+	testNotCapitalized = "a"
+	testCapitalized    = "A"
+
+	// BigQueryFieldTypeToGoType
+	testNotSupportedFieldType = "notSupportedFieldType"
+)
+
+func Test_Generate(t *testing.T) {
+	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		_, err := Generate(ctx, client, testSupportedDatasetID, false)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_testNotSupportedDatasetID_"+testNotSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		_, err := Generate(ctx, client, testNotSupportedDatasetID, false)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_GoType_importSpec(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		goType GoType
+		spec   string
+	}{
+		{"正常系_same_name", GoType{Name: "sql.NullTime", PkgPath: "database/sql"}, "database/sql"},
+		{"正常系_different_name", GoType{Name: "geom.T", PkgPath: "github.com/twpayne/go-geom"}, "geom \"github.com/twpayne/go-geom\""},
+		{"正常系_pointer", GoType{Name: "*geom.Point", PkgPath: "github.com/twpayne/go-geom"}, "geom \"github.com/twpayne/go-geom\""},
+		{"正常系_builtin", GoType{Name: "int64"}, ""},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if spec := tt.goType.importSpec(); spec != tt.spec {
+				t.Error("importSpec: want=`" + tt.spec + "` current=`" + spec + "`")
+			}
+		})
+	}
+}
+
+func Test_generateImportPackagesCode(t *testing.T) {
+	t.Run("正常系_import_nothing", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = ""
+		)
+		var (
+			testImportsSlice = []string{}
+		)
+
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+		if generatedCode != testImportCode {
+			t.Error()
+		}
+	})
+
+	t.Run("正常系_import_time", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = "import \"time\"\n\n"
+		)
+		var (
+			testImportsSlice = []string{"time"}
+		)
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+
+		if generatedCode != testImportCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testImportCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+
+	t.Run("正常系_import_math/big_time", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = `import (
+	"math/big"
+	"time"
+)
+
+`
+		)
+		var (
+			testImportsSlice = []string{"math/big", "time"}
+		)
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+
+		if generatedCode != testImportCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testImportCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+
+	t.Run("正常系_import_spec", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = `import (
+	geom "github.com/twpayne/go-geom"
+	"time"
+)
+
+`
+		)
+		var (
+			testImportsSlice = []string{"geom \"github.com/twpayne/go-geom\"", "time"}
+		)
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+
+		if generatedCode != testImportCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testImportCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+}
+
+func Test_generateCode(t *testing.T) {
+	var (
+		testSchemas = []TableSchema{{
+			Table: &bigquery.Table{ProjectID: testProjectNotFound, DatasetID: testDatasetNotFound, TableID: testTableID},
+			Metadata: &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				},
+			},
+		}}
+	)
+
+	t.Run("正常系_outputFormatGo", func(t *testing.T) {
+		generatedCode, err := GenerateCode(testSchemas, Options{OutputFormat: OutputFormatGo})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(string(generatedCode), "type Test_table struct {") {
+			t.Error("generateCode: " + string(generatedCode))
+		}
+	})
+
+	t.Run("正常系_NoGoimports", func(t *testing.T) {
+		var (
+			schemas = []TableSchema{{
+				Table: &bigquery.Table{ProjectID: testProjectNotFound, DatasetID: testDatasetNotFound, TableID: testTableID},
+				Metadata: &bigquery.TableMetadata{
+					Schema: bigquery.Schema{
+						{Name: "created_at", Type: bigquery.TimestampFieldType},
+						{Name: "price", Type: bigquery.NumericFieldType},
+						{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+							{Name: "updated_on", Type: bigquery.DateFieldType},
+						}},
+					},
+				},
+			}}
+		)
+		generatedCode, err := GenerateCode(schemas, Options{OutputFormat: OutputFormatGo, NoGoimports: true, EmitValueMap: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "", generatedCode, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var importPaths []string
+		for _, spec := range file.Imports {
+			importPaths = append(importPaths, spec.Path.Value)
+		}
+		// gofmt sorts the import block
+		if want := []string{`"cloud.google.com/go/bigquery"`, `"cloud.google.com/go/civil"`, `"math/big"`, `"time"`}; !reflect.DeepEqual(importPaths, want) {
+			t.Errorf("generateCode: imports: want=%v current=%v", want, importPaths)
+		}
+		if formatted, err := format.Source(generatedCode); err != nil || !bytes.Equal(formatted, generatedCode) {
+			t.Errorf("generateCode: not formatted: err=%v code=%s", err, generatedCode)
+		}
+	})
+
+	t.Run("正常系_ExtraImports", func(t *testing.T) {
+		generatedCode, err := GenerateCode(testSchemas, Options{OutputFormat: OutputFormatGo, NoGoimports: true, ExtraImports: []string{`_ "github.com/lib/pq"`}})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(string(generatedCode), "import _ \"github.com/lib/pq\"\n") {
+			t.Error("generateCode: extra import not found: " + string(generatedCode))
+		}
+	})
+
+	t.Run("正常系_outputFormatProto", func(t *testing.T) {
+		generatedCode, err := GenerateCode(testSchemas, Options{OutputFormat: OutputFormatProto})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(string(generatedCode), "message Test_table {") {
+			t.Error("generateCode: " + string(generatedCode))
+		}
+	})
+}
+
+func Test_getAllTableSchemas(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		schemas, err := getAllTableSchemas(ctx, client, testEmptyString, testSupportedDatasetID)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, schema := range schemas {
+			if _, _, err := generateStructCode(schema.Table, schema.Metadata, Options{}); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("異常系_testPublicDataProjectID_testNotSupportedDatasetID_testSubStrFieldTypeNotSupported", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		schemas, err := getAllTableSchemas(ctx, client, testEmptyString, testNotSupportedDatasetID)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, schema := range schemas {
+			if _, _, err := generateStructCode(schema.Table, schema.Metadata, Options{}); err != nil {
+				// NOTE(ginokent): "bigquery.FieldType not supported." 以外のエラーが出たら Fail
+				if !strings.Contains(err.Error(), testSubStrFieldTypeNotSupported) {
+					t.Error(err)
+				}
+				// NOTE(ginokent): ここまで来たら、確認したいことは確認済み。
+				// ref. https://github.com/ginokent/bqschema-gen-go/blob/260524ce0ae2dd5bdcbdd57446cdd8c140326ca4/main.go#L212
+				return
+			}
+		}
+	})
+}
+
+func Test_getTableSchemas(t *testing.T) {
+	t.Run("異常系_cancelled", func(t *testing.T) {
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			tables      = []*bigquery.Table{{ProjectID: testProjectNotFound, DatasetID: testDatasetNotFound, TableID: testTableID}}
+		)
+		cancel()
+		if _, err := GetTableSchemas(ctx, tables); !errors.Is(err, context.Canceled) {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_getTableMetadata(t *testing.T) {
+	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
+		var (
+			ctx     = context.Background()
+			ngTable = &bigquery.Table{
+				ProjectID: testProjectNotFound,
+				DatasetID: testDatasetNotFound,
+				TableID:   testEmptyString,
+			}
+		)
+		if _, err := getTableMetadata(ctx, ngTable); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound_testNotSupportedDatasetID", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			ngClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			ngTable, _  = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx).Next()
+		)
+
+		ngTable.ProjectID = testProjectNotFound
+		if _, err := getTableMetadata(ctx, ngTable); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGenerateStruct(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testStructCode = "// Users is BigQuery Table `Users` schema struct.\n" +
+				"type Users struct {\n" +
+				"\tId   int64  `bigquery:\"id\"`\n" +
+				"\tName string `bigquery:\"name\"`\n" +
+				"}\n"
+		)
+		schema := bigquery.Schema{
+			{Name: "id", Type: "INT64", Required: true},
+			{Name: "name", Type: bigquery.StringFieldType},
+		}
+		generatedCode, err := GenerateStruct("Users", schema, Options{})
+		if err != nil {
+			t.Errorf("GenerateStruct: %v", err)
+		}
+		if string(generatedCode) != testStructCode {
+			t.Error("GenerateStruct: want=`" + testStructCode + "` current=`" + string(generatedCode) + "`")
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		schema := bigquery.Schema{{Name: "id", Type: "UNKNOWN"}}
+		if _, err := GenerateStruct("Users", schema, Options{}); !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Errorf("GenerateStruct: want=%v current=%v", ErrFieldTypeNotSupported, err)
+		}
+	})
+}
+
+func Test_generateStructCode(t *testing.T) {
+	var (
+		testTable = &bigquery.Table{
+			ProjectID: testProjectNotFound,
+			DatasetID: testDatasetNotFound,
+			TableID:   testTableID,
+		}
+		testMetadata = &bigquery.TableMetadata{
+			FullID: testProjectNotFound + ":" + testDatasetNotFound + "." + testTableID,
+			Schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType},
+				{Name: "name", Type: bigquery.StringFieldType},
+			},
+			Clustering: &bigquery.Clustering{Fields: []string{"id"}},
+		}
+	)
+
+	t.Run("正常系_testTableID", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testStructCode = "// Test_table is BigQuery Table `projectnotfound:datasetnotfound.test_table` schema struct.\n" +
+				"type Test_table struct {\n" +
+				"\tId int64 `bigquery:\"id\"`\n" +
+				"\tName string `bigquery:\"name\"`\n" +
+				"}\n"
+		)
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		if generatedCode != testStructCode {
+			t.Error("generateStructCode: want=`" + testStructCode + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_EmitClustered", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{EmitClustered: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "`bigquery:\"id\"` // clustered\n") {
+			t.Error("generateStructCode: clustered comment not found: " + generatedCode)
+		}
+		if strings.Contains(generatedCode, "`bigquery:\"name\"` // clustered\n") {
+			t.Error("generateStructCode: unexpected clustered comment: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_EmitConsoleLinks", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{EmitConsoleLinks: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "// Console: https://console.cloud.google.com/bigquery?p=projectnotfound&d=datasetnotfound&t=test_table\ntype Test_table struct {\n") {
+			t.Error("generateStructCode: console link not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_pseudo_column", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "_PARTITIONTIME", Type: bigquery.TimestampFieldType},
+					{Name: "_id", Type: bigquery.StringFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{AnnotateNullable: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\t_PARTITIONTIME time.Time `bigquery:\"_PARTITIONTIME\"` // pseudo column, nullable\n",
+			"\t_id string `bigquery:\"_id\"` // nullable\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_EmitViewQuery", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				FullID:    "projectnotfound:datasetnotfound.test_table",
+				ViewQuery: "SELECT id\r\nFROM users  \nWHERE deleted_at IS NULL\n",
+				Schema:    bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitViewQuery: true, ViewQueryMaxLines: 2})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "// View query:\n//\tSELECT id\n//\tFROM users\n//\t...\ntype Test_table struct {\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_StrictCase", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{StrictCase: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "// The `bigquery` tags are the exact-case column names. Match them case-sensitively.\ntype Test_table struct {\n") {
+			t.Error("generateStructCode: strict case comment not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_ProtoNumbers", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{ProtoNumbers: map[*bigquery.FieldSchema]int{testMetadata.Schema[1]: 3}})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tId int64 `bigquery:\"id\"`\n\tName string `bigquery:\"name\" protobuf:\"3\"`\n") {
+			t.Error("generateStructCode: protobuf tag not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_Immutable", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{Immutable: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "func (t *Test_table) ToImmutable() Test_tableImmutable {\n\treturn NewTest_tableImmutable(t.Id, t.Name)\n}\n") {
+			t.Error("generateStructCode: ToImmutable not found: " + generatedCode)
+		}
+		if _, err := format.Source([]byte("package bqschema\n\n" + generatedCode)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_EmitStructID", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{EmitStructID: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\nconst Test_tableStructID = \""+structID(testDatasetNotFound, testTableID)+"\"\n") {
+			t.Error("generateStructCode: struct ID not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_EmbedPatterns", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testStructCode = "// Test_table is BigQuery Table `projectnotfound:datasetnotfound.test_table` schema struct.\n" +
+				"type Test_table struct {\n" +
+				"\tTest_tableMetadata\n" +
+				"\tId int64 `bigquery:\"id\"`\n" +
+				"\tName string `bigquery:\"name\"`\n" +
+				"}\n" +
+				"\n" +
+				"// Test_tableMetadata is the metadata columns of BigQuery Table `projectnotfound:datasetnotfound.test_table` embedded in Test_table.\n" +
+				"type Test_tableMetadata struct {\n" +
+				"\tCreated_at time.Time `bigquery:\"created_at\"`\n" +
+				"}\n"
+		)
+		var (
+			md = &bigquery.TableMetadata{
+				FullID: testMetadata.FullID,
+				Schema: append(bigquery.Schema{
+					{Name: "created_at", Type: bigquery.TimestampFieldType},
+					{Name: "deleted_at", Type: bigquery.StringFieldType},
+				}, testMetadata.Schema...),
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmbedPatterns: []EmbedPattern{{Type: bigquery.TimestampFieldType, Pattern: "*_at"}}})
+		if err != nil {
+			t.Error(err)
+		}
+		// deleted_at is not TIMESTAMP
+		if !strings.Contains(generatedCode, "\tDeleted_at string `bigquery:\"deleted_at\"`\n") {
+			t.Error("generateStructCode: deleted_at not found: " + generatedCode)
+		}
+		generatedCode = strings.Replace(generatedCode, "\tDeleted_at string `bigquery:\"deleted_at\"`\n", "", 1)
+		if generatedCode != testStructCode {
+			t.Error("generateStructCode: want=`" + testStructCode + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_GormTags", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{GormTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tName string `bigquery:\"name\" gorm:\"column:name\"`\n") {
+			t.Error("generateStructCode: gorm tag not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_AnnotateNullable", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "created_at", Type: bigquery.TimestampFieldType},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{AnnotateNullable: true, NullTypesFor: map[bigquery.FieldType]bool{bigquery.TimestampFieldType: true}})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tId int64 `bigquery:\"id\"`\n",
+			"\tName string `bigquery:\"name\"` // nullable\n",
+			"\tCreated_at bigquery.NullTimestamp `bigquery:\"created_at\"` // nullable\n",
+			"\tTags []string `bigquery:\"tags\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_AnnotateUTC_EmitInUTC", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "created_at", Type: bigquery.TimestampFieldType, Required: true},
+					{Name: "deleted_at", Type: bigquery.TimestampFieldType},
+					{Name: "logged_at", Type: bigquery.TimestampFieldType, Repeated: true},
+					{Name: "birthday", Type: bigquery.DateFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{AnnotateUTC: true, EmitInUTC: true, NullTypes: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tId int64 `bigquery:\"id\"`\n",
+			"\tCreated_at time.Time `bigquery:\"created_at\"` // UTC\n",
+			"\tDeleted_at bigquery.NullTimestamp `bigquery:\"deleted_at\"` // UTC\n",
+			"\tLogged_at []time.Time `bigquery:\"logged_at\"` // UTC\n",
+			"\tBirthday bigquery.NullDate `bigquery:\"birthday\"`\n",
+			"func (t *Test_table) InUTC() {\n" +
+				"\tt.Created_at = t.Created_at.UTC()\n" +
+				"\tif t.Deleted_at.Valid {\n" +
+				"\t\tt.Deleted_at.Timestamp = t.Deleted_at.Timestamp.UTC()\n" +
+				"\t}\n" +
+				"\tfor i := range t.Logged_at {\n" +
+				"\t\tt.Logged_at[i] = t.Logged_at[i].UTC()\n" +
+				"\t}\n" +
+				"}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_Debug_TableColumns", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		log.SetOutput(buf)
+		defer log.SetOutput(os.Stderr)
+
+		// A column has been filtered out of the 3 columns of the table, e.g. by TableOverrides
+		if _, _, err := generateStructCode(testTable, testMetadata, Options{Debug: true, TableColumns: map[string]int{testTableID: 3}}); err != nil {
+			t.Fatal(err)
+		}
+		if want := "WARN: struct `Test_table` has 2 fields, but BigQuery Table `" + testMetadata.FullID + "` has 3 columns"; !strings.Contains(buf.String(), want) {
+			t.Error("generateStructCode: want=`" + want + "` current=`" + buf.String() + "`")
+		}
+
+		buf.Reset()
+		if _, _, err := generateStructCode(testTable, testMetadata, Options{Debug: true, TableColumns: map[string]int{testTableID: 2}}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "fields, but") {
+			t.Error("generateStructCode: current=`" + buf.String() + "`")
+		}
+	})
+
+	t.Run("正常系_NullTypes", func(t *testing.T) {
+		md := &bigquery.TableMetadata{
+			Schema: bigquery.Schema{
+				{Name: "s", Type: bigquery.StringFieldType},
+				{Name: "i", Type: bigquery.IntegerFieldType},
+				{Name: "f", Type: bigquery.FloatFieldType},
+				{Name: "b", Type: bigquery.BooleanFieldType},
+				{Name: "ts", Type: bigquery.TimestampFieldType},
+				{Name: "d", Type: bigquery.DateFieldType},
+				{Name: "t", Type: bigquery.TimeFieldType},
+				{Name: "dt", Type: bigquery.DateTimeFieldType},
+				{Name: "g", Type: bigquery.GeographyFieldType},
+				{Name: "n", Type: bigquery.NumericFieldType},
+				{Name: "by", Type: bigquery.BytesFieldType},
+				{Name: "r", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "c", Type: bigquery.StringFieldType}}},
+				{Name: "req", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "rep", Type: bigquery.StringFieldType, Repeated: true},
+			},
+		}
+		generatedCode, _, err := generateStructCode(testTable, md, Options{NullTypes: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the scalar columns are not pointers, but the bigquery Null* types
+		for _, want := range []string{
+			"\tS bigquery.NullString `bigquery:\"s\"`\n",
+			"\tI bigquery.NullInt64 `bigquery:\"i\"`\n",
+			"\tF bigquery.NullFloat64 `bigquery:\"f\"`\n",
+			"\tB bigquery.NullBool `bigquery:\"b\"`\n",
+			"\tTs bigquery.NullTimestamp `bigquery:\"ts\"`\n",
+			"\tD bigquery.NullDate `bigquery:\"d\"`\n",
+			"\tT bigquery.NullTime `bigquery:\"t\"`\n",
+			"\tDt bigquery.NullDateTime `bigquery:\"dt\"`\n",
+			"\tG bigquery.NullGeography `bigquery:\"g\"`\n",
+			"\tN *big.Rat `bigquery:\"n\"`\n",
+			"\tBy []uint8 `bigquery:\"by\"`\n",
+			"\tR *Test_tableR `bigquery:\"r\"`\n",
+			"\tReq int64 `bigquery:\"req\"`\n",
+			"\tRep []string `bigquery:\"rep\"`\n",
+			"\tC bigquery.NullString `bigquery:\"c\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_IgnoredColumns", func(t *testing.T) {
+		md := &bigquery.TableMetadata{
+			Schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			},
+		}
+		opts := Options{
+			EmitValueMap: true,
+			IgnoredColumns: map[string]bigquery.Schema{testTableID: {
+				{Name: "password", Type: bigquery.StringFieldType, Required: true},
+			}},
+		}
+		generatedCode, _, err := generateStructCode(testTable, md, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "\tId int64 `bigquery:\"id\"`\n" +
+			"\n" +
+			"\t// The columns excluded by the overrides, which are not loaded.\n" +
+			"\tPassword string `bigquery:\"-\"` // column password\n" +
+			"}\n"
+		if !strings.Contains(generatedCode, want) {
+			t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+		if strings.Contains(generatedCode, "t.Password") {
+			t.Error("generateStructCode: the ignored field is referred to: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_EmitInUTC_NullTypes", func(t *testing.T) {
+		md := &bigquery.TableMetadata{
+			Schema: bigquery.Schema{
+				{Name: "deleted_at", Type: bigquery.TimestampFieldType},
+			},
+		}
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitInUTC: true, NullTypes: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tDeleted_at bigquery.NullTimestamp `bigquery:\"deleted_at\"`\n",
+			"func (t *Test_table) InUTC() {\n" +
+				"\tif t.Deleted_at.Valid {\n" +
+				"\t\tt.Deleted_at.Timestamp = t.Deleted_at.Timestamp.UTC()\n" +
+				"\t}\n" +
+				"}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_EmitInUTC_no_TIMESTAMP", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitInUTC: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(generatedCode, "InUTC") {
+			t.Error("generateStructCode: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_MaxNameLength", func(t *testing.T) {
+		var (
+			table = &bigquery.Table{ProjectID: testTable.ProjectID, DatasetID: testTable.DatasetID, TableID: "customer_addresses"}
+			md    = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "address_line_1", Type: bigquery.StringFieldType},
+					{Name: "address_line_2", Type: bigquery.StringFieldType},
+				},
+			}
+			opts = Options{MaxNameLength: 12}
+		)
+		generatedCode, _, err := generateStructCode(table, md, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"type " + truncateName("Customer_addresses", 12) + " struct {\n",
+			"\tId int64 `bigquery:\"id\"`\n",
+			"\t" + truncateName("Address_line_1", 12) + " string `bigquery:\"address_line_1\"`\n",
+			"\t" + truncateName("Address_line_2", 12) + " string `bigquery:\"address_line_2\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_SpannerTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "customer_id", Type: bigquery.IntegerFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{SpannerTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tCustomer_id int64 `bigquery:\"customer_id\" spanner:\"CustomerId\"`\n") {
+			t.Error("generateStructCode: spanner tag not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_YAMLTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "user_name", Type: bigquery.StringFieldType},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+					{Name: "address", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+						{Name: "zip_code", Type: bigquery.StringFieldType},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{YAMLTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tUser_name string `bigquery:\"user_name\" yaml:\"user_name\"`\n",
+			"\tTags []string `bigquery:\"tags\" yaml:\"tags\"`\n",
+			"\tAddress []Test_tableAddress `bigquery:\"address\" yaml:\"address\"`\n",
+			"\tZip_code string `bigquery:\"zip_code\" yaml:\"zip_code\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: yaml tag not found: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_BSONTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "ID", Type: bigquery.StringFieldType, Required: true},
+					{Name: "user_name", Type: bigquery.StringFieldType},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "id", Type: bigquery.IntegerFieldType},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{BSONTags: true, BSONIDColumn: "id"})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tID string `bigquery:\"ID\" bson:\"_id\"`\n",
+			"\tUser_name string `bigquery:\"user_name\" bson:\"user_name\"`\n",
+			"\tAddress Test_tableAddress `bigquery:\"address\" bson:\"address\"`\n",
+			"\tId int64 `bigquery:\"id\" bson:\"id\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: bson tag not found: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_EmitRowInterface", func(t *testing.T) {
+		generatedCode, _, err := generateStructCode(testTable, testMetadata, Options{EmitRowInterface: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "// isBQRow implements Row.\nfunc (Test_table) isBQRow() {}\n") {
+			t.Error("generateStructCode: isBQRow not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_FirestoreTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "user_name", Type: bigquery.StringFieldType},
+					{Name: "_PARTITIONTIME", Type: bigquery.TimestampFieldType},
+					// Firestore stores nested structs as maps, keyed by the tags of the fields.
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "zip_code", Type: bigquery.StringFieldType},
+						{Name: "geo", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+							{Name: "lat", Type: bigquery.FloatFieldType},
+						}},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{FirestoreTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tUser_name string `bigquery:\"user_name\" firestore:\"user_name\"`\n",
+			"\t_PARTITIONTIME time.Time `bigquery:\"_PARTITIONTIME\" firestore:\"-\"` // pseudo column\n",
+			"\tAddress Test_tableAddress `bigquery:\"address\" firestore:\"address\"`\n",
+			"\tZip_code string `bigquery:\"zip_code\" firestore:\"zip_code\"`\n",
+			"\tGeo []Test_tableAddressGeo `bigquery:\"geo\" firestore:\"geo\"`\n",
+			"\tLat float64 `bigquery:\"lat\" firestore:\"lat\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: firestore tag not found: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_EmitOrdinal", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				},
+			}
+		)
+		// FieldGroup moves id to the top, but the ordinal is the position in the table schema
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitOrdinal: true, FieldGroup: FieldGroupMode})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tId int64 `bigquery:\"id\" ordinal:\"1\"`\n\tName string `bigquery:\"name\" ordinal:\"0\"`\n") {
+			t.Error("generateStructCode: ordinal tag not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_EmitModeTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitModeTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\tId int64 `bigquery:\"id\" mode:\"REQUIRED\"`\n",
+			"\tName string `bigquery:\"name\" mode:\"NULLABLE\"`\n",
+			"\tTags []string `bigquery:\"tags\" mode:\"REPEATED\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: mode tag not found: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_deprecated", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "old_id", Type: bigquery.IntegerFieldType, Description: "legacy ID.\nDeprecated: use id instead."},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "zip", Type: bigquery.StringFieldType, Description: "DEPRECATED:"},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\t// Deprecated: use id instead.\n\tOld_id int64 `bigquery:\"old_id\"`\n") {
+			t.Error("generateStructCode: deprecation comment not found: " + generatedCode)
+		}
+		if !strings.Contains(generatedCode, "\t// Deprecated: the column is deprecated.\n\tZip string `bigquery:\"zip\"`\n") {
+			t.Error("generateStructCode: deprecation comment of nested field not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_EmitBQTypeComment", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true, Description: "Deprecated: use uuid instead."},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "lines", Type: bigquery.StringFieldType, Repeated: true},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{EmitBQTypeComment: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\t// BQ: INTEGER REQUIRED\n\t//\n\t// Deprecated: use uuid instead.\n\tId int64 `bigquery:\"id\"`\n",
+			"\t// BQ: RECORD NULLABLE\n\tAddress Test_tableAddress `bigquery:\"address\"`\n",
+			"\t// BQ: STRING REPEATED\n\tLines []string `bigquery:\"lines\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateStructCode: BQ type comment not found: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_UnsupportedAsAny", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "payload", Type: testNotSupportedFieldType},
+				},
+			}
+		)
+		if _, _, err := generateStructCode(testTable, md, Options{}); !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Errorf("generateStructCode: want=errFieldTypeNotSupported current=%v", err)
+		}
+		generatedCode, _, err := generateStructCode(testTable, md, Options{UnsupportedAsAny: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tPayload interface{} `bigquery:\"payload\"` // unsupported BigQuery type: "+testNotSupportedFieldType+"\n") {
+			t.Error("generateStructCode: interface{} field not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_AvroTags", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "1st", Type: bigquery.StringFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{AvroTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tId int64 `bigquery:\"id\" avro:\"id\"`\n") {
+			t.Error("generateStructCode: avro tag not found: " + generatedCode)
+		}
+		if !strings.Contains(generatedCode, "`bigquery:\"1st\" avro:\"_1st\"` // avro: `1st` is not a valid Avro name\n") {
+			t.Error("generateStructCode: sanitized avro tag not found: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_column_names_that_need_escaping", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "first name", Type: bigquery.StringFieldType},
+					{Name: "say \"hi\"", Type: bigquery.StringFieldType},
+					{Name: "back`quote", Type: bigquery.StringFieldType},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{GormTags: true})
+		if err != nil {
+			t.Error(err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), "", "package bqschema\n\n"+generatedCode, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields []*ast.Field
+		ast.Inspect(f, func(node ast.Node) bool {
+			if st, ok := node.(*ast.StructType); ok && fields == nil {
+				fields = st.Fields.List
+			}
+			return true
+		})
+		if len(fields) != len(md.Schema) {
+			t.Fatal("generateStructCode: unexpected structs: " + generatedCode)
+		}
+		for i, field := range fields {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				t.Error(err)
+			}
+			if current := reflect.StructTag(tag).Get("bigquery"); current != md.Schema[i].Name {
+				t.Error("generateStructCode: want=`" + md.Schema[i].Name + "` current=`" + current + "`")
+			}
+			if current := reflect.StructTag(tag).Get("gorm"); current != "column:"+md.Schema[i].Name {
+				t.Error("generateStructCode: want=`column:" + md.Schema[i].Name + "` current=`" + current + "`")
+			}
+		}
+		if fields[0].Names[0].Name != "First_name" {
+			t.Error("generateStructCode: want=`First_name` current=`" + fields[0].Names[0].Name + "`")
+		}
+	})
+
+	t.Run("正常系_RecordFieldType", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testStructCode = "// Test_table is BigQuery Table `` schema struct.\n" +
+				"type Test_table struct {\n" +
+				"\tId int64 `bigquery:\"id\"`\n" +
+				"\tLine_items []Test_tableLine_items `bigquery:\"line_items\"`\n" +
+				"}\n" +
+				"\n" +
+				"// Test_tableLine_items is the RECORD column `line_items` of Test_table.\n" +
+				"type Test_tableLine_items struct {\n" +
+				"\tSku string `bigquery:\"sku\"`\n" +
+				"\tPrice Test_tableLine_itemsPrice `bigquery:\"price\"`\n" +
+				"}\n" +
+				"\n" +
+				"// Test_tableLine_itemsPrice is the RECORD column `price` of Test_tableLine_items.\n" +
+				"type Test_tableLine_itemsPrice struct {\n" +
+				"\tAmount float64 `bigquery:\"amount\"`\n" +
+				"}\n"
+		)
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "line_items", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+						{Name: "sku", Type: bigquery.StringFieldType},
+						{Name: "price", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+							{Name: "amount", Type: bigquery.FloatFieldType},
+						}},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		if generatedCode != testStructCode {
+			t.Error("generateStructCode: want=`" + testStructCode + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_RecordFieldType_NullTypes", func(t *testing.T) {
+		var (
+			md = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "rec", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					}},
+				},
+			}
+		)
+		generatedCode, _, err := generateStructCode(testTable, md, Options{NullTypes: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "\tRec *Test_tableRec `bigquery:\"rec\"`\n") {
+			t.Error("generateStructCode: pointer to nested struct not found: " + generatedCode)
+		}
+	})
+
+	t.Run("異常系_testNotSupportedFieldType_in_RecordFieldType", func(t *testing.T) {
+		var (
+			ngMetadata = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "rec", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "ng", Type: bigquery.FieldType(testNotSupportedFieldType)},
+					}},
+				},
+			}
+		)
+		_, _, err := generateStructCode(testTable, ngMetadata, Options{})
+		if !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Error(err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "column=rec: fieldSchemaToGoType: column=ng") {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testNotSupportedFieldType", func(t *testing.T) {
+		var (
+			ngMetadata = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "ng", Type: bigquery.FieldType(testNotSupportedFieldType)},
+				},
+			}
+		)
+		_, _, err := generateStructCode(testTable, ngMetadata, Options{})
+		if !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Error(err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "column=ng") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_generateImmutableCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImmutableCode = "// UsersImmutable is the immutable version of Users.\n" +
+				"type UsersImmutable struct {\n" +
+				"\tid int64\n" +
+				"\ttags []string\n" +
+				"}\n" +
+				"\n" +
+				"// NewUsersImmutable returns a new UsersImmutable.\n" +
+				"func NewUsersImmutable(id int64, tags []string) UsersImmutable {\n" +
+				"\treturn UsersImmutable{\n" +
+				"\t\tid: id,\n" +
+				"\t\ttags: append([]string(nil), tags...),\n" +
+				"\t}\n" +
+				"}\n" +
+				"\n" +
+				"// ID returns the value of the column `id`.\n" +
+				"func (u UsersImmutable) ID() int64 {\n" +
+				"\treturn u.id\n" +
+				"}\n" +
+				"\n" +
+				"// Tags returns the value of the column `tags`.\n" +
+				"func (u UsersImmutable) Tags() []string {\n" +
+				"\treturn append([]string(nil), u.tags...)\n" +
+				"}\n" +
+				"\n" +
+				"// ToImmutable returns the UsersImmutable of Users.\n" +
+				"func (u *Users) ToImmutable() UsersImmutable {\n" +
+				"\treturn NewUsersImmutable(u.ID, u.Tags)\n" +
+				"}\n"
+		)
+		var (
+			fields = []goField{
+				{Name: "ID", Type: "int64", Column: "id"},
+				{Name: "Tags", Type: "[]string", Column: "tags"},
+			}
+		)
+		if generatedCode := generateImmutableCode("Users", fields, Options{}); generatedCode != testImmutableCode {
+			t.Error("generateImmutableCode: want=`" + testImmutableCode + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_InitNumericZero", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testConstructorCode = "func NewUsersImmutable(id int64, price *big.Rat, prices []*big.Rat) UsersImmutable {\n" +
+				"\tif price == nil {\n" +
+				"\t\tprice = big.NewRat(0, 1)\n" +
+				"\t}\n" +
+				"\treturn UsersImmutable{\n"
+		)
+		var (
+			fields = []goField{
+				{Name: "ID", Type: "int64", Column: "id"},
+				{Name: "Price", Type: "*big.Rat", Column: "price"},
+				{Name: "Prices", Type: "[]*big.Rat", Column: "prices"},
+			}
+		)
+		if generatedCode := generateImmutableCode("Users", fields, Options{InitNumericZero: true}); !strings.Contains(generatedCode, testConstructorCode) {
+			t.Error("generateImmutableCode: want=`" + testConstructorCode + "` current=`" + generatedCode + "`")
+		}
+		if generatedCode := generateImmutableCode("Users", fields, Options{}); strings.Contains(generatedCode, "big.NewRat") {
+			t.Error("generateImmutableCode: big.NewRat without InitNumericZero: " + generatedCode)
+		}
+	})
+	t.Run("正常系_not_exported", func(t *testing.T) {
+		var (
+			schemas = []TableSchema{{
+				Table: &bigquery.Table{ProjectID: testProjectNotFound, DatasetID: testDatasetNotFound, TableID: testTableID},
+				Metadata: &bigquery.TableMetadata{
+					Schema: bigquery.Schema{
+						{Name: "_id", Type: bigquery.IntegerFieldType, Required: true},
+						{Name: "_id_", Type: bigquery.IntegerFieldType},
+						{Name: "_PARTITIONTIME", Type: bigquery.TimestampFieldType},
+					},
+				},
+			}}
+		)
+		generatedCode, err := GenerateCode(schemas, Options{Immutable: true, NoGoimports: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", generatedCode, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		if _, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil); err != nil {
+			t.Error("generateImmutableCode: " + err.Error() + ": " + string(generatedCode))
+		}
+		if !strings.Contains(string(generatedCode), "\t_PARTITIONTIME_ time.Time\n") {
+			t.Error("generateImmutableCode: " + string(generatedCode))
+		}
+	})
+}
+
+func Test_generateValidateCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testValidateCode = "// Validate returns an error naming the REQUIRED columns of Users whose fields are nil,\n" +
+				"// or the error of the structs of the RECORD columns.\n" +
+				"func (u Users) Validate() error {\n" +
+				"\tvar nullColumns []string\n" +
+				"\tif u.Price == nil {\n" +
+				"\t\tnullColumns = append(nullColumns, \"price\")\n" +
+				"\t}\n" +
+				"\tif len(nullColumns) > 0 {\n" +
+				"\t\treturn fmt.Errorf(\"Users: REQUIRED columns are NULL: %s\", strings.Join(nullColumns, \", \"))\n" +
+				"\t}\n" +
+				"\tif err := u.Address.Validate(); err != nil {\n" +
+				"\t\treturn fmt.Errorf(\"address: %w\", err)\n" +
+				"\t}\n" +
+				"\tfor i := range u.Items {\n" +
+				"\t\tif err := u.Items[i].Validate(); err != nil {\n" +
+				"\t\t\treturn fmt.Errorf(\"items[%d]: %w\", i, err)\n" +
+				"\t\t}\n" +
+				"\t}\n" +
+				"\tif u.Profile != nil {\n" +
+				"\t\tif err := u.Profile.Validate(); err != nil {\n" +
+				"\t\t\treturn fmt.Errorf(\"profile: %w\", err)\n" +
+				"\t\t}\n" +
+				"\t}\n" +
+				"\treturn nil\n" +
+				"}\n"
+		)
+		var (
+			fields = []goField{
+				{Name: "ID", Type: "int64", Column: "id", Schema: &bigquery.FieldSchema{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+				{Name: "Price", Type: "*big.Rat", Column: "price", Schema: &bigquery.FieldSchema{Name: "price", Type: bigquery.NumericFieldType, Required: true}},
+				{Name: "Memo", Type: "*string", Column: "memo", Schema: &bigquery.FieldSchema{Name: "memo", Type: bigquery.StringFieldType}},
+				{Name: "Tags", Type: "[]string", Column: "tags", Schema: &bigquery.FieldSchema{Name: "tags", Type: bigquery.StringFieldType, Repeated: true}},
+				{Name: "Address", Type: "UsersAddress", Column: "address", Schema: &bigquery.FieldSchema{Name: "address", Type: bigquery.RecordFieldType, Required: true}},
+				{Name: "Items", Type: "[]UsersItems", Column: "items", Schema: &bigquery.FieldSchema{Name: "items", Type: bigquery.RecordFieldType, Repeated: true}},
+				{Name: "Profile", Type: "*UsersProfile", Column: "profile", Schema: &bigquery.FieldSchema{Name: "profile", Type: bigquery.RecordFieldType}},
+				// NULL cannot be told from the zero value
+				{Name: "Settings", Type: "UsersSettings", Column: "settings", Schema: &bigquery.FieldSchema{Name: "settings", Type: bigquery.RecordFieldType}},
+			}
+		)
+		generatedCode, importPackages := generateValidateCode("Users", fields, Options{})
+		if generatedCode != testValidateCode {
+			t.Error("generateValidateCode: want=`" + testValidateCode + "` current=`" + generatedCode + "`")
+		}
+		if expect := []string{"fmt", "strings", "fmt"}; !reflect.DeepEqual(importPackages, expect) {
+			t.Errorf("generateValidateCode: want=%v current=%v", expect, importPackages)
+		}
+	})
+
+	t.Run("正常系_no_checks", func(t *testing.T) {
+		var (
+			fields = []goField{
+				{Name: "ID", Type: "int64", Column: "id", Schema: &bigquery.FieldSchema{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+			}
+		)
+		generatedCode, importPackages := generateValidateCode("Users", fields, Options{})
+		if !strings.HasSuffix(generatedCode, "func (u Users) Validate() error {\n\treturn nil\n}\n") || len(importPackages) != 0 {
+			t.Errorf("generateValidateCode: current=`%s` %v", generatedCode, importPackages)
+		}
+	})
+}
+
+func Test_generateValueMapCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testValueMapCode = "// ToValueMap returns the values of the columns of Users to be inserted by the bigquery client.\n" +
+				"// NULL values are omitted.\n" +
+				"func (u Users) ToValueMap() map[string]bigquery.Value {\n" +
+				"\tvalues := make(map[string]bigquery.Value)\n" +
+				"\tvalues[\"id\"] = u.Id\n" +
+				"\tif u.Price != nil {\n" +
+				"\t\tvalues[\"price\"] = bigquery.NumericString(u.Price)\n" +
+				"\t}\n" +
+				"\treturn values\n" +
+				"}\n" +
+				"\n" +
+				"// UsersFromValueMap returns the Users of the values loaded by the bigquery client,\n" +
+				"// e.g. by RowIterator.Next(&map[string]bigquery.Value{}). Values of unexpected types are ignored.\n" +
+				"func UsersFromValueMap(values map[string]bigquery.Value) Users {\n" +
+				"\tvar u Users\n" +
+				"\tif value, ok := values[\"id\"].(int64); ok {\n" +
+				"\t\tu.Id = value\n" +
+				"\t}\n" +
+				"\tif value, ok := values[\"price\"].(*big.Rat); ok {\n" +
+				"\t\tu.Price = value\n" +
+				"\t}\n" +
+				"\treturn u\n" +
+				"}\n"
+		)
+		var (
+			fields = []goField{
+				{Name: "Id", Type: "int64", Column: "id", Schema: &bigquery.FieldSchema{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+				{Name: "Price", Type: "*big.Rat", Column: "price", Schema: &bigquery.FieldSchema{Name: "price", Type: bigquery.NumericFieldType}},
+			}
+		)
+		generatedCode, importPackages := generateValueMapCode("Users", fields, Options{})
+		if generatedCode != testValueMapCode {
+			t.Error("generateValueMapCode: want=`" + testValueMapCode + "` current=`" + generatedCode + "`")
+		}
+		if want := []string{bigqueryPkgPath, "math/big"}; !reflect.DeepEqual(importPackages, want) {
+			t.Errorf("generateValueMapCode: want=%v current=%v", want, importPackages)
+		}
+	})
+}
+
+func Test_valueMapToCode(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		field goField
+		want  string
+	}{
+		{"正常系_pointer", goField{Type: "*civil.Time", Column: "t", Schema: &bigquery.FieldSchema{Type: bigquery.TimeFieldType}},
+			"\tif u.T != nil {\n\t\tvalues[\"t\"] = bigquery.CivilTimeString(*u.T)\n\t}\n"},
+		{"正常系_NullInt64", goField{Type: "bigquery.NullInt64", Column: "i", Schema: &bigquery.FieldSchema{Type: bigquery.IntegerFieldType}},
+			"\tif u.T.Valid {\n\t\tvalues[\"i\"] = u.T.Int64\n\t}\n"},
+		{"正常系_NullTime", goField{Type: "bigquery.NullTime", Column: "t", Schema: &bigquery.FieldSchema{Type: bigquery.TimeFieldType}},
+			"\tif u.T.Valid {\n\t\tvalues[\"t\"] = bigquery.CivilTimeString(u.T.Time)\n\t}\n"},
+		{"正常系_NullString", goField{Type: "bigquery.NullString", Column: "s", Schema: &bigquery.FieldSchema{Type: bigquery.StringFieldType}},
+			"\tif u.T.Valid {\n\t\tvalues[\"s\"] = u.T.StringVal\n\t}\n"},
+		{"正常系_repeated", goField{Type: "[]string", Column: "s", Schema: &bigquery.FieldSchema{Type: bigquery.StringFieldType, Repeated: true}},
+			"\tvalues[\"s\"] = u.T\n"},
+		{"正常系_RecordFieldType_NullTypes", goField{Type: "*UsersAddress", Column: "r", Schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType}},
+			"\tif u.T != nil {\n\t\tvalues[\"r\"] = u.T.ToValueMap()\n\t}\n"},
+		{"正常系_TypeOverrides", goField{Type: "int64", Column: "ts", Schema: &bigquery.FieldSchema{Type: bigquery.TimestampFieldType}},
+			"\tvalues[\"ts\"] = u.T\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if generatedCode := valueMapToCode(tt.field, "u.T"); generatedCode != tt.want {
+				t.Error("valueMapToCode: want=`" + tt.want + "` current=`" + generatedCode + "`")
+			}
+		})
+	}
+}
+
+func Test_valueMapFromCode(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		field goField
+		want  string
+	}{
+		{"正常系_pointer", goField{Type: "*civil.Time", Column: "t", Schema: &bigquery.FieldSchema{Type: bigquery.TimeFieldType}},
+			"\tif value, ok := values[\"t\"].(civil.Time); ok {\n\t\tu.T = &value\n\t}\n"},
+		{"正常系_NullTimestamp", goField{Type: "bigquery.NullTimestamp", Column: "ts", Schema: &bigquery.FieldSchema{Type: bigquery.TimestampFieldType}},
+			"\tif value, ok := values[\"ts\"].(time.Time); ok {\n\t\tu.T = bigquery.NullTimestamp{Timestamp: value, Valid: true}\n\t}\n"},
+		{"正常系_RecordFieldType_NullTypes", goField{Type: "*UsersAddress", Column: "r", Schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType}},
+			"\tif value, ok := values[\"r\"].(map[string]bigquery.Value); ok {\n\t\tnested := UsersAddressFromValueMap(value)\n\t\tu.T = &nested\n\t}\n"},
+		{"正常系_RecordFieldType_repeated", goField{Type: "[]UsersAddress", Column: "r", Schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true}},
+			"\tif elems, ok := values[\"r\"].([]bigquery.Value); ok {\n" +
+				"\t\tu.T = make([]UsersAddress, 0, len(elems))\n" +
+				"\t\tfor _, elem := range elems {\n" +
+				"\t\t\tif value, ok := elem.(map[string]bigquery.Value); ok {\n" +
+				"\t\t\t\tu.T = append(u.T, UsersAddressFromValueMap(value))\n" +
+				"\t\t\t}\n" +
+				"\t\t}\n" +
+				"\t}\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if generatedCode := valueMapFromCode(tt.field, "u.T"); generatedCode != tt.want {
+				t.Error("valueMapFromCode: want=`" + tt.want + "` current=`" + generatedCode + "`")
+			}
+		})
+	}
+}
+
+func Test_receiverName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			typeName string
+			style    string
+			expect   string
+		}{
+			{"Users", "", "u"},
+			{"Users", ReceiverStyleShort, "u"},
+			{"Users", ReceiverStyleFull, "users"},
+			{"URLPaths", ReceiverStyleFull, "urlPaths"},
+			{"UsersImmutable", ReceiverStyleFull, "usersImmutable"},
+			{"Type", ReceiverStyleFull, "type_"},
+		} {
+			if name := receiverName(tt.typeName, tt.style); name != tt.expect {
+				t.Error("receiverName: " + tt.typeName + ": " + tt.style + ": want=" + tt.expect + " current=" + name)
+			}
+		}
+	})
+}
+
+func Test_unexportedName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for exported, unexported := range map[string]string{
+			"Id":          "id",
+			"ID":          "id",
+			"CustomerID":  "customerID",
+			"URLPath":     "urlPath",
+			"Customer_id": "customer_id",
+			"Type":        "type_",
+		} {
+			if v := unexportedName(exported); v != unexported {
+				t.Error("unexportedName: want=" + unexported + " current=" + v)
+			}
+		}
+	})
+}
+
+func Test_nestedStructName(t *testing.T) {
+	t.Run("正常系_default", func(t *testing.T) {
+		name, err := nestedStructName("Orders", "line_items", Options{Initialisms: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if name != "OrdersLineItems" {
+			t.Error("nestedStructName: want=OrdersLineItems current=" + name)
+		}
+	})
+
+	t.Run("正常系_NestedNameTemplate", func(t *testing.T) {
+		tmpl, err := ParseNestedNameTemplate("{{.Parent}}{{singular .Field}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, err := nestedStructName("Orders", "line_items", Options{Initialisms: true, NestedNameTemplate: tmpl})
+		if err != nil {
+			t.Error(err)
+		}
+		if name != "OrdersLineItem" {
+			t.Error("nestedStructName: want=OrdersLineItem current=" + name)
+		}
+	})
+
+	t.Run("異常系_invalid_name", func(t *testing.T) {
+		tmpl, err := ParseNestedNameTemplate("{{.Column}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := nestedStructName("Orders", "line_items", Options{NestedNameTemplate: tmpl}); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_parseNestedNameTemplate(t *testing.T) {
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		tmpl, err := ParseNestedNameTemplate(testEmptyString)
+		if err != nil || tmpl != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		if _, err := ParseNestedNameTemplate("{{.Parent"); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_singular(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for plural, s := range map[string]string{
+			"LineItems":  "LineItem",
+			"Categories": "Category",
+			"Addresses":  "Address",
+			"Boxes":      "Box",
+			"Status":     "Status",
+			"Class":      "Class",
+			"Data":       "Data",
+		} {
+			if v := singular(plural); v != s {
+				t.Error("singular: want=" + s + " current=" + v)
+			}
+		}
+	})
+}
+
+func Test_structID(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		id := structID(testDatasetNotFound, testTableID)
+		if len(id) != 8 {
+			t.Error("structID: want 8 characters, current=`" + id + "`")
+		}
+		if current := structID(testDatasetNotFound, testTableID); current != id {
+			t.Error("structID: not stable: want=`" + id + "` current=`" + current + "`")
+		}
+		if current := structID(testDatasetNotFound, testTableID+"2"); current == id {
+			t.Error("structID: same ID for a different table: `" + current + "`")
+		}
+	})
+}
+
+func Test_generateColumnMetaCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testColumnMetaCode = "// UsersColumns describes the columns of BigQuery Table `p:d.users`.\n" +
+				"var UsersColumns = []bqmeta.ColumnMeta{\n" +
+				"\t{Go: \"Id\", BQ: \"id\", Type: \"INTEGER\", Nullable: false, Repeated: false},\n" +
+				"\t{Go: \"Name\", BQ: \"name\", Type: \"STRING\", Nullable: true, Repeated: false},\n" +
+				"\t{Go: \"Tags\", BQ: \"tags\", Type: \"STRING\", Nullable: false, Repeated: true},\n" +
+				"}\n"
+		)
+		var (
+			md = &bigquery.TableMetadata{
+				FullID: "p:d.users",
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+				},
+			}
+		)
+		if generatedCode := generateColumnMetaCode("Users", md, Options{}); generatedCode != testColumnMetaCode {
+			t.Error("generateColumnMetaCode: want=`" + testColumnMetaCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateFieldTypesCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testFieldTypesCode = "// UsersFieldTypes maps the fields of Users to the field types of the columns of BigQuery Table `p:d.users`.\n" +
+				"var UsersFieldTypes = map[string]bigquery.FieldType{\n" +
+				"\t\"Id\": bigquery.IntegerFieldType,\n" +
+				"\t\"Address\": bigquery.RecordFieldType,\n" +
+				"\t\"Payload\": bigquery.FieldType(\"" + testNotSupportedFieldType + "\"),\n" +
+				"}\n"
+		)
+		var (
+			md = &bigquery.TableMetadata{
+				FullID: "p:d.users",
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "address", Type: bigquery.RecordFieldType},
+					{Name: "payload", Type: testNotSupportedFieldType},
+				},
+			}
+		)
+		if generatedCode := generateFieldTypesCode("Users", md, Options{}); generatedCode != testFieldTypesCode {
+			t.Error("generateFieldTypesCode: want=`" + testFieldTypesCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateCompareSchemaCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testCompareSchemaCode = "// CompareSchema fetches the metadata of BigQuery Table `p:d.users`\n" +
+				"// and returns the differences from the columns Users has been generated for.\n" +
+				"func (Users) CompareSchema(ctx context.Context, client *bigquery.Client) ([]bqmeta.SchemaDiff, error) {\n" +
+				"\tmd, err := client.DatasetInProject(\"p\", \"d\").Table(\"users\").Metadata(ctx)\n" +
+				"\tif err != nil {\n" +
+				"\t\treturn nil, fmt.Errorf(\"Metadata: %w\", err)\n" +
+				"\t}\n" +
+				"\treturn bqmeta.CompareSchema([]bqmeta.ColumnMeta{\n" +
+				"\t\t{Go: \"Id\", BQ: \"id\", Type: \"INTEGER\", Nullable: false, Repeated: false},\n" +
+				"\t}, md.Schema), nil\n" +
+				"}\n"
+		)
+		var (
+			table = &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+			md    = &bigquery.TableMetadata{
+				FullID: "p:d.users",
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				},
+			}
+		)
+		if generatedCode := generateCompareSchemaCode("Users", table, md, Options{}); generatedCode != testCompareSchemaCode {
+			t.Error("generateCompareSchemaCode: want=`" + testCompareSchemaCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateInserterCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testInserterCode = "// InsertUsers streams rows into BigQuery Table `p:d.users`.\n" +
+				"func InsertUsers(ctx context.Context, client *bigquery.Client, rows []Users) error {\n" +
+				"\tinserter := client.DatasetInProject(\"p\", \"d\").Table(\"users\").Inserter()\n" +
+				"\tif err := inserter.Put(ctx, rows); err != nil {\n" +
+				"\t\treturn fmt.Errorf(\"Put: %w\", err)\n" +
+				"\t}\n" +
+				"\treturn nil\n" +
+				"}\n"
+		)
+		var (
+			table = &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+			md    = &bigquery.TableMetadata{FullID: "p:d.users"}
+		)
+		if generatedCode := generateInserterCode("Users", table, md); generatedCode != testInserterCode {
+			t.Error("generateInserterCode: want=`" + testInserterCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_descriptionComment(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			description string
+			comment     string
+		}{
+			{"users of the service", "// Description: users of the service\n"},
+			{testEmptyString, testEmptyString},
+			{" ", testEmptyString},
+		} {
+			if comment := descriptionComment(tt.description); comment != tt.comment {
+				t.Errorf("descriptionComment: %q: want=%q current=%q", tt.description, tt.comment, comment)
+			}
+		}
+	})
+}
+
+func Test_generateImplementsCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImplementsCode = "// Users must implement the following interfaces.\n" +
+				"var _ mypkg.Record = (*Users)(nil)\n" +
+				"var _ fmt.Stringer = (*Users)(nil)\n"
+		)
+		interfaces := []GoType{{Name: "mypkg.Record", PkgPath: "example.com/mypkg"}, {Name: "fmt.Stringer", PkgPath: "fmt"}}
+		if generatedCode := generateImplementsCode("Users", interfaces); generatedCode != testImplementsCode {
+			t.Error("generateImplementsCode: want=`" + testImplementsCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateRegisterFuncCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testRegisterFuncCode = "\nfunc init() {\n" +
+				"\tmypkg.Register(\"users\", Users{})\n" +
+				"\tmypkg.Register(\"user_events\", User_events{})\n" +
+				"}\n"
+		)
+		tables := []*bigquery.Table{{TableID: "users"}, {TableID: "user_events"}}
+		opts := Options{RegisterFunc: GoType{Name: "mypkg.Register", PkgPath: "example.com/mypkg"}}
+		if generatedCode := generateRegisterFuncCode(tables, opts); generatedCode != testRegisterFuncCode {
+			t.Error("generateRegisterFuncCode: want=`" + testRegisterFuncCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateSetCode(t *testing.T) {
+	fields := []goField{
+		{Name: "Tags", Type: "[]string", Column: "tags", Schema: &bigquery.FieldSchema{Name: "tags", Type: bigquery.StringFieldType, Repeated: true}},
+		{Name: "Name", Type: "string", Column: "name", Schema: &bigquery.FieldSchema{Name: "name", Type: bigquery.StringFieldType}},
+	}
+
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testSetCode = "\n" +
+				"// TagsSet returns the values of Tags as a set.\n" +
+				"func (u *Users) TagsSet() map[string]struct{} {\n" +
+				"\tset := make(map[string]struct{}, len(u.Tags))\n" +
+				"\tfor _, v := range u.Tags {\n" +
+				"\t\tset[v] = struct{}{}\n" +
+				"\t}\n" +
+				"\treturn set\n" +
+				"}\n" +
+				"\n" +
+				"// SetTagsSet sets the values of the set to Tags in sorted order, so that the rows are deterministic.\n" +
+				"func (u *Users) SetTagsSet(set map[string]struct{}) {\n" +
+				"\tu.Tags = make([]string, 0, len(set))\n" +
+				"\tfor v := range set {\n" +
+				"\t\tu.Tags = append(u.Tags, v)\n" +
+				"\t}\n" +
+				"\tsort.Slice(u.Tags, func(i, j int) bool { return u.Tags[i] < u.Tags[j] })\n" +
+				"}\n"
+		)
+		if generatedCode := generateSetCode("users", "Users", fields, []string{"TAGS"}, Options{}); generatedCode != testSetCode {
+			t.Error("generateSetCode: want=`" + testSetCode + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_not_REPEATED_STRING", func(t *testing.T) {
+		if generatedCode := generateSetCode("users", "Users", fields, []string{"name", "notfound"}, Options{}); generatedCode != "" {
+			t.Error("generateSetCode: want=`` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateSelectQueryCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testSelectQueryCode = "// SelectQuery returns a query that selects the columns of Users from BigQuery Table `p:d.users`.\n" +
+				"// where is the condition of the WHERE clause, which is omitted if where is empty.\n" +
+				"func (Users) SelectQuery(where string) string {\n" +
+				"\tconst query = \"SELECT `id`, `name`, `address` FROM `p.d.users`\"\n" +
+				"\tif where == \"\" {\n" +
+				"\t\treturn query\n" +
+				"\t}\n" +
+				"\treturn query + \" WHERE \" + where\n" +
+				"}\n"
+		)
+		var (
+			table = &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+			md    = &bigquery.TableMetadata{
+				FullID: "p:d.users",
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "city", Type: bigquery.StringFieldType}}},
+				},
+			}
+		)
+		if generatedCode := generateSelectQueryCode("Users", table, md); generatedCode != testSelectQueryCode {
+			t.Error("generateSelectQueryCode: want=`" + testSelectQueryCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_quoteSQLIdentifier(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			identifier string
+			quoted     string
+		}{
+			{"id", "`id`"},
+			{"p.d.t", "`p.d.t`"},
+			{"a`b", "`a\\`b`"},
+		} {
+			if quoted := quoteSQLIdentifier(tt.identifier); quoted != tt.quoted {
+				t.Errorf("quoteSQLIdentifier: %s: want=%s current=%s", tt.identifier, tt.quoted, quoted)
+			}
+		}
+	})
+}
+
+func Test_avroName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			name          string
+			sanitizedName string
+			sanitized     bool
+		}{
+			{"created_at", "created_at", false},
+			{"_id", "_id", false},
+			{"1st", "_1st", true},
+			{"first-name", "first_name", true},
+			{"名前", "__", true},
+			{testEmptyString, "_", true},
+		} {
+			sanitizedName, sanitized := avroName(tt.name)
+			if sanitizedName != tt.sanitizedName || sanitized != tt.sanitized {
+				t.Errorf("avroName: %s: want=%s,%t current=%s,%t", tt.name, tt.sanitizedName, tt.sanitized, sanitizedName, sanitized)
+			}
+		}
+	})
+}
+
+func Test_matchEmbedPatterns(t *testing.T) {
+	var (
+		embedPatterns = []EmbedPattern{{Type: bigquery.TimestampFieldType, Pattern: "*_at"}, {Pattern: "*_by"}}
+	)
+	for _, tt := range []struct {
+		schema *bigquery.FieldSchema
+		want   bool
+	}{
+		{&bigquery.FieldSchema{Name: "created_at", Type: bigquery.TimestampFieldType}, true},
+		{&bigquery.FieldSchema{Name: "created_at", Type: bigquery.StringFieldType}, false},
+		{&bigquery.FieldSchema{Name: "created_by", Type: bigquery.StringFieldType}, true},
+		{&bigquery.FieldSchema{Name: "name", Type: bigquery.StringFieldType}, false},
+	} {
+		if matchEmbedPatterns(tt.schema, embedPatterns) != tt.want {
+			t.Errorf("matchEmbedPatterns: %s %s: want=%t", tt.schema.Name, tt.schema.Type, tt.want)
+		}
+	}
+}
+
+func Test_groupFieldsByMode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			schemas = []*bigquery.FieldSchema{
+				{Name: "r1", Repeated: true},
+				{Name: "n1"},
+				{Name: "q1", Required: true},
+				{Name: "n2"},
+				{Name: "q2", Required: true},
+				{Name: "r2", Repeated: true},
+			}
+			want = []string{"q1", "q2", "n1", "n2", "r1", "r2"}
+		)
+		grouped := groupFieldsByMode(schemas)
+		var current []string
+		for _, schema := range grouped {
+			current = append(current, schema.Name)
+		}
+		if strings.Join(current, ",") != strings.Join(want, ",") {
+			t.Errorf("groupFieldsByMode: want=%v current=%v", want, current)
+		}
+	})
+}
+
+func Test_tableIDToStructName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for tableID, structName := range map[string]string{
+			"users":         "Users",
+			"full_201510":   "Full_201510",
+			"my-table-name": "My_table_name",
+			testEmptyString: testEmptyString,
+		} {
+			if v := tableIDToStructName(tableID); v != structName {
+				t.Error("tableIDToStructName: want=" + structName + " current=" + v)
+			}
+		}
+	})
+}
+
+func Test_truncateName(t *testing.T) {
+	t.Run("正常系_not_truncated", func(t *testing.T) {
+		for _, tt := range []struct {
+			name      string
+			maxLength int
+		}{
+			{"CustomerName", 0},
+			{"CustomerName", 12},
+			{"CustomerName", 20},
+		} {
+			if truncated := truncateName(tt.name, tt.maxLength); truncated != tt.name {
+				t.Errorf("truncateName: name=%s maxLength=%d current=%s", tt.name, tt.maxLength, truncated)
+			}
+		}
+	})
+
+	t.Run("正常系_truncated", func(t *testing.T) {
+		var (
+			a = truncateName("CustomerAddressLine1", 12)
+			b = truncateName("CustomerAddressLine2", 12)
+		)
+		if len(a) != 12 || !strings.HasPrefix(a, "Cust") || len(b) != 12 || !strings.HasPrefix(b, "Cust") {
+			t.Error("truncateName: current=" + a + "," + b)
+		}
+		if a == b {
+			t.Error("truncateName: collision: " + a)
+		}
+		if again := truncateName("CustomerAddressLine1", 12); again != a {
+			t.Error("truncateName: not deterministic: " + a + "," + again)
+		}
+	})
+
+	t.Run("正常系_multibyte", func(t *testing.T) {
+		if truncated := truncateName("Ｃｕｓｔｏｍｅｒ名前", 10); len([]rune(truncated)) != 10 || !strings.HasPrefix(truncated, "Ｃｕ") {
+			t.Error("truncateName: current=" + truncated)
+		}
+	})
+}
+
+func Test_generateTypeRegistryCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testTypeRegistryCode = "// TableTypes maps BigQuery Table IDs to the schema struct types.\n" +
+				"var TableTypes = map[string]reflect.Type{\n" +
+				"\t\"users\": reflect.TypeOf(Users{}),\n" +
+				"\t\"my-table\": reflect.TypeOf(My_table{}),\n" +
+				"}\n"
+		)
+		generatedCode := generateTypeRegistryCode([]*bigquery.Table{{TableID: "users"}, {TableID: "my-table"}}, Options{})
+		if generatedCode != testTypeRegistryCode {
+			t.Error("generateTypeRegistryCode: want=`" + testTypeRegistryCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateRowInterfaceCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testRowDecoderCode = "\t\"my-table\": func(it *bigquery.RowIterator) (Row, error) {\n" +
+				"\t\tvar row My_table\n" +
+				"\t\tif err := it.Next(&row); err != nil {\n" +
+				"\t\t\treturn nil, err\n" +
+				"\t\t}\n" +
+				"\t\treturn &row, nil\n" +
+				"\t},\n"
+		)
+		generatedCode := generateRowInterfaceCode([]*bigquery.Table{{TableID: "users"}, {TableID: "my-table"}}, Options{})
+		for _, want := range []string{"type Row interface {\n\tisBQRow()\n}\n", "\t\"users\": func(it *bigquery.RowIterator) (Row, error) {\n", testRowDecoderCode} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateRowInterfaceCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if _, err := format.Source([]byte("package bqschema\n\nimport \"cloud.google.com/go/bigquery\"\n" + generatedCode)); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_generateGeneratedFromCode(t *testing.T) {
+	table := &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+
+	t.Run("正常系", func(t *testing.T) {
+		generatedCode := generateGeneratedFromCode(table, time.Date(2020, time.January, 2, 3, 4, 5, 6, time.FixedZone("JST", 9*60*60)))
+		if !strings.Contains(generatedCode, "\tProject:     \"p\",\n\tDataset:     \"d\",\n\tGeneratedAt: time.Date(2020, time.January, 1, 18, 4, 5, 0, time.UTC),\n") {
+			t.Error("generateGeneratedFromCode: current=`" + generatedCode + "`")
+		}
+		if _, err := format.Source([]byte("package bqschema\n\nimport \"time\"\n" + generatedCode)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_zero", func(t *testing.T) {
+		generatedCode := generateGeneratedFromCode(table, time.Time{})
+		if !strings.Contains(generatedCode, "\tGeneratedAt: time.Time{},\n") {
+			t.Error("generateGeneratedFromCode: current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_schemaVersion(t *testing.T) {
+	var (
+		users = TableSchema{
+			Table: &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"},
+			Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			}},
+		}
+		usersInOtherProject = TableSchema{
+			Table:    &bigquery.Table{ProjectID: "other", DatasetID: "other", TableID: "users"},
+			Metadata: users.Metadata,
+		}
+		usersNullable = TableSchema{
+			Table: users.Table,
+			Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType},
+			}},
+		}
+		orders = TableSchema{
+			Table: &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "orders"},
+			Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{
+				{Name: "item", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+				}},
+			}},
+		}
+	)
+
+	version := schemaVersion([]TableSchema{users, orders})
+	if len(version) != 16 {
+		t.Error("schemaVersion: current=" + version)
+	}
+
+	t.Run("正常系_same_version", func(t *testing.T) {
+		if v := schemaVersion([]TableSchema{orders, users}); v != version {
+			t.Error("schemaVersion: table order: want=" + version + " current=" + v)
+		}
+		if v := schemaVersion([]TableSchema{usersInOtherProject, orders}); v != version {
+			t.Error("schemaVersion: project: want=" + version + " current=" + v)
+		}
+	})
+
+	t.Run("正常系_different_version", func(t *testing.T) {
+		if v := schemaVersion([]TableSchema{usersNullable, orders}); v == version {
+			t.Error("schemaVersion: mode change is not detected: " + v)
+		}
+		if v := schemaVersion([]TableSchema{users}); v == version {
+			t.Error("schemaVersion: removed table is not detected: " + v)
+		}
+	})
+}
+
+func Test_generateAllColumnsCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testAllColumnsCode = "// Column names of all BigQuery Tables.\n" +
+				"const (\n" +
+				"\t// Users is BigQuery Table `p:d.users`.\n" +
+				"\tUsersColumnId = \"id\"\n" +
+				"\tUsersColumnAddress = \"address\"\n" +
+				"\tUsersColumnAddressCity = \"address.city\"\n" +
+				"\n" +
+				"\t// My_table is BigQuery Table `p:d.my-table`.\n" +
+				"\tMy_tableColumnName = \"name\"\n" +
+				")\n"
+		)
+		var (
+			schemas = []TableSchema{
+				{
+					Table: &bigquery.Table{TableID: "users"},
+					Metadata: &bigquery.TableMetadata{
+						FullID: "p:d.users",
+						Schema: bigquery.Schema{
+							{Name: "id", Type: bigquery.IntegerFieldType},
+							{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+								{Name: "city", Type: bigquery.StringFieldType},
+							}},
+						},
+					},
+				},
+				{
+					Table: &bigquery.Table{TableID: "my-table"},
+					Metadata: &bigquery.TableMetadata{
+						FullID: "p:d.my-table",
+						Schema: bigquery.Schema{
+							{Name: "name", Type: bigquery.StringFieldType},
+						},
+					},
+				},
+			}
+		)
+		generatedCode := generateAllColumnsCode(schemas, Options{})
+		if generatedCode != testAllColumnsCode {
+			t.Error("generateAllColumnsCode: want=`" + testAllColumnsCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_getAllTables(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		if _, err := GetAllTables(ctx, okClient, testEmptyString, testSupportedDatasetID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_testPublicDataProjectID_DatasetInProject", func(t *testing.T) {
+
+		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
+			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		}
+
+		var (
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
+		)
+
+		if _, err := GetAllTables(ctx, okClient, testPublicDataProjectID, testSupportedDatasetID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
+
+		backupValue, exist := os.LookupEnv(GOOGLE_APPLICATION_CREDENTIALS)
+		_ = os.Setenv(GOOGLE_APPLICATION_CREDENTIALS, testGoogleApplicationCredentials)
+		defer func() {
+			if exist {
+				_ = os.Setenv(GOOGLE_APPLICATION_CREDENTIALS, backupValue)
+				return
+			}
+			_ = os.Unsetenv(GOOGLE_APPLICATION_CREDENTIALS)
+		}()
+
+		var (
+			ctx         = context.Background()
+			ngClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
+		)
+
+		if _, err := GetAllTables(ctx, ngClient, testEmptyString, testDatasetNotFound); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_goFieldName(t *testing.T) {
+	t.Run("正常系_Initialisms_false", func(t *testing.T) {
+		if v := goFieldName("customer_id", Options{}); v != "Customer_id" {
+			t.Error("goFieldName: want=Customer_id current=" + v)
+		}
+	})
+
+	t.Run("正常系_Initialisms_true", func(t *testing.T) {
+		if v := goFieldName("customer_id", Options{Initialisms: true}); v != "CustomerID" {
+			t.Error("goFieldName: want=CustomerID current=" + v)
+		}
+	})
+
+	t.Run("正常系_invalid_characters", func(t *testing.T) {
+		if v := goFieldName("customer id", Options{Initialisms: true}); v != "CustomerID" {
+			t.Error("goFieldName: want=CustomerID current=" + v)
+		}
+	})
+}
+
+func Test_structTagLiteral(t *testing.T) {
+	t.Run("正常系_raw_string", func(t *testing.T) {
+		if v := structTagLiteral([]string{`bigquery:"id"`, `gorm:"column:id"`}); v != "`bigquery:\"id\" gorm:\"column:id\"`" {
+			t.Error("structTagLiteral: current=" + v)
+		}
+	})
+
+	t.Run("正常系_interpreted_string", func(t *testing.T) {
+		if v := structTagLiteral([]string{`bigquery:"back` + "`" + `quote"`}); v != `"bigquery:\"back`+"`"+`quote\""` {
+			t.Error("structTagLiteral: current=" + v)
+		}
+	})
+}
+
+func Test_isPseudoColumn(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			pseudo bool
+		}{
+			{"_PARTITIONTIME", true},
+			{"_partitiondate", true},
+			{"_TABLE_SUFFIX", true},
+			{"_FILE_NAME", true},
+			{"_id", false},
+			{"partition", false},
+		} {
+			if pseudo := isPseudoColumn(tt.name); pseudo != tt.pseudo {
+				t.Errorf("isPseudoColumn: %s: want=%t current=%t", tt.name, tt.pseudo, pseudo)
+			}
+		}
+	})
+}
+
+func Test_spannerColumnName(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		columnName  string
+		opts        Options
+		spannerName string
+	}{
+		{"正常系_snake_case", "first_name", Options{}, "FirstName"},
+		{"正常系_initialism_disabled", "customer_id", Options{}, "CustomerId"},
+		{"正常系_initialism", "customer_id", Options{Initialisms: true}, "CustomerID"},
+		{"正常系_initialisms", "http_api_key", Options{Initialisms: true}, "HTTPAPIKey"},
+		{"正常系_invalid_characters", "first name", Options{}, "FirstName"},
+		{"正常系_underscore_only", "_", Options{}, "_"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if spannerName := spannerColumnName(tt.columnName, tt.opts); spannerName != tt.spannerName {
+				t.Error("spannerColumnName: want=" + tt.spannerName + " current=" + spannerName)
+			}
+		})
+	}
+}
+
+func Test_snakeToCamelWithInitialisms(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for s, camel := range map[string]string{
+			// No initialisms
+			"name":        "Name",
+			"first_name":  "FirstName",
+			"full_201510": "Full201510",
+			"ids":         "Ids",
+			"identity":    "Identity",
+			// Initialism at the end
+			"customer_id": "CustomerID",
+			"avatar_url":  "AvatarURL",
+			// Initialism at the start
+			"id":          "ID",
+			"id_customer": "IDCustomer",
+			"url_path":    "URLPath",
+			// Initialism in the middle
+			"x_id_y":          "XIDY",
+			"user_id_hash":    "UserIDHash",
+			"last_ip_address": "LastIPAddress",
+			// Multiple initialisms
+			"http_api_key":      "HTTPAPIKey",
+			"user_url_id":       "UserURLID",
+			"json_sql_id_count": "JSONSQLIDCount",
+			// Case-insensitive
+			"Customer_Id": "CustomerID",
+			"USER_ID":     "USERID",
+			// Leading, trailing and consecutive underscores
+			"_id":           "ID",
+			"user__id_":     "UserID",
+			"_":             "_",
+			testEmptyString: testEmptyString,
+		} {
+			if v := snakeToCamelWithInitialisms(s, nil); v != camel {
+				t.Error("snakeToCamelWithInitialisms: " + s + ": want=" + camel + " current=" + v)
+			}
+		}
+	})
+
+	t.Run("正常系_exceptions", func(t *testing.T) {
+		exceptions := map[string]string{"ios": "IOS", "oauth": "OAuth", "id": "Id"}
+		for s, camel := range map[string]string{
+			"ios_version":    "IOSVersion",
+			"OAUTH_token":    "OAuthToken",
+			"customer_id":    "CustomerId",
+			"avatar_url":     "AvatarURL",
+			"iosapp_version": "IosappVersion",
+		} {
+			if v := snakeToCamelWithInitialisms(s, exceptions); v != camel {
+				t.Error("snakeToCamelWithInitialisms: " + s + ": want=" + camel + " current=" + v)
+			}
+		}
+	})
+}
+
+func Test_capitalizeInitial(t *testing.T) {
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		if capitalizeInitial(testEmptyString) != testEmptyString {
+			t.Error()
+		}
+	})
+
+	t.Run("正常系_testCapitalized", func(t *testing.T) {
+		if capitalizeInitial(testNotCapitalized) != testCapitalized {
+			t.Error()
+		}
+	})
+}
+
+func Test_warnln(t *testing.T) {
+	warnln("test")
+}
+
+func Test_fieldSchemaToGoType(t *testing.T) {
+	var (
+		nullableTimestamp = &bigquery.FieldSchema{Name: "t", Type: bigquery.TimestampFieldType}
+		requiredTimestamp = &bigquery.FieldSchema{Name: "t", Type: bigquery.TimestampFieldType, Required: true}
+		nullableNumeric   = &bigquery.FieldSchema{Name: "n", Type: bigquery.NumericFieldType}
+		nullableBytes     = &bigquery.FieldSchema{Name: "b", Type: bigquery.BytesFieldType}
+		repeatedString    = &bigquery.FieldSchema{Name: "s", Type: bigquery.StringFieldType, Repeated: true}
+		nullableAny       = &bigquery.FieldSchema{Name: "a", Type: testNotSupportedFieldType}
+		repeatedAny       = &bigquery.FieldSchema{Name: "a", Type: testNotSupportedFieldType, Repeated: true}
+		nullTime          = GoType{Name: "bigquery.NullTimestamp", PkgPath: "cloud.google.com/go/bigquery"}
+		nullableGeography = &bigquery.FieldSchema{Name: "g", Type: bigquery.GeographyFieldType}
+		repeatedGeography = &bigquery.FieldSchema{Name: "g", Type: bigquery.GeographyFieldType, Repeated: true}
+		geomPoint         = GoType{Name: "geom.Point", PkgPath: "github.com/twpayne/go-geom"}
+		unixTime          = GoType{Name: "int64"}
+	)
+
+	for _, tt := range []struct {
+		name    string
+		schema  *bigquery.FieldSchema
+		opts    Options
+		goType  string
+		pkgPath string
+	}{
+		{"正常系_default", nullableTimestamp, Options{}, "time.Time", "time"},
+		{"正常系_NullTypes", nullableTimestamp, Options{NullTypes: true}, "bigquery.NullTimestamp", "cloud.google.com/go/bigquery"},
+		{"正常系_NullTypes_required", requiredTimestamp, Options{NullTypes: true}, "time.Time", "time"},
+		{"正常系_NullTypes_repeated", repeatedString, Options{NullTypes: true}, "[]string", ""},
+		{"正常系_NullTypes_numeric", nullableNumeric, Options{NullTypes: true}, "*big.Rat", "math/big"},
+		{"正常系_NullTypesFor", nullableTimestamp, Options{NullTypesFor: map[bigquery.FieldType]bool{bigquery.TimestampFieldType: true}}, "bigquery.NullTimestamp", "cloud.google.com/go/bigquery"},
+		{"正常系_NullTypesFor_required", requiredTimestamp, Options{NullTypesFor: map[bigquery.FieldType]bool{bigquery.TimestampFieldType: true}}, "time.Time", "time"},
+		{"正常系_NullTypesFor_numeric", nullableNumeric, Options{NullTypesFor: map[bigquery.FieldType]bool{bigquery.NumericFieldType: true}}, "*big.Rat", "math/big"},
+		{"正常系_NullTypesFor_other_type", nullableTimestamp, Options{NullTypesFor: map[bigquery.FieldType]bool{bigquery.StringFieldType: true}}, "time.Time", "time"},
+		{"正常系_NullTypes_bytes", nullableBytes, Options{NullTypes: true}, typeOfByteSlice.String(), ""},
+		{"正常系_TypeOverrides", requiredTimestamp, Options{TypeOverrides: map[bigquery.FieldType]GoType{bigquery.TimestampFieldType: unixTime}}, "int64", ""},
+		{"正常系_TypeOverrides_NullTypes", nullableTimestamp, Options{NullTypes: true, TypeOverrides: map[bigquery.FieldType]GoType{bigquery.TimestampFieldType: unixTime}}, "int64", ""},
+		{"正常系_NullTypes_geography", nullableGeography, Options{NullTypes: true}, "bigquery.NullGeography", "cloud.google.com/go/bigquery"},
+		{"正常系_NullableTypeOverrides", nullableTimestamp, Options{NullTypes: true, NullableTypeOverrides: map[bigquery.FieldType]GoType{bigquery.TimestampFieldType: nullTime}}, "bigquery.NullTimestamp", "cloud.google.com/go/bigquery"},
+		{"正常系_TypeOverrides_geography", nullableGeography, Options{TypeOverrides: map[bigquery.FieldType]GoType{bigquery.GeographyFieldType: geomPoint}}, "geom.Point", "geom \"github.com/twpayne/go-geom\""},
+		{"正常系_TypeOverrides_geography_NullTypes", nullableGeography, Options{NullTypes: true, TypeOverrides: map[bigquery.FieldType]GoType{bigquery.GeographyFieldType: geomPoint}}, "geom.Point", "geom \"github.com/twpayne/go-geom\""},
+		{"正常系_TypeOverrides_geography_repeated", repeatedGeography, Options{NullTypes: true, TypeOverrides: map[bigquery.FieldType]GoType{bigquery.GeographyFieldType: geomPoint}}, "[]geom.Point", "geom \"github.com/twpayne/go-geom\""},
+		{"正常系_geography_default", nullableGeography, Options{}, "string", ""},
+		{"正常系_UnsupportedAsAny", nullableAny, Options{UnsupportedAsAny: true, NullTypes: true}, "interface{}", ""},
+		{"正常系_UnsupportedAsAny_repeated", repeatedAny, Options{UnsupportedAsAny: true}, "[]interface{}", ""},
+		{"正常系_NullableTypeOverrides_required", requiredTimestamp, Options{NullTypes: true, NullableTypeOverrides: map[bigquery.FieldType]GoType{bigquery.TimestampFieldType: nullTime}}, "time.Time", "time"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			goType, pkgPath, err := fieldSchemaToGoType(tt.schema, tt.opts)
+			if err != nil {
+				t.Error(err)
+			}
+			if goType != tt.goType || pkgPath != tt.pkgPath {
+				t.Error("fieldSchemaToGoType: want=" + tt.goType + "," + tt.pkgPath + " current=" + goType + "," + pkgPath)
+			}
+		})
+	}
+
+	t.Run("異常系_testNotSupportedFieldType", func(t *testing.T) {
+		if _, _, err := fieldSchemaToGoType(&bigquery.FieldSchema{Type: testNotSupportedFieldType}, Options{}); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+// testLoadableSchema has a NULLABLE column of each type, and testLoadableRows has the rows of them
+// as the BigQuery API returns, the values and the NULLs.
+var (
+	testLoadableSchema = bigquery.Schema{
+		{Name: "string", Type: bigquery.StringFieldType},
+		{Name: "bytes", Type: bigquery.BytesFieldType},
+		{Name: "integer", Type: bigquery.IntegerFieldType},
+		{Name: "float", Type: bigquery.FloatFieldType},
+		{Name: "boolean", Type: bigquery.BooleanFieldType},
+		{Name: "timestamp", Type: bigquery.TimestampFieldType},
+		{Name: "date", Type: bigquery.DateFieldType},
+		{Name: "time", Type: bigquery.TimeFieldType},
+		{Name: "datetime", Type: bigquery.DateTimeFieldType},
+		{Name: "numeric", Type: bigquery.NumericFieldType},
+		{Name: "geography", Type: bigquery.GeographyFieldType},
+	}
+	testLoadableRows = [][]interface{}{
+		{"s", "Yg==", "1", "1.5", "true", "1.6E9", "2020-01-01", "12:00:00", "2020-01-01T12:00:00", "1.5", "POINT(0 0)"},
+		{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+	}
+)
+
+// testLoadStruct loads rows of the columns of schema by RowIterator.Next of the bigquery client, which reads them
+// from a fake BigQuery API, into a struct of the Go types fieldSchemaToGoType returns for the columns.
+func testLoadStruct(t *testing.T, schema bigquery.Schema, rows [][]interface{}, opts Options) (err error) {
+	t.Helper()
+
+	goTypes := make(map[string]reflect.Type)
+	for _, value := range []interface{}{
+		"", []byte{}, int64(0), float64(0), false, time.Time{}, civil.Date{}, civil.Time{}, civil.DateTime{}, &big.Rat{},
+		bigquery.NullString{}, bigquery.NullGeography{}, bigquery.NullInt64{}, bigquery.NullFloat64{}, bigquery.NullBool{},
+		bigquery.NullTimestamp{}, bigquery.NullDate{}, bigquery.NullTime{}, bigquery.NullDateTime{},
+	} {
+		goTypes[reflect.TypeOf(value).String()] = reflect.TypeOf(value)
+	}
+
+	var fields []reflect.StructField
+	var jsonFields []map[string]string
+	for _, fieldSchema := range schema {
+		goType, _, err := fieldSchemaToGoType(fieldSchema, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if goTypes[goType] == nil {
+			t.Fatal("testLoadStruct: unknown type: " + goType)
+		}
+		fields = append(fields, reflect.StructField{Name: goFieldName(fieldSchema.Name, opts), Type: goTypes[goType], Tag: reflect.StructTag(`bigquery:"` + fieldSchema.Name + `"`)})
+		jsonFields = append(jsonFields, map[string]string{"name": fieldSchema.Name, "type": string(fieldSchema.Type), "mode": fieldMode(fieldSchema)})
+	}
+
+	var jsonRows []map[string]interface{}
+	for _, row := range rows {
+		var cells []map[string]interface{}
+		for _, value := range row {
+			cells = append(cells, map[string]interface{}{"v": value})
+		}
+		jsonRows = append(jsonRows, map[string]interface{}{"f": cells})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{} = map[string]interface{}{"schema": map[string]interface{}{"fields": jsonFields}}
+		if strings.HasSuffix(r.URL.Path, "/data") {
+			response = map[string]interface{}{"totalRows": strconv.Itoa(len(jsonRows)), "rows": jsonRows}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := bigquery.NewClient(ctx, "project", option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	it := client.Dataset("dataset").Table("table").Read(ctx)
+	for range rows {
+		dst := reflect.New(reflect.StructOf(fields)).Interface()
+		if err := it.Next(dst); err != nil {
+			return fmt.Errorf("RowIterator.Next: %w", err)
+		}
+	}
+	return nil
+}
+
+func Test_fieldSchemaToGoType_load(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts Options
+	}{
+		{"正常系_default", Options{}},
+		{"正常系_NullTypes", Options{NullTypes: true}},
+		{"正常系_NullTypesFor", Options{NullTypesFor: map[bigquery.FieldType]bool{
+			bigquery.StringFieldType: true, bigquery.TimestampFieldType: true, bigquery.DateFieldType: true, bigquery.TimeFieldType: true, bigquery.DateTimeFieldType: true,
+			bigquery.IntegerFieldType: true, bigquery.FloatFieldType: true, bigquery.BooleanFieldType: true, bigquery.GeographyFieldType: true, bigquery.NumericFieldType: true,
+		}}},
+		{"正常系_NullableTypeOverrides", Options{NullableTypeOverrides: map[bigquery.FieldType]GoType{
+			bigquery.TimestampFieldType: {Name: "bigquery.NullTimestamp", PkgPath: "cloud.google.com/go/bigquery"},
+		}, NullTypes: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// The default types cannot be loaded with NULL
+			rows := testLoadableRows
+			if tt.name == "正常系_default" {
+				rows = rows[:1]
+			}
+			if err := testLoadStruct(t, testLoadableSchema, rows, tt.opts); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_normalizeFieldType(t *testing.T) {
+	for _, tt := range []struct {
+		fieldType bigquery.FieldType
+		want      bigquery.FieldType
+	}{
+		{"BOOL", bigquery.BooleanFieldType},
+		{"INT64", bigquery.IntegerFieldType},
+		{"INT", bigquery.IntegerFieldType},
+		{"SMALLINT", bigquery.IntegerFieldType},
+		{"BIGINT", bigquery.IntegerFieldType},
+		{"TINYINT", bigquery.IntegerFieldType},
+		{"BYTEINT", bigquery.IntegerFieldType},
+		{"FLOAT64", bigquery.FloatFieldType},
+		{"DECIMAL", bigquery.NumericFieldType},
+		{"BIGDECIMAL", "BIGNUMERIC"},
+		{"STRUCT", bigquery.RecordFieldType},
+		{"STRING", bigquery.StringFieldType},
+		{"BYTES", bigquery.BytesFieldType},
+		{"NUMERIC", bigquery.NumericFieldType},
+		{"BIGNUMERIC", "BIGNUMERIC"},
+		{"int64", bigquery.IntegerFieldType},
+		{bigquery.TimestampFieldType, bigquery.TimestampFieldType},
+	} {
+		tt := tt
+		t.Run("正常系_"+string(tt.fieldType), func(t *testing.T) {
+			if normalized := NormalizeFieldType(tt.fieldType); normalized != tt.want {
+				t.Error("normalizeFieldType: want=" + string(tt.want) + " current=" + string(normalized))
+			}
+		})
+	}
+}
+
+func Test_normalizeSchema(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			schema = bigquery.Schema{
+				{Name: "flag", Type: "BOOL"},
+				{Name: "s", Type: "STRUCT", Schema: bigquery.Schema{
+					{Name: "n", Type: "INT64"},
+					{Name: "f", Type: bigquery.FloatFieldType},
+				}},
+			}
+		)
+		NormalizeSchema(schema)
+		if schema[0].Type != bigquery.BooleanFieldType || schema[1].Type != bigquery.RecordFieldType ||
+			schema[1].Schema[0].Type != bigquery.IntegerFieldType || schema[1].Schema[1].Type != bigquery.FloatFieldType {
+			t.Errorf("normalizeSchema: current=%v,%v,%v,%v", schema[0].Type, schema[1].Type, schema[1].Schema[0].Type, schema[1].Schema[1].Type)
+		}
+	})
+}
+
+func Test_bigqueryFieldTypeToGoType(t *testing.T) {
+	var (
+		supportedBigqueryFieldTypes = map[bigquery.FieldType]string{
+			bigquery.StringFieldType:    reflect.String.String(),
+			bigquery.BytesFieldType:     typeOfByteSlice.String(),
+			bigquery.IntegerFieldType:   reflect.Int64.String(),
+			bigquery.FloatFieldType:     reflect.Float64.String(),
+			bigquery.BooleanFieldType:   reflect.Bool.String(),
+			bigquery.TimestampFieldType: typeOfGoTime.String(),
+			// TODO(ginokent): support bigquery.RecordFieldType
+			//bigquery.RecordFieldType: "",
+			bigquery.DateFieldType:      typeOfDate.String(),
+			bigquery.TimeFieldType:      typeOfTime.String(),
+			bigquery.DateTimeFieldType:  typeOfDateTime.String(),
+			bigquery.NumericFieldType:   typeOfRat.String(),
+			bigquery.GeographyFieldType: reflect.String.String(),
+			"BOOL":                      reflect.Bool.String(),
+			"INT64":                     reflect.Int64.String(),
+			"FLOAT64":                   reflect.Float64.String(),
+		}
+
+		unsupportedBigqueryFieldTypes = map[bigquery.FieldType]string{
+			bigquery.RecordFieldType: testEmptyString,
+			"STRUCT":                 testEmptyString,
+			bigquery.FieldType(testNotSupportedFieldType): testEmptyString,
+		}
+	)
+
+	t.Run("正常系_supportedBigqueryFieldTypes", func(t *testing.T) {
+		for bigqueryFieldType, typeOf := range supportedBigqueryFieldTypes {
+			goType, _, err := BigQueryFieldTypeToGoType(bigqueryFieldType)
+			if err != nil {
+				t.Error(err)
+			}
+			if goType != typeOf {
+				t.Error()
+			}
+		}
+	})
+
+	t.Run("異常系_unsupportedBigqueryFieldTypes", func(t *testing.T) {
+		for bigqueryFieldType, typeOf := range unsupportedBigqueryFieldTypes {
+			goType, _, err := BigQueryFieldTypeToGoType(bigqueryFieldType)
+			if !errors.Is(err, ErrFieldTypeNotSupported) {
+				t.Error(err)
+			}
+			if err != nil && !strings.Contains(err.Error(), testSubStrFieldTypeNotSupported) {
+				t.Error(err)
+			}
+			if goType != typeOf {
+				t.Error()
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+)
+
+// PreservedStruct is the code of a table in the existing Go output, which is kept as it is instead of being generated.
+type PreservedStruct struct {
+	// Code is the declarations of the table, from the schema struct to the last one before the next table.
+	Code string
+	// ImportPackages is the packages Code refers to, in the format of generateImportPackagesCode.
+	ImportPackages []string
+}
+
+// PreservedStructs returns the code of each table of the dataset in the generated Go code src, keyed by table ID.
+// The declarations generated once for all the tables, e.g. TableTypes, are not in any code.
+func PreservedStructs(src []byte, projectID, datasetID string) (preserved map[string]PreservedStruct, err error) {
+	fset := token.NewFileSet()
+	var f *ast.File
+	f, err = parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	preserved = make(map[string]PreservedStruct)
+	var tableID string
+	var start, end int
+	var decls []ast.Decl
+	flush := func() {
+		if tableID != "" {
+			preserved[tableID] = PreservedStruct{Code: string(src[start:end]), ImportPackages: usedImportPackages(f, decls)}
+		}
+		tableID, decls = "", nil
+	}
+
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+
+		if doc := declDoc(decl); doc != nil {
+			if match := StructTableRegexp.FindSubmatch(src[offset(doc.Pos()):offset(doc.End())]); match != nil {
+				flush()
+				// The structs of the other datasets are not kept, but generated if they are in the dataset now
+				if string(match[1]) == projectID && string(match[2]) == datasetID {
+					tableID, start = string(match[3]), offset(doc.Pos())
+				}
+			}
+		}
+		if isPackageLevelDecl(decl) {
+			flush()
+		}
+		if tableID != "" {
+			decls = append(decls, decl)
+			end = offset(decl.End())
+		}
+	}
+	flush()
+
+	return preserved, nil
+}
+
+func declDoc(decl ast.Decl) (doc *ast.CommentGroup) {
+	switch d := decl.(type) {
+	case *ast.GenDecl:
+		return d.Doc
+	case *ast.FuncDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// isPackageLevelDecl returns true if decl is one of the declarations GenerateGoFiles generates once for all the tables.
+func isPackageLevelDecl(decl ast.Decl) bool {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		// The init() of RegisterFunc
+		return d.Recv == nil && d.Name.Name == "init"
+	case *ast.GenDecl:
+		// The const block of EmitAllColumns
+		if d.Doc != nil && strings.TrimSpace(d.Doc.Text()) == "Column names of all BigQuery Tables." {
+			return true
+		}
+		for _, spec := range d.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if spec.Name.Name == "Row" {
+					return true
+				}
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					switch name.Name {
+					case "TableTypes", "RowDecoders", "SchemaVersion", "GeneratedFrom":
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// usedImportPackages returns the imports of f that decls refer to, in the format of generateImportPackagesCode.
+func usedImportPackages(f *ast.File, decls []ast.Decl) (importPackages []string) {
+	used := make(map[string]bool)
+	for _, decl := range decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if sel, ok := node.(*ast.SelectorExpr); ok {
+				// A package name is not resolved to any object in the file
+				if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+					used[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	for _, spec := range f.Imports {
+		importPath := strings.Trim(spec.Path.Value, "\"`")
+		if spec.Name != nil {
+			if used[spec.Name.Name] {
+				importPackages = append(importPackages, spec.Name.Name+" "+spec.Path.Value)
+			}
+			continue
+		}
+		// The generated code imports the packages whose names differ from the last elements
+		// of the paths with the names. See GoType.importSpec.
+		if used[path.Base(importPath)] {
+			importPackages = append(importPackages, importPath)
+		}
+	}
+	return importPackages
+}
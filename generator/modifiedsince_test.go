@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func newModifiedSinceTestSchemas(lastModifiedTime time.Time) (schemas []TableSchema) {
+	return []TableSchema{
+		{
+			Table: &bigquery.Table{ProjectID: "project", DatasetID: "dataset", TableID: "users"},
+			Metadata: &bigquery.TableMetadata{
+				FullID:           "project:dataset.users",
+				LastModifiedTime: lastModifiedTime,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "created_at", Type: bigquery.TimestampFieldType},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "city", Type: bigquery.StringFieldType},
+					}},
+				},
+			},
+		},
+		{
+			Table: &bigquery.Table{ProjectID: "project", DatasetID: "dataset", TableID: "events"},
+			Metadata: &bigquery.TableMetadata{
+				FullID:           "project:dataset.events",
+				LastModifiedTime: lastModifiedTime,
+				Schema: bigquery.Schema{
+					{Name: "name", Type: bigquery.StringFieldType},
+				},
+			},
+		},
+	}
+}
+
+func Test_preservedStructs(t *testing.T) {
+	opts := Options{EmitRowInterface: true, EmitTypeRegistry: true, EmitVersion: true, Immutable: true}
+
+	t.Run("正常系", func(t *testing.T) {
+		generatedCode, err := generateGoCode(newModifiedSinceTestSchemas(time.Time{}), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		preserved, err := PreservedStructs(generatedCode, "project", "dataset")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(preserved) != 2 {
+			t.Fatalf("preservedStructs: want=2 current=%d: %v", len(preserved), preserved)
+		}
+
+		users := preserved["users"]
+		if !strings.HasPrefix(users.Code, "// Users is BigQuery Table `project:dataset.users` schema struct.\n") {
+			t.Error("preservedStructs: the code does not start with the struct: " + users.Code)
+		}
+		for _, want := range []string{"type UsersAddress struct {", "func NewUsersImmutable("} {
+			if !strings.Contains(users.Code, want) {
+				t.Error("preservedStructs: " + want + " not found: " + users.Code)
+			}
+		}
+		if strings.Contains(users.Code, "Events") || strings.Contains(users.Code, "TableTypes") || strings.Contains(users.Code, "SchemaVersion") || strings.Contains(users.Code, "RowDecoders") {
+			t.Error("preservedStructs: the code has the other declarations: " + users.Code)
+		}
+		if expect := []string{"time"}; !reflect.DeepEqual(users.ImportPackages, expect) {
+			t.Errorf("preservedStructs: want=%v current=%v", expect, users.ImportPackages)
+		}
+		if events := preserved["events"]; len(events.ImportPackages) != 0 || strings.Contains(events.Code, "TableTypes") {
+			t.Errorf("preservedStructs: the code has the other declarations: %v", events)
+		}
+	})
+
+	t.Run("正常系_other_dataset", func(t *testing.T) {
+		generatedCode, err := generateGoCode(newModifiedSinceTestSchemas(time.Time{}), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		preserved, err := PreservedStructs(generatedCode, "project", "other_dataset")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(preserved) != 0 {
+			t.Errorf("preservedStructs: want=0 current=%d", len(preserved))
+		}
+	})
+
+	t.Run("正常系_generateGoCode", func(t *testing.T) {
+		// The preserved code is generated as it is, so the output is the same
+		schemas := newModifiedSinceTestSchemas(time.Time{})
+		generatedCode, err := generateGoCode(schemas, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		preservedOpts := opts
+		preservedOpts.PreservedStructs, err = PreservedStructs(generatedCode, "project", "dataset")
+		if err != nil {
+			t.Fatal(err)
+		}
+		preservedCode, err := generateGoCode(schemas, preservedOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(preservedCode) != string(generatedCode) {
+			t.Error("generateGoCode: want=`" + string(generatedCode) + "` current=`" + string(preservedCode) + "`")
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// TableOverride is the settings of a table that override the ones derived from the table.
+type TableOverride struct {
+	// StructName is the name of the Go struct generated for the table instead of the one derived from the table ID.
+	StructName string `json:"struct_name,omitempty"`
+	// IncludeColumns is the top-level columns to generate fields for. The other columns are omitted.
+	IncludeColumns []string `json:"include_columns,omitempty"`
+	// ExcludeColumns is the top-level columns not to generate fields for.
+	ExcludeColumns []string `json:"exclude_columns,omitempty"`
+	// SetColumns is the top-level REPEATED STRING columns to generate the conversions between the field and a set for.
+	SetColumns []string `json:"set_columns,omitempty"`
+	// ColumnTags is the struct tags of the fields of the top-level columns keyed by column name, in the format of
+	// struct tags. e.g. `json:"-" validate:"email"` A tag replaces the one of the same key generated for the field,
+	// and the others are appended.
+	ColumnTags map[string]string `json:"column_tags,omitempty"`
+}
+
+// applyTableOverrides returns the schemas with the columns of the tables filtered by the overrides.
+// The schemas are not modified.
+func applyTableOverrides(schemas []TableSchema, overrides map[string]TableOverride) (overridden []TableSchema) {
+	if len(overrides) == 0 {
+		return schemas
+	}
+
+	tableIDs := make(map[string]bool)
+	for _, schema := range schemas {
+		tableIDs[schema.Table.TableID] = true
+
+		override, exist := overrides[schema.Table.TableID]
+		for column := range override.ColumnTags {
+			if !hasColumn(schema.Metadata.Schema, column) {
+				warnln(fmt.Sprintf("table overrides: column %s of table %s is not found", column, schema.Table.TableID))
+			}
+		}
+		if !exist || (len(override.IncludeColumns) == 0 && len(override.ExcludeColumns) == 0) {
+			overridden = append(overridden, schema)
+			continue
+		}
+
+		md := *schema.Metadata
+		md.Schema = filterColumns(schema.Table.TableID, schema.Metadata.Schema, override)
+		overridden = append(overridden, TableSchema{Table: schema.Table, Metadata: &md})
+	}
+
+	for tableID := range overrides {
+		if !tableIDs[tableID] {
+			warnln(fmt.Sprintf("table overrides: table %s is not found", tableID))
+		}
+	}
+
+	return overridden
+}
+
+// tableColumnCounts returns the number of the top-level columns of each table in schemas, keyed by table ID.
+func tableColumnCounts(schemas []TableSchema) (counts map[string]int) {
+	counts = make(map[string]int)
+	for _, schema := range schemas {
+		counts[schema.Table.TableID] = len(schema.Metadata.Schema)
+	}
+	return counts
+}
+
+// ignoredColumns returns the top-level columns of each table in schemas that are not in overridden, keyed by table ID.
+// The tables with no such columns are omitted.
+func ignoredColumns(schemas, overridden []TableSchema) (ignored map[string]bigquery.Schema) {
+	kept := make(map[*bigquery.FieldSchema]bool)
+	for _, schema := range overridden {
+		for _, field := range schema.Metadata.Schema {
+			kept[field] = true
+		}
+	}
+	ignored = make(map[string]bigquery.Schema)
+	for _, schema := range schemas {
+		for _, field := range schema.Metadata.Schema {
+			if !kept[field] {
+				ignored[schema.Table.TableID] = append(ignored[schema.Table.TableID], field)
+			}
+		}
+	}
+	return ignored
+}
+
+// PrepareSchemas applies opts.TypeAliases and opts.TableOverrides to the schemas, and sets opts.TableColumns to the
+// numbers of the columns before the overrides filter them, and opts.IgnoredColumns to the filtered ones if
+// opts.ExcludeAsIgnored is set. Every entry point prepares the schemas with it.
+func PrepareSchemas(schemas []TableSchema, opts *Options) (prepared []TableSchema) {
+	// The schema files exported by the other tools are the main source of the non-standard types
+	for _, schema := range schemas {
+		applyTypeAliases(schema.Metadata.Schema, opts.TypeAliases)
+	}
+	opts.TableColumns = tableColumnCounts(schemas)
+	prepared = applyTableOverrides(schemas, opts.TableOverrides)
+	if opts.ExcludeAsIgnored {
+		opts.IgnoredColumns = ignoredColumns(schemas, prepared)
+	}
+	return prepared
+}
+
+// filterColumns returns the top-level columns in the schema that the override includes.
+func filterColumns(tableID string, schema bigquery.Schema, override TableOverride) (filtered bigquery.Schema) {
+	// BigQuery column names are case-insensitive.
+	columns := make(map[string]bool)
+	for _, field := range schema {
+		columns[strings.ToLower(field.Name)] = true
+	}
+	listed := make(map[string]bool)
+	for _, column := range append(override.IncludeColumns, override.ExcludeColumns...) {
+		if !columns[strings.ToLower(column)] {
+			warnln(fmt.Sprintf("table overrides: column %s of table %s is not found", column, tableID))
+		}
+		listed[strings.ToLower(column)] = true
+	}
+
+	include := len(override.IncludeColumns) > 0
+	for _, field := range schema {
+		if listed[strings.ToLower(field.Name)] == include {
+			filtered = append(filtered, field)
+		}
+	}
+
+	return filtered
+}
+
+// hasColumn returns true if the schema has the top-level column.
+func hasColumn(schema bigquery.Schema, column string) bool {
+	for _, field := range schema {
+		// BigQuery column names are case-insensitive.
+		if strings.EqualFold(field.Name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideColumnTags returns the tags of the field of the column with the tags of the column in override.ColumnTags,
+// which replace the ones of the same keys.
+func overrideColumnTags(tags []string, column string, override TableOverride) (overridden []string) {
+	var columnTags []string
+	for name, tag := range override.ColumnTags {
+		// BigQuery column names are case-insensitive.
+		if strings.EqualFold(name, column) {
+			// The tags are validated when the overrides are read.
+			columnTags, _ = SplitStructTag(tag)
+			break
+		}
+	}
+	if len(columnTags) == 0 {
+		return tags
+	}
+
+	replaced := make(map[string]bool)
+	for _, tag := range tags {
+		key := tag[:strings.Index(tag, ":")]
+		for _, columnTag := range columnTags {
+			if strings.HasPrefix(columnTag, key+":") {
+				tag, replaced[columnTag] = columnTag, true
+			}
+		}
+		overridden = append(overridden, tag)
+	}
+	for _, columnTag := range columnTags {
+		if !replaced[columnTag] {
+			overridden = append(overridden, columnTag)
+		}
+	}
+	return overridden
+}
+
+// SplitStructTag splits the struct tag into the key:"value" pairs, in the conventional format reflect.StructTag parses.
+func SplitStructTag(tag string) (tags []string, err error) {
+	for tag = strings.TrimLeft(tag, " "); tag != ""; tag = strings.TrimLeft(tag, " ") {
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, fmt.Errorf("struct tag must be key:\"value\" pairs: %s", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("struct tag value is not terminated: %s", tag)
+		}
+		value := tag[:i+1]
+		tag = tag[i+1:]
+		if _, err = strconv.Unquote(value); err != nil {
+			return nil, fmt.Errorf("strconv.Unquote: %s: %w", value, err)
+		}
+		tags = append(tags, key+":"+value)
+	}
+	return tags, nil
+}
+
+// applyTypeAliases replaces the types of the columns that are the keys of aliases, including in RECORD columns, in place.
+// The types are compared case-insensitively.
+func applyTypeAliases(schema bigquery.Schema, aliases map[bigquery.FieldType]bigquery.FieldType) {
+	if len(aliases) == 0 {
+		return
+	}
+	for _, field := range schema {
+		if fieldType, ok := aliases[bigquery.FieldType(strings.ToUpper(string(field.Type)))]; ok {
+			field.Type = fieldType
+		}
+		applyTypeAliases(field.Schema, aliases)
+	}
+}
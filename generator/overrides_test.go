@@ -0,0 +1,193 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func Test_tableColumnCounts(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		schemas := newModifiedSinceTestSchemas(time.Time{})
+		if counts, expect := tableColumnCounts(schemas), map[string]int{"users": 3, "events": 1}; !reflect.DeepEqual(counts, expect) {
+			t.Errorf("tableColumnCounts: want=%v current=%v", expect, counts)
+		}
+	})
+}
+
+func Test_prepareSchemas(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		schemas := newModifiedSinceTestSchemas(time.Time{})
+		schemas[1].Metadata.Schema = append(schemas[1].Metadata.Schema, &bigquery.FieldSchema{Name: "created_at", Type: "DATETIME2"})
+		opts := Options{
+			TypeAliases:    map[bigquery.FieldType]bigquery.FieldType{"DATETIME2": bigquery.DateTimeFieldType},
+			TableOverrides: map[string]TableOverride{"events": {ExcludeColumns: []string{"name"}}},
+		}
+
+		prepared := PrepareSchemas(schemas, &opts)
+
+		if fields := prepared[1].Metadata.Schema; len(fields) != 1 || fields[0].Type != bigquery.DateTimeFieldType {
+			t.Errorf("prepareSchemas: want=[created_at DATETIME] current=%v", fields)
+		}
+		if expect := map[string]int{"users": 3, "events": 2}; !reflect.DeepEqual(opts.TableColumns, expect) {
+			t.Errorf("prepareSchemas: want=%v current=%v", expect, opts.TableColumns)
+		}
+		if opts.IgnoredColumns != nil {
+			t.Errorf("prepareSchemas: want=nil current=%v", opts.IgnoredColumns)
+		}
+	})
+
+	t.Run("正常系_ExcludeAsIgnored", func(t *testing.T) {
+		schemas := newModifiedSinceTestSchemas(time.Time{})
+		opts := Options{
+			ExcludeAsIgnored: true,
+			TableOverrides:   map[string]TableOverride{"events": {ExcludeColumns: []string{"name"}}, "users": {IncludeColumns: []string{"id"}}},
+		}
+
+		PrepareSchemas(schemas, &opts)
+
+		ignored := make(map[string][]string)
+		for tableID, fields := range opts.IgnoredColumns {
+			for _, field := range fields {
+				ignored[tableID] = append(ignored[tableID], field.Name)
+			}
+		}
+		if expect := map[string][]string{"users": {"created_at", "address"}, "events": {"name"}}; !reflect.DeepEqual(ignored, expect) {
+			t.Errorf("prepareSchemas: want=%v current=%v", expect, ignored)
+		}
+	})
+}
+
+func Test_applyTableOverrides(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		newSchema := func(tableID string) TableSchema {
+			return TableSchema{
+				Table: &bigquery.Table{TableID: tableID},
+				Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "password", Type: bigquery.StringFieldType},
+				}},
+			}
+		}
+		schemas := []TableSchema{newSchema("users"), newSchema("events"), newSchema("logs")}
+		overrides := map[string]TableOverride{
+			"users":  {StructName: "User", ExcludeColumns: []string{"PASSWORD"}},
+			"events": {IncludeColumns: []string{"id"}},
+		}
+
+		overridden := applyTableOverrides(schemas, overrides)
+
+		for i, expect := range [][]string{{"id", "name"}, {"id"}, {"id", "name", "password"}} {
+			var columns []string
+			for _, field := range overridden[i].Metadata.Schema {
+				columns = append(columns, field.Name)
+			}
+			if !reflect.DeepEqual(columns, expect) {
+				t.Errorf("applyTableOverrides: %s: want=%v current=%v", overridden[i].Table.TableID, expect, columns)
+			}
+		}
+		if len(schemas[0].Metadata.Schema) != 3 {
+			t.Error("applyTableOverrides: the schemas are modified")
+		}
+		if structName := goStructName("users", Options{TableOverrides: overrides}); structName != "User" {
+			t.Errorf("goStructName: want=User current=%s", structName)
+		}
+	})
+}
+
+func Test_overrideColumnTags(t *testing.T) {
+	override := TableOverride{ColumnTags: map[string]string{"Email": `json:"-" validate:"email"`}}
+
+	t.Run("正常系", func(t *testing.T) {
+		tags := overrideColumnTags([]string{`bigquery:"email"`, `json:"email"`}, "email", override)
+		if expect := []string{`bigquery:"email"`, `json:"-"`, `validate:"email"`}; !reflect.DeepEqual(tags, expect) {
+			t.Errorf("overrideColumnTags: want=%v current=%v", expect, tags)
+		}
+	})
+
+	t.Run("正常系_other_column", func(t *testing.T) {
+		tags := overrideColumnTags([]string{`bigquery:"name"`}, "name", override)
+		if expect := []string{`bigquery:"name"`}; !reflect.DeepEqual(tags, expect) {
+			t.Errorf("overrideColumnTags: want=%v current=%v", expect, tags)
+		}
+	})
+
+	t.Run("正常系_generateGoCode", func(t *testing.T) {
+		generatedCode, err := generateGoCode(newModifiedSinceTestSchemas(time.Time{}), Options{
+			TableOverrides: map[string]TableOverride{"users": {ColumnTags: map[string]string{"id": `db:"user_id"`}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "`bigquery:\"id\" db:\"user_id\"`"; !strings.Contains(string(generatedCode), want) {
+			t.Error("generateGoCode: " + want + " not found: " + string(generatedCode))
+		}
+	})
+}
+
+func Test_splitStructTag(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		tags, err := SplitStructTag(` json:"name,omitempty"  validate:"re=\"a b\""`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := []string{`json:"name,omitempty"`, `validate:"re=\"a b\""`}; !reflect.DeepEqual(tags, expect) {
+			t.Errorf("splitStructTag: want=%v current=%v", expect, tags)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, tag := range []string{`json`, `json:name`, `:"name"`, `json:"name`} {
+			if _, err := SplitStructTag(tag); err == nil {
+				t.Errorf("splitStructTag: %s: err == nil", tag)
+			}
+		}
+	})
+}
+
+func Test_applyTypeAliases(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			aliases = map[bigquery.FieldType]bigquery.FieldType{
+				"DATETIME2":                   bigquery.DateTimeFieldType,
+				"TIMESTAMP WITHOUT TIME ZONE": bigquery.DateTimeFieldType,
+				"VARCHAR":                     bigquery.StringFieldType,
+			}
+			schema = bigquery.Schema{
+				{Name: "created_at", Type: "datetime2"},
+				{Name: "updated_at", Type: "Timestamp Without Time Zone"},
+				{Name: "deleted_at", Type: bigquery.TimestampFieldType},
+				{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "city", Type: "VARCHAR"},
+				}},
+			}
+		)
+		applyTypeAliases(schema, aliases)
+		for field, expect := range map[*bigquery.FieldSchema]bigquery.FieldType{
+			schema[0]:           bigquery.DateTimeFieldType,
+			schema[1]:           bigquery.DateTimeFieldType,
+			schema[2]:           bigquery.TimestampFieldType,
+			schema[3]:           bigquery.RecordFieldType,
+			schema[3].Schema[0]: bigquery.StringFieldType,
+		} {
+			if field.Type != expect {
+				t.Errorf("applyTypeAliases: %s: want=%s current=%s", field.Name, expect, field.Type)
+			}
+		}
+	})
+
+	t.Run("正常系_GenerateStruct", func(t *testing.T) {
+		schema := bigquery.Schema{{Name: "created_at", Type: "DATETIME2", Required: true}}
+		generatedCode, err := GenerateStruct("Users", schema, Options{TypeAliases: map[bigquery.FieldType]bigquery.FieldType{"DATETIME2": bigquery.DateTimeFieldType}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(generatedCode), "civil.DateTime") {
+			t.Error("GenerateStruct: civil.DateTime not found: " + string(generatedCode))
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// generateProtoCode is the same as generateGoCode, but generates Protocol Buffers messages instead of Go structs.
+func generateProtoCode(schemas []TableSchema, opts Options) (generatedCode []byte, err error) {
+
+	const head = `// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.
+
+syntax = "proto3";
+
+package bqschema;
+
+`
+
+	var tail string
+	var importFiles []string
+	for _, schema := range schemas {
+		table := schema.Table
+
+		var messageCode string
+		var files []string
+		messageCode, files, err = generateProtoMessageCode(table, schema.Metadata)
+		if err != nil {
+			if opts.FailOnUnsupported && errors.Is(err, ErrFieldTypeNotSupported) {
+				return nil, fmt.Errorf("generateProtoMessageCode: table=%s.%s.%s: %w", table.ProjectID, table.DatasetID, table.TableID, err)
+			}
+			warnln("generateProtoMessageCode: " + err.Error())
+			continue
+		}
+
+		importFiles = append(importFiles, files...)
+		tail = tail + "\n" + messageCode
+	}
+
+	code := head + generateProtoImportsCode(importFiles) + tail
+
+	if opts.Debug {
+		fmt.Println(">>>> DEBUG >>>>>>>>>>>>>>>>")
+		fmt.Println(code)
+		fmt.Println("<<<< DEBUG <<<<<<<<<<<<<<<<")
+	}
+
+	return []byte(code), nil
+}
+
+func generateProtoImportsCode(importFiles []string) (generatedCode string) {
+	importFilesUniq := make(map[string]bool)
+	for _, file := range importFiles {
+		importFilesUniq[file] = true
+	}
+
+	// Fix the order
+	importFilesUniqSort := make([]string, 0, len(importFilesUniq))
+	for file := range importFilesUniq {
+		importFilesUniqSort = append(importFilesUniqSort, file)
+	}
+	sort.Strings(importFilesUniqSort)
+
+	for _, file := range importFilesUniqSort {
+		generatedCode = generatedCode + "import \"" + file + "\";\n"
+	}
+
+	return generatedCode
+}
+
+func generateProtoMessageCode(table *bigquery.Table, md *bigquery.TableMetadata) (generatedCode string, importFiles []string, err error) {
+	messageName := tableIDToStructName(table.TableID)
+
+	var body string
+	body, importFiles, err = generateProtoMessageBodyCode(md.Schema, "\t")
+	if err != nil {
+		return "", nil, fmt.Errorf("generateProtoMessageBodyCode: %w", err)
+	}
+
+	generatedCode = "// " + messageName + " is BigQuery Table `" + md.FullID + "` schema message.\n" +
+		descriptionComment(md.Description) +
+		"message " + messageName + " {\n" +
+		body +
+		"}\n"
+
+	return generatedCode, importFiles, nil
+}
+
+// generateProtoMessageBodyCode generates the fields of a message, and nested messages for RECORD columns.
+// Field numbers are assigned in schema order.
+func generateProtoMessageBodyCode(schema bigquery.Schema, indent string) (generatedCode string, importFiles []string, err error) {
+	var nestedCode, fieldsCode string
+
+	for i, field := range schema {
+		var protoType, file string
+		if field.Type == bigquery.RecordFieldType {
+			protoType = capitalizeInitial(field.Name)
+
+			var body string
+			var files []string
+			body, files, err = generateProtoMessageBodyCode(field.Schema, indent+"\t")
+			if err != nil {
+				return "", nil, fmt.Errorf("generateProtoMessageBodyCode: column=%s: %w", field.Name, err)
+			}
+			importFiles = append(importFiles, files...)
+			nestedCode = nestedCode + indent + "message " + protoType + " {\n" + body + indent + "}\n"
+		} else {
+			protoType, file, err = bigqueryFieldTypeToProtoType(field.Type)
+			if err != nil {
+				return "", nil, fmt.Errorf("bigqueryFieldTypeToProtoType: column=%s: %w", field.Name, err)
+			}
+			if file != "" {
+				importFiles = append(importFiles, file)
+			}
+		}
+
+		var label string
+		if field.Repeated {
+			label = "repeated "
+		}
+		fieldsCode = fieldsCode + indent + label + protoType + " " + field.Name + " = " + strconv.Itoa(i+1) + ";\n"
+	}
+
+	return nestedCode + fieldsCode, importFiles, nil
+}
+
+func bigqueryFieldTypeToProtoType(bigqueryFieldType bigquery.FieldType) (protoType string, importFile string, err error) {
+	switch NormalizeFieldType(bigqueryFieldType) {
+	case bigquery.StringFieldType, bigquery.GeographyFieldType:
+		return "string", "", nil
+	case bigquery.BytesFieldType:
+		return "bytes", "", nil
+	case bigquery.IntegerFieldType:
+		return "int64", "", nil
+	case bigquery.FloatFieldType:
+		return "double", "", nil
+	case bigquery.BooleanFieldType:
+		return "bool", "", nil
+	case bigquery.TimestampFieldType:
+		return "google.protobuf.Timestamp", "google/protobuf/timestamp.proto", nil
+	case bigquery.DateFieldType:
+		return "google.type.Date", "google/type/date.proto", nil
+	case bigquery.TimeFieldType:
+		return "google.type.TimeOfDay", "google/type/timeofday.proto", nil
+	case bigquery.DateTimeFieldType:
+		return "google.type.DateTime", "google/type/datetime.proto", nil
+	case bigquery.NumericFieldType:
+		// NUMERIC is represented as a decimal string to avoid losing precision.
+		return "string", "", nil
+	default:
+		return "", "", fmt.Errorf("%w. bigquery.FieldType=%s", ErrFieldTypeNotSupported, bigqueryFieldType)
+	}
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func Test_generateProtoImportsCode(t *testing.T) {
+	t.Run("正常系_import_nothing", func(t *testing.T) {
+		if generatedCode := generateProtoImportsCode(nil); generatedCode != testEmptyString {
+			t.Error("generateProtoImportsCode: want=`` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_import_sorted_uniq", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = "import \"google/protobuf/timestamp.proto\";\n" +
+				"import \"google/type/date.proto\";\n"
+		)
+		generatedCode := generateProtoImportsCode([]string{"google/type/date.proto", "google/protobuf/timestamp.proto", "google/type/date.proto"})
+		if generatedCode != testImportCode {
+			t.Error("generateProtoImportsCode: want=`" + testImportCode + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_generateProtoMessageCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testMessageCode = "// Users is BigQuery Table `p:d.users` schema message.\n" +
+				"message Users {\n" +
+				"\tmessage Address {\n" +
+				"\t\tstring city = 1;\n" +
+				"\t\tgoogle.type.Date since = 2;\n" +
+				"\t}\n" +
+				"\tint64 id = 1;\n" +
+				"\trepeated string tags = 2;\n" +
+				"\trepeated Address address = 3;\n" +
+				"\tgoogle.protobuf.Timestamp created_at = 4;\n" +
+				"}\n"
+		)
+		var (
+			table = &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"}
+			md    = &bigquery.TableMetadata{
+				FullID: "p:d.users",
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+					{Name: "address", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+						{Name: "city", Type: bigquery.StringFieldType},
+						{Name: "since", Type: bigquery.DateFieldType},
+					}},
+					{Name: "created_at", Type: bigquery.TimestampFieldType},
+				},
+			}
+		)
+		generatedCode, importFiles, err := generateProtoMessageCode(table, md)
+		if err != nil {
+			t.Error(err)
+		}
+		if generatedCode != testMessageCode {
+			t.Error("generateProtoMessageCode: want=`" + testMessageCode + "` current=`" + generatedCode + "`")
+		}
+		if len(importFiles) != 2 {
+			t.Errorf("generateProtoMessageCode: importFiles=%v", importFiles)
+		}
+	})
+
+	t.Run("異常系_testNotSupportedFieldType", func(t *testing.T) {
+		var (
+			table = &bigquery.Table{TableID: "ng"}
+			md    = &bigquery.TableMetadata{
+				Schema: bigquery.Schema{
+					{Name: "rec", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "ng", Type: testNotSupportedFieldType},
+					}},
+				},
+			}
+		)
+		if _, _, err := generateProtoMessageCode(table, md); !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_bigqueryFieldTypeToProtoType(t *testing.T) {
+	t.Run("正常系_supportedBigqueryFieldTypes", func(t *testing.T) {
+		for _, bigqueryFieldType := range []bigquery.FieldType{
+			bigquery.StringFieldType,
+			bigquery.BytesFieldType,
+			bigquery.IntegerFieldType,
+			bigquery.FloatFieldType,
+			bigquery.BooleanFieldType,
+			bigquery.TimestampFieldType,
+			bigquery.DateFieldType,
+			bigquery.TimeFieldType,
+			bigquery.DateTimeFieldType,
+			bigquery.NumericFieldType,
+			bigquery.GeographyFieldType,
+		} {
+			if protoType, _, err := bigqueryFieldTypeToProtoType(bigqueryFieldType); err != nil || protoType == "" {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("異常系_unsupportedBigqueryFieldTypes", func(t *testing.T) {
+		if _, _, err := bigqueryFieldTypeToProtoType(testNotSupportedFieldType); !errors.Is(err, ErrFieldTypeNotSupported) {
+			t.Error(err)
+		}
+	})
+}
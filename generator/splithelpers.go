@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// SplitHelpersCode splits the generated Go code into the code of the type declarations, e.g. the schema structs, and
+// the code of the other declarations, e.g. the methods and the package-level vars, with the import blocks of each.
+// The header comments, e.g. the go:generate directive, and the blank imports stay in the code of the types.
+func SplitHelpersCode(generatedCode []byte, opts Options) (typesCode, helpersCode []byte, err error) {
+	fset := token.NewFileSet()
+	var f *ast.File
+	f, err = parser.ParseFile(fset, "", generatedCode, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var typeDecls, helperDecls []ast.Decl
+	var typeDeclsCode, helperDeclsCode string
+	for _, decl := range f.Decls {
+		start := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+		declCode := string(generatedCode[offset(start):offset(decl.End())]) + "\n\n"
+
+		gen, ok := decl.(*ast.GenDecl)
+		switch {
+		case ok && gen.Tok == token.IMPORT:
+			continue
+		case ok && gen.Tok == token.TYPE:
+			typeDecls = append(typeDecls, decl)
+			typeDeclsCode = typeDeclsCode + declCode
+		default:
+			helperDecls = append(helperDecls, decl)
+			helperDeclsCode = helperDeclsCode + declCode
+		}
+	}
+
+	// The blank imports are for the side effects, which the package needs only once
+	var blankImports []string
+	for _, spec := range f.Imports {
+		if spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".") {
+			blankImports = append(blankImports, spec.Name.Name+" "+spec.Path.Value)
+		}
+	}
+
+	packageClause := "package " + f.Name.Name + "\n\n"
+
+	typesCode, err = formatGoCode(string(generatedCode[:offset(f.Package)])+packageClause+
+		generateImportPackagesCode(append(usedImportPackages(f, typeDecls), blankImports...))+typeDeclsCode, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatGoCode: %w", err)
+	}
+
+	helpersCode, err = formatGoCode(GeneratedFileHeader+"\n\n"+packageClause+
+		generateImportPackagesCode(usedImportPackages(f, helperDecls))+helperDeclsCode, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatGoCode: %w", err)
+	}
+
+	return typesCode, helpersCode, nil
+}
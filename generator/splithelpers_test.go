@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_splitHelpersCode(t *testing.T) {
+	opts := Options{
+		EmitInUTC:        true,
+		EmitTypeRegistry: true,
+		EmitVersion:      true,
+		ExtraImports:     []string{`_ "github.com/lib/pq"`},
+		Immutable:        true,
+		NoGoimports:      true,
+	}
+
+	t.Run("正常系", func(t *testing.T) {
+		generatedCode, err := generateGoCode(newModifiedSinceTestSchemas(time.Time{}), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		typesCode, helpersCode, err := SplitHelpersCode(generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{
+			"//go:generate go run github.com/ginokent/bqschema-gen-go\n",
+			"import (\n\t_ \"github.com/lib/pq\"\n\t\"time\"\n)\n",
+			"// Users is BigQuery Table `project:dataset.users` schema struct.\ntype Users struct {\n",
+			"type UsersAddress struct {\n",
+			"type UsersImmutable struct {\n",
+		} {
+			if !strings.Contains(string(typesCode), want) {
+				t.Error("splitHelpersCode: want=`" + want + "` current=`" + string(typesCode) + "`")
+			}
+		}
+		for _, unwanted := range []string{"func ", "TableTypes", "SchemaVersion", "reflect"} {
+			if strings.Contains(string(typesCode), unwanted) {
+				t.Error("splitHelpersCode: " + unwanted + " in the types: " + string(typesCode))
+			}
+		}
+
+		for _, want := range []string{
+			GeneratedFileHeader + "\n\npackage bqschema\n\nimport (\n\t\"reflect\"\n\t\"time\"\n)\n",
+			"func NewUsersImmutable(",
+			"func (u *Users) InUTC() {\n",
+			"var TableTypes = map[string]reflect.Type{\n",
+			"const SchemaVersion = ",
+		} {
+			if !strings.Contains(string(helpersCode), want) {
+				t.Error("splitHelpersCode: want=`" + want + "` current=`" + string(helpersCode) + "`")
+			}
+		}
+		for _, unwanted := range []string{"type ", "go:generate", "github.com/lib/pq"} {
+			if strings.Contains(string(helpersCode), unwanted) {
+				t.Error("splitHelpersCode: " + unwanted + " in the helpers: " + string(helpersCode))
+			}
+		}
+
+		// All the declarations are in either of the files
+		var declCounts []int
+		for _, code := range [][]byte{generatedCode, typesCode, helpersCode} {
+			f, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var declCount int
+			for _, decl := range f.Decls {
+				if gen, ok := decl.(*ast.GenDecl); !ok || gen.Tok != token.IMPORT {
+					declCount++
+				}
+			}
+			declCounts = append(declCounts, declCount)
+		}
+		if declCounts[0] != declCounts[1]+declCounts[2] {
+			t.Errorf("splitHelpersCode: want=%d current=%d+%d", declCounts[0], declCounts[1], declCounts[2])
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		if _, _, err := SplitHelpersCode([]byte("package"), opts); err == nil {
+			t.Error("splitHelpersCode: err == nil")
+		}
+	})
+}
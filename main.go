@@ -3,269 +3,2158 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"go/format"
+	"io"
 	"io/ioutil"
 	"log"
-	"math/big"
+	"net/http"
 	"os"
-	"reflect"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	"cloud.google.com/go/bigquery"
-	"cloud.google.com/go/civil"
-	"golang.org/x/tools/imports"
+	"cloud.google.com/go/storage"
+	"github.com/ginokent/bqschema-gen-go/generator"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 const (
 	// optName
-	optNameProjectID  = "project"
-	optNameDataset    = "dataset"
-	optNameOutputFile = "output"
-	optNameDebug      = "debug"
+	optNameProjectID            = "project"
+	optNameDataset              = "dataset"
+	optNameOutputFile           = "output"
+	optNameDebug                = "debug"
+	optNameEmitClustered        = "emit-clustered"
+	optNameCheck                = "check"
+	optNameNullTypes            = "null-types"
+	optNameTypeOverride         = "type-override"
+	optNameNullableTypeOverride = "nullable-type-override"
+	optNameGormTags             = "gorm-tags"
+	optNameEmitConsoleLinks     = "emit-console-links"
+	optNameEmitTypeRegistry     = "emit-type-registry"
+	optNameFailOnUnsupported    = "fail-on-unsupported"
+	optNameDatasetProject       = "dataset-project"
+	optNameOutputFormat         = "output-format"
+	optNameTokenCache           = "token-cache"
+	optNameLocation             = "location"
+	optNameEndpoint             = "endpoint"
+	optNameFieldGroup           = "field-group"
+	optNameEmbedPattern         = "embed-pattern"
+	optNameEmitColumnMeta       = "emit-column-meta"
+	optNameAvroTags             = "avro-tags"
+	optNameInitialisms          = "initialisms"
+	optNameEmitStructID         = "emit-struct-id"
+	optNameEmitOrdinal          = "emit-ordinal"
+	optNameTablesFile           = "tables-file"
+	optNameImmutable            = "immutable"
+	optNameNestedNameTemplate   = "nested-name-template"
+	optNameEmitValueMap         = "emit-value-map"
+	optNameDiscover             = "discover"
+	optNameDiscoverFilter       = "discover-filter"
+	optNameNullTypesFor         = "null-types-for"
+	optNameCheckChangelog       = "check-changelog"
+	optNameUnsupportedAsAny     = "unsupported-as-any"
+	optNameEmitAllColumns       = "emit-all-columns"
+	optNameEmitModeTags         = "emit-mode-tags"
+	optNameEmitCompareSchema    = "emit-compare-schema"
+	optNameEmitInserter         = "emit-inserter"
+	optNameEmitFieldTypes       = "emit-field-types"
+	optNameInteractive          = "interactive"
+	optNameYes                  = "yes"
+	optNameGCSSchemas           = "gcs-schemas"
+	optNameLimit                = "limit"
+	optNameEmitVersion          = "emit-version"
+	optNameNoGoimports          = "no-goimports"
+	optNameYAMLTags             = "yaml-tags"
+	optNameExtraImports         = "extra-import"
+	optNameFromTempTable        = "from-temp-table"
+	optNameAnnotateNullable     = "annotate-nullable"
+	optNameSpannerTags          = "spanner-tags"
+	optNameMaxNameLength        = "max-name-length"
+	optNameEmitSelect           = "emit-select"
+	optNameLineEnding           = "line-ending"
+	optNameImplements           = "implements"
+	optNameRegisterFunc         = "register-func"
+	optNameSchemaDir            = "schema-dir"
+	optNameAnnotateUTC          = "annotate-utc"
+	optNameEmitInUTC            = "emit-in-utc"
+	optNameOverrides            = "overrides"
+	optNameNameExceptions       = "name-exceptions"
+	optNameBSONTags             = "bson-tags"
+	optNameBSONIDColumn         = "bson-id-column"
+	optNameTypecheck            = "typecheck"
+	optNameStrictCase           = "strict-case"
+	optNameEmitFakes            = "emit-fakes"
+	optNameListTimeout          = "list-timeout"
+	optNameListRetries          = "list-retries"
+	optNameEmitViewQuery        = "emit-view-query"
+	optNameViewQueryMaxLines    = "view-query-max-lines"
+	optNameEmitProtoNumbers     = "emit-proto-numbers"
+	optNameProtoNumbersFile     = "proto-numbers-file"
+	optNameEmitGeneratedFrom    = "emit-generated-from"
+	optNameOmitGeneratedAt      = "omit-generated-at"
+	optNameOutputArchive        = "output-archive"
+	optNameEmitBQTypeComment    = "emit-bq-type-comment"
+	optNameTableOutput          = "table-output"
+	optNameReceiverStyle        = "receiver-style"
+	optNamePrune                = "prune"
+	optNameFirestoreTags        = "firestore-tags"
+	optNameInitNumericZero      = "init-numeric-zero"
+	optNameModifiedSince        = "modified-since"
+	optNameEmitRowInterface     = "emit-row-interface"
+	optNameEmitValidate         = "emit-validate"
+	optNameSplitHelpers         = "split-helpers"
+	optNameTypeAliases          = "type-aliases"
+	optNameQuery                = "query"
+	optNameQueryName            = "query-name"
+	optNameParam                = "param"
+	optNameExcludeAsIgnored     = "exclude-as-ignored"
 	// envName
-	envNameGCloudProjectID = "GCLOUD_PROJECT_ID"
-	envNameBigQueryDataset = "BIGQUERY_DATASET"
-	envNameOutputFile      = "OUTPUT_FILE"
-	envNameDebug           = "DEBUG"
+	envNameGCloudProjectID      = "GCLOUD_PROJECT_ID"
+	envNameBigQueryDataset      = "BIGQUERY_DATASET"
+	envNameOutputFile           = "OUTPUT_FILE"
+	envNameDebug                = "DEBUG"
+	envNameEmitClustered        = "EMIT_CLUSTERED"
+	envNameCheck                = "CHECK"
+	envNameNullTypes            = "NULL_TYPES"
+	envNameTypeOverride         = "TYPE_OVERRIDE"
+	envNameNullableTypeOverride = "NULLABLE_TYPE_OVERRIDE"
+	envNameGormTags             = "GORM_TAGS"
+	envNameEmitConsoleLinks     = "EMIT_CONSOLE_LINKS"
+	envNameEmitTypeRegistry     = "EMIT_TYPE_REGISTRY"
+	envNameFailOnUnsupported    = "FAIL_ON_UNSUPPORTED"
+	envNameDatasetProject       = "BIGQUERY_DATASET_PROJECT_ID"
+	envNameOutputFormat         = "OUTPUT_FORMAT"
+	envNameTokenCache           = "TOKEN_CACHE"
+	envNameLocation             = "BIGQUERY_LOCATION"
+	envNameEndpoint             = "BIGQUERY_ENDPOINT"
+	envNameFieldGroup           = "FIELD_GROUP"
+	envNameEmbedPattern         = "EMBED_PATTERN"
+	envNameEmitColumnMeta       = "EMIT_COLUMN_META"
+	envNameAvroTags             = "AVRO_TAGS"
+	envNameInitialisms          = "INITIALISMS"
+	envNameEmitStructID         = "EMIT_STRUCT_ID"
+	envNameEmitOrdinal          = "EMIT_ORDINAL"
+	envNameTablesFile           = "TABLES_FILE"
+	envNameImmutable            = "IMMUTABLE"
+	envNameNestedNameTemplate   = "NESTED_NAME_TEMPLATE"
+	envNameEmitValueMap         = "EMIT_VALUE_MAP"
+	envNameDiscover             = "DISCOVER"
+	envNameDiscoverFilter       = "DISCOVER_FILTER"
+	envNameNullTypesFor         = "NULL_TYPES_FOR"
+	envNameCheckChangelog       = "CHECK_CHANGELOG"
+	envNameUnsupportedAsAny     = "UNSUPPORTED_AS_ANY"
+	envNameEmitAllColumns       = "EMIT_ALL_COLUMNS"
+	envNameEmitModeTags         = "EMIT_MODE_TAGS"
+	envNameEmitCompareSchema    = "EMIT_COMPARE_SCHEMA"
+	envNameEmitInserter         = "EMIT_INSERTER"
+	envNameEmitFieldTypes       = "EMIT_FIELD_TYPES"
+	envNameInteractive          = "INTERACTIVE"
+	envNameYes                  = "YES"
+	envNameGCSSchemas           = "GCS_SCHEMAS"
+	envNameLimit                = "LIMIT"
+	envNameEmitVersion          = "EMIT_VERSION"
+	envNameNoGoimports          = "NO_GOIMPORTS"
+	envNameYAMLTags             = "YAML_TAGS"
+	envNameExtraImports         = "EXTRA_IMPORTS"
+	envNameFromTempTable        = "FROM_TEMP_TABLE"
+	envNameAnnotateNullable     = "ANNOTATE_NULLABLE"
+	envNameSpannerTags          = "SPANNER_TAGS"
+	envNameMaxNameLength        = "MAX_NAME_LENGTH"
+	envNameEmitSelect           = "EMIT_SELECT"
+	envNameLineEnding           = "LINE_ENDING"
+	envNameImplements           = "IMPLEMENTS"
+	envNameRegisterFunc         = "REGISTER_FUNC"
+	envNameSchemaDir            = "SCHEMA_DIR"
+	envNameAnnotateUTC          = "ANNOTATE_UTC"
+	envNameEmitInUTC            = "EMIT_IN_UTC"
+	envNameOverrides            = "OVERRIDES"
+	envNameNameExceptions       = "NAME_EXCEPTIONS"
+	envNameBSONTags             = "BSON_TAGS"
+	envNameBSONIDColumn         = "BSON_ID_COLUMN"
+	envNameTypecheck            = "TYPECHECK"
+	envNameStrictCase           = "STRICT_CASE"
+	envNameEmitFakes            = "EMIT_FAKES"
+	envNameListTimeout          = "LIST_TIMEOUT"
+	envNameListRetries          = "LIST_RETRIES"
+	envNameEmitViewQuery        = "EMIT_VIEW_QUERY"
+	envNameViewQueryMaxLines    = "VIEW_QUERY_MAX_LINES"
+	envNameEmitProtoNumbers     = "EMIT_PROTO_NUMBERS"
+	envNameProtoNumbersFile     = "PROTO_NUMBERS_FILE"
+	envNameEmitGeneratedFrom    = "EMIT_GENERATED_FROM"
+	envNameOmitGeneratedAt      = "OMIT_GENERATED_AT"
+	envNameOutputArchive        = "OUTPUT_ARCHIVE"
+	envNameEmitBQTypeComment    = "EMIT_BQ_TYPE_COMMENT"
+	envNameTableOutput          = "TABLE_OUTPUT"
+	envNameReceiverStyle        = "RECEIVER_STYLE"
+	envNamePrune                = "PRUNE"
+	envNameFirestoreTags        = "FIRESTORE_TAGS"
+	envNameInitNumericZero      = "INIT_NUMERIC_ZERO"
+	envNameModifiedSince        = "MODIFIED_SINCE"
+	envNameEmitRowInterface     = "EMIT_ROW_INTERFACE"
+	envNameEmitValidate         = "EMIT_VALIDATE"
+	envNameSplitHelpers         = "SPLIT_HELPERS"
+	envNameTypeAliases          = "TYPE_ALIASES"
+	envNameQuery                = "QUERY"
+	envNameQueryName            = "QUERY_NAME"
+	envNameParams               = "PARAMS"
+	envNameExcludeAsIgnored     = "EXCLUDE_AS_IGNORED"
 	// defaultValue
-	defaultValueEmpty      = ""
-	defaultValueOutputFile = "bqschema.generated.go"
-	defaultValueDebug      = "false"
+	defaultValueEmpty             = ""
+	defaultValueOutputFile        = "bqschema.generated.go"
+	defaultValueDebug             = "false"
+	defaultValueEmitClustered     = "false"
+	defaultValueCheck             = "false"
+	defaultValueNullTypes         = "false"
+	defaultValueGormTags          = "false"
+	defaultValueEmitConsoleLinks  = "false"
+	defaultValueEmitTypeRegistry  = "false"
+	defaultValueFailOnUnsupported = "false"
+	defaultValueOutputFormat      = "go"
+	defaultValueEmitColumnMeta    = "false"
+	defaultValueAvroTags          = "false"
+	defaultValueInitialisms       = "false"
+	defaultValueEmitStructID      = "false"
+	defaultValueEmitOrdinal       = "false"
+	defaultValueImmutable         = "false"
+	defaultValueEmitValueMap      = "false"
+	defaultValueDiscover          = "iterator"
+	defaultValueUnsupportedAsAny  = "false"
+	defaultValueEmitAllColumns    = "false"
+	defaultValueEmitModeTags      = "false"
+	defaultValueEmitCompareSchema = "false"
+	defaultValueEmitInserter      = "false"
+	defaultValueEmitFieldTypes    = "false"
+	defaultValueInteractive       = "false"
+	defaultValueYes               = "false"
+	defaultValueLimit             = "0"
+	defaultValueEmitVersion       = "false"
+	defaultValueNoGoimports       = "false"
+	defaultValueYAMLTags          = "false"
+	defaultValueAnnotateNullable  = "false"
+	defaultValueSpannerTags       = "false"
+	defaultValueMaxNameLength     = "0"
+	defaultValueEmitSelect        = "false"
+	defaultValueLineEnding        = "lf"
+	defaultValueAnnotateUTC       = "false"
+	defaultValueEmitInUTC         = "false"
+	defaultValueBSONTags          = "false"
+	defaultValueTypecheck         = "false"
+	defaultValueStrictCase        = "false"
+	defaultValueEmitFakes         = "false"
+	defaultValueListTimeout       = "5m"
+	defaultValueListRetries       = "3"
+	defaultValueEmitViewQuery     = "false"
+	defaultValueViewQueryMaxLines = "20"
+	defaultValueEmitProtoNumbers  = "false"
+	defaultValueProtoNumbersFile  = "bqschema.protonumbers.json"
+	defaultValueEmitGeneratedFrom = "false"
+	defaultValueOmitGeneratedAt   = "false"
+	defaultValueEmitBQTypeComment = "false"
+	defaultValueReceiverStyle     = "short"
+	defaultValuePrune             = "false"
+	defaultValueFirestoreTags     = "false"
+	defaultValueInitNumericZero   = "false"
+	defaultValueEmitRowInterface  = "false"
+	defaultValueEmitValidate      = "false"
+	defaultValueSplitHelpers      = "false"
+	defaultValueQueryName         = "query_result"
+	defaultValueExcludeAsIgnored  = "false"
+)
+
+const (
+	discoverIterator          = "iterator"
+	discoverInformationSchema = "information-schema"
+
+	lineEndingLF   = "lf"
+	lineEndingCRLF = "crlf"
 )
 
 var (
 	// optValue
-	optValueProjectID  = flag.String(optNameProjectID, defaultValueEmpty, "")
-	optValueDataset    = flag.String(optNameDataset, defaultValueEmpty, "")
-	optValueOutputPath = flag.String(optNameOutputFile, defaultValueEmpty, "path to output the generated code")
+	optValueProjectID            = flag.String(optNameProjectID, defaultValueEmpty, "")
+	optValueDataset              = flag.String(optNameDataset, defaultValueEmpty, "")
+	optValueOutputPath           = flag.String(optNameOutputFile, defaultValueEmpty, "path to output the generated code")
+	optValueEmitClustered        = flag.String(optNameEmitClustered, defaultValueEmpty, "annotate fields that are part of the clustering key with a clustered comment")
+	optValueCheck                = flag.String(optNameCheck, defaultValueEmpty, "do not write the output file, but fail with a diff of the struct definitions if it is not up to date. it exits with 3 if columns have changed type")
+	optValueNullTypes            = flag.String(optNameNullTypes, defaultValueEmpty, "generate the bigquery Null* types, e.g. bigquery.NullTimestamp, for NULLABLE scalar columns, and pointers for NULLABLE RECORD columns. the bigquery client loads NULL into them, but not into pointers to the scalar types. BYTES and NUMERIC stay []byte and *big.Rat")
+	optValueTypeOverride         = flag.String(optNameTypeOverride, defaultValueEmpty, "comma-separated list of BigQuery type to Go type overrides. e.g. GEOGRAPHY=github.com/twpayne/go-geom:geom.T")
+	optValueNullableTypeOverride = flag.String(optNameNullableTypeOverride, defaultValueEmpty, "same as -type-override, but applied only to NULLABLE columns. The types must be loadable with NULL by the bigquery client. e.g. TIMESTAMP=cloud.google.com/go/bigquery:bigquery.NullTimestamp")
+	optValueGormTags             = flag.String(optNameGormTags, defaultValueEmpty, "add gorm:\"column:<name>\" tags")
+	optValueEmitConsoleLinks     = flag.String(optNameEmitConsoleLinks, defaultValueEmpty, "add a link to the table in the BigQuery console to the struct comment")
+	optValueEmitTypeRegistry     = flag.String(optNameEmitTypeRegistry, defaultValueEmpty, "generate a TableTypes map from table ID to reflect.Type of the struct")
+	optValueFailOnUnsupported    = flag.String(optNameFailOnUnsupported, defaultValueEmpty, "fail instead of skipping the table when a column of an unsupported type is found")
+	optValueDatasetProject       = flag.String(optNameDatasetProject, defaultValueEmpty, "GCP Project ID that owns the dataset, if different from -project")
+	optValueOutputFormat         = flag.String(optNameOutputFormat, defaultValueEmpty, "format of the generated code. go or proto. a comma-separated list with the same number of output paths generates multiple formats at once")
+	optValueTokenCache           = flag.String(optNameTokenCache, defaultValueEmpty, "path to a file to cache the OAuth2 token in, and reuse it until it expires")
+	optValueLocation             = flag.String(optNameLocation, defaultValueEmpty, "location of the dataset. e.g. europe-west3. the regional endpoint of the location is used unless -endpoint is set")
+	optValueEndpoint             = flag.String(optNameEndpoint, defaultValueEmpty, "BigQuery API endpoint. e.g. https://bigquery.europe-west3.rep.googleapis.com/bigquery/v2/")
+	optValueFieldGroup           = flag.String(optNameFieldGroup, defaultValueEmpty, "group struct fields. mode: REQUIRED first, then NULLABLE, then REPEATED, each in schema order")
+	optValueEmbedPattern         = flag.String(optNameEmbedPattern, defaultValueEmpty, "comma-separated list of [BIGQUERY_TYPE:]glob. matching columns are factored into an embedded <Struct>Metadata struct. e.g. TIMESTAMP:*_at")
+	optValueEmitColumnMeta       = flag.String(optNameEmitColumnMeta, defaultValueEmpty, "generate a <Struct>Columns slice of bqmeta.ColumnMeta per struct")
+	optValueAvroTags             = flag.String(optNameAvroTags, defaultValueEmpty, "add avro:\"<name>\" tags. names are sanitized to valid Avro names")
+	optValueInitialisms          = flag.String(optNameInitialisms, defaultValueEmpty, "convert snake_case column names to CamelCase field names with Go initialisms. e.g. customer_id to CustomerID")
+	optValueEmitStructID         = flag.String(optNameEmitStructID, defaultValueEmpty, "generate a <Struct>StructID const per struct, a stable short ID derived from the dataset and table IDs")
+	optValueEmitOrdinal          = flag.String(optNameEmitOrdinal, defaultValueEmpty, "add ordinal:\"<N>\" tags, the zero-based position of the column in the table schema")
+	optValueTablesFile           = flag.String(optNameTablesFile, defaultValueEmpty, "path to a file listing project.dataset.table lines to generate instead of all tables in the dataset. blank lines and lines starting with # are ignored")
+	optValueImmutable            = flag.String(optNameImmutable, defaultValueEmpty, "also generate a <Struct>Immutable type per struct, with unexported fields, getters, a constructor and a conversion from the struct")
+	optValueNestedNameTemplate   = flag.String(optNameNestedNameTemplate, defaultValueEmpty, "text/template of the names of the structs generated for RECORD columns. fields: .Parent .Field .Column, funcs: singular. default: {{.Parent}}{{.Field}}")
+	optValueEmitValueMap         = flag.String(optNameEmitValueMap, defaultValueEmpty, "generate a ToValueMap method and a <Struct>FromValueMap func per struct, to convert between the struct and map[string]bigquery.Value")
+	optValueDiscover             = flag.String(optNameDiscover, defaultValueEmpty, "how to discover the tables in the dataset. iterator (the tables API) or information-schema (a query to INFORMATION_SCHEMA.TABLES, region-qualified if -location is set)")
+	optValueDiscoverFilter       = flag.String(optNameDiscoverFilter, defaultValueEmpty, "SQL predicate on INFORMATION_SCHEMA.TABLES columns to filter the tables with -discover=information-schema. e.g. table_name LIKE 'events_%'")
+	optValueNullTypesFor         = flag.String(optNameNullTypesFor, defaultValueEmpty, "comma-separated BigQuery types to generate the types of -null-types for, only for NULLABLE columns, i.e. the bigquery Null* types for the scalar types and pointers for RECORD. e.g. TIMESTAMP,RECORD")
+	optValueCheckChangelog       = flag.String(optNameCheckChangelog, defaultValueEmpty, "path to write the added, removed and retyped columns per struct of each Go output found in -check mode, as a JSON array")
+	optValueUnsupportedAsAny     = flag.String(optNameUnsupportedAsAny, defaultValueEmpty, "generate interface{} fields for columns of unsupported types instead of skipping the table")
+	optValueEmitAllColumns       = flag.String(optNameEmitAllColumns, defaultValueEmpty, "generate a package-level const block of the column names of all tables")
+	optValueEmitModeTags         = flag.String(optNameEmitModeTags, defaultValueEmpty, "add a mode tag of REQUIRED, NULLABLE or REPEATED to the fields")
+	optValueEmitCompareSchema    = flag.String(optNameEmitCompareSchema, defaultValueEmpty, "generate a CompareSchema method per struct that returns the differences from the live table schema")
+	optValueEmitInserter         = flag.String(optNameEmitInserter, defaultValueEmpty, "generate an Insert<Struct> function per struct that streams rows into the table")
+	optValueEmitFieldTypes       = flag.String(optNameEmitFieldTypes, defaultValueEmpty, "generate a <Struct>FieldTypes map from field name to bigquery.FieldType per struct")
+	optValueInteractive          = flag.String(optNameInteractive, defaultValueEmpty, "print a summary of the changes to the output files and ask for confirmation before writing them")
+	optValueYes                  = flag.String(optNameYes, defaultValueEmpty, "confirm writing the output files without asking in -interactive mode")
+	optValueGCSSchemas           = flag.String(optNameGCSSchemas, defaultValueEmpty, "GCS URL of the schema JSON files exported by bq show --schema --format=json, to generate from instead of the table metadata. e.g. gs://bucket/schemas/")
+	optValueLimit                = flag.String(optNameLimit, defaultValueEmpty, "maximum number of tables to generate, in the order they are listed. 0 means no limit")
+	optValueEmitVersion          = flag.String(optNameEmitVersion, defaultValueEmpty, "generate a SchemaVersion const, a hash of the schemas of all tables")
+	optValueNoGoimports          = flag.String(optNameNoGoimports, defaultValueEmpty, "skip the goimports pass and use the generated import block as it is, only formatted by gofmt")
+	optValueYAMLTags             = flag.String(optNameYAMLTags, defaultValueEmpty, "add yaml tags with the column names to the fields")
+	optValueExtraImports         = newRepeatedFlag(optNameExtraImports, "package to add to the import block, as path or name path. repeatable. imports not referenced by the generated code are removed by goimports unless the name is _")
+	optValueFromTempTable        = flag.String(optNameFromTempTable, defaultValueEmpty, "reference of an existing temporary table, e.g. the destination table of a query job, to generate a struct for. format: project:dataset.table, project.dataset.table or dataset.table")
+	optValueAnnotateNullable     = flag.String(optNameAnnotateNullable, defaultValueEmpty, "add a nullable comment to the fields of NULLABLE columns")
+	optValueSpannerTags          = flag.String(optNameSpannerTags, defaultValueEmpty, "add spanner tags with the PascalCase column names of Cloud Spanner conventions to the fields")
+	optValueMaxNameLength        = flag.String(optNameMaxNameLength, defaultValueEmpty, "maximum length of the generated struct and field names. longer names are cut and suffixed with a hash of the full name. 0 means no limit")
+	optValueEmitSelect           = flag.String(optNameEmitSelect, defaultValueEmpty, "generate a SelectQuery method per struct that returns a SELECT of the columns of the struct")
+	optValueLineEnding           = flag.String(optNameLineEnding, defaultValueEmpty, "line ending of the generated files. lf or crlf. applied after formatting")
+	optValueImplements           = flag.String(optNameImplements, defaultValueEmpty, "comma-separated interfaces that the generated structs must implement, as [import/path:]pkg.Interface. a compile-time assertion is generated per struct. e.g. example.com/mypkg:mypkg.Record")
+	optValueRegisterFunc         = flag.String(optNameRegisterFunc, defaultValueEmpty, "function to register each generated struct with in a generated init(), as [import/path:]pkg.Func. it is called as Func(tableID, Struct{}). e.g. example.com/mypkg:mypkg.Register")
+	optValueSchemaDir            = flag.String(optNameSchemaDir, defaultValueEmpty, "directory of the schema JSON files exported by bq show --schema --format=prettyjson, to generate from offline instead of the table metadata. the table ID is the file name without .json")
+	optValueAnnotateUTC          = flag.String(optNameAnnotateUTC, defaultValueEmpty, "comment the fields of TIMESTAMP columns with UTC, as BigQuery stores TIMESTAMP in UTC")
+	optValueEmitInUTC            = flag.String(optNameEmitInUTC, defaultValueEmpty, "generate an InUTC method per struct that sets the location of the time.Time values of TIMESTAMP columns to UTC after loading")
+	optValueOverrides            = flag.String(optNameOverrides, defaultValueEmpty, `JSON file of the per-table overrides keyed by table ID. keys: struct_name, include_columns, exclude_columns, set_columns, column_tags. e.g. {"users": {"struct_name": "User", "exclude_columns": ["password"]}}`)
+	optValueNameExceptions       = flag.String(optNameNameExceptions, defaultValueEmpty, `JSON file of the output casings of snake_case segments, consulted after the initialisms with -initialisms. e.g. {"ios": "IOS", "oauth": "OAuth"}`)
+	optValueBSONTags             = flag.String(optNameBSONTags, defaultValueEmpty, "add bson tags with the column names to the fields, for MongoDB")
+	optValueBSONIDColumn         = flag.String(optNameBSONIDColumn, defaultValueEmpty, "top-level column whose bson tag is _id, the primary key of MongoDB documents, with -bson-tags. e.g. id")
+	optValueTypecheck            = flag.String(optNameTypecheck, defaultValueEmpty, "type-check the generated Go code with the other files in the output directory before writing, loading the imported packages from source")
+	optValueStrictCase           = flag.String(optNameStrictCase, defaultValueEmpty, "document in the struct comments that the bigquery tags are the exact-case column names, for loaders that match the fields case-sensitively")
+	optValueEmitFakes            = flag.String(optNameEmitFakes, defaultValueEmpty, "generate Fake<Struct> and Fake<Struct>Slice funcs that return the structs populated with deterministic values for tests, into a companion *_fake.generated.go file in the same package")
+	optValueListTimeout          = flag.String(optNameListTimeout, defaultValueEmpty, "timeout of each attempt to list the tables in the dataset. 0 disables the timeout")
+	optValueListRetries          = flag.String(optNameListRetries, defaultValueEmpty, "max number of retries of listing the tables in the dataset on retryable errors, with exponential backoff")
+	optValueEmitViewQuery        = flag.String(optNameEmitViewQuery, defaultValueEmpty, "comment the structs of views with the defining queries")
+	optValueViewQueryMaxLines    = flag.String(optNameViewQueryMaxLines, defaultValueEmpty, "max number of lines of the defining query in the comment with -emit-view-query. 0 comments the full query")
+	optValueEmitProtoNumbers     = flag.String(optNameEmitProtoNumbers, defaultValueEmpty, "add protobuf tags with field numbers assigned in schema order to the fields. the assignments are persisted in -proto-numbers-file, so that the numbers do not change across runs")
+	optValueProtoNumbersFile     = flag.String(optNameProtoNumbersFile, defaultValueEmpty, "path to the JSON file tracking the field numbers of -emit-proto-numbers. the numbers of removed columns are kept reserved")
+	optValueEmitGeneratedFrom    = flag.String(optNameEmitGeneratedFrom, defaultValueEmpty, "generate a GeneratedFrom var of the project and the dataset the structs are generated from, and when, for logging the provenance at runtime")
+	optValueOmitGeneratedAt      = flag.String(optNameOmitGeneratedAt, defaultValueEmpty, "leave GeneratedAt of -emit-generated-from zero, so that the generated code does not change on every run (e.g. with -check)")
+	optValueOutputArchive        = flag.String(optNameOutputArchive, defaultValueEmpty, "path to a .zip, .tar, .tar.gz or .tgz archive to write the generated files into instead of the filesystem, by the paths of -output as the entry names. the -proto-numbers-file is still written to the filesystem")
+	optValueEmitBQTypeComment    = flag.String(optNameEmitBQTypeComment, defaultValueEmpty, "comment each field with the BigQuery type and mode of the column. e.g. // BQ: INTEGER NULLABLE")
+	optValueTableOutput          = newRepeatedFlag(optNameTableOutput, "table to generate into its own Go file instead of the main Go output, as table=path. the path must be in the directory of the main Go output. repeatable. e.g. events=events.generated.go")
+	optValueReceiverStyle        = flag.String(optNameReceiverStyle, defaultValueEmpty, "receiver name style of the generated methods. short: the initial of the type (e.g. u for Users), full: the lower-camel-case type name (e.g. users for Users)")
+	optValuePrune                = flag.String(optNamePrune, defaultValueEmpty, "after writing, remove the .generated.go files in the directory of the Go output that have been generated by this command only for tables of the dataset that no longer exist, and their fakes. files without the DO NOT EDIT header are never removed")
+	optValueFirestoreTags        = flag.String(optNameFirestoreTags, defaultValueEmpty, "add firestore tags with the column names to the fields, for the Firestore client. pseudo columns are skipped by firestore:\"-\"")
+	optValueInitNumericZero      = flag.String(optNameInitNumericZero, defaultValueEmpty, "with -immutable, initialize the nil *big.Rat arguments of the constructors to zero instead of leaving them nil")
+	optValueModifiedSince        = flag.String(optNameModifiedSince, defaultValueEmpty, "RFC 3339 timestamp. e.g. 2006-01-02T15:04:05Z. regenerate only the structs of the tables modified after it, and keep the structs of the other tables as they are in the existing Go outputs")
+	optValueEmitRowInterface     = flag.String(optNameEmitRowInterface, defaultValueEmpty, "generate a Row interface implemented by all the structs, and a RowDecoders map from table ID to the decoder of the rows of a *bigquery.RowIterator into the struct")
+	optValueEmitValidate         = flag.String(optNameEmitValidate, defaultValueEmpty, "generate a Validate method per struct (including nested ones) that returns an error naming the REQUIRED columns whose fields are nil")
+	optValueSplitHelpers         = flag.String(optNameSplitHelpers, defaultValueEmpty, "write the type declarations to the Go output, and the methods and the other helpers to a companion *_helpers.generated.go file in the same package")
+	optValueTypeAliases          = flag.String(optNameTypeAliases, defaultValueEmpty, "path to a JSON file that maps the type names of non-standard sources, e.g. legacy SQL tools, to BigQuery types. e.g. {\"DATETIME2\": \"DATETIME\"}")
+	optValueQuery                = flag.String(optNameQuery, defaultValueEmpty, "standard SQL query to generate a struct for its result schema instead of the tables of the dataset. the query is validated by a dry run, which is not charged, and is not executed")
+	optValueQueryName            = flag.String(optNameQueryName, defaultValueEmpty, "table ID the struct of -query is named after. e.g. daily_sales")
+	optValueParams               = newRepeatedFlag(optNameParam, "named parameter of -query, as name:type:value. the type is a BigQuery type. repeatable. the value cannot contain `,`. e.g. day:DATE:2020-11-01")
+	optValueExcludeAsIgnored     = flag.String(optNameExcludeAsIgnored, defaultValueEmpty, "keep the columns that -overrides exclude in the structs as fields tagged `bigquery:\"-\"`, which the bigquery client does not load. the other generated code does not refer to them")
 )
 
-func main() {
+// repeatedFlag is a flag.Value that collects the values of a flag given more than once.
+type repeatedFlag []string
+
+func newRepeatedFlag(name, usage string) *repeatedFlag {
+	f := &repeatedFlag{}
+	flag.Var(f, name, usage)
+	return f
+}
+
+// String returns the values joined with `,`, in the same format as the environment variable.
+func (f *repeatedFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+
+	ctx, stop := notifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	runMain(ctx, stop, Run)
+}
+
+// runMain calls run with ctx, then logs the error of it and exits with the exit code of the error if any.
+func runMain(ctx context.Context, stop context.CancelFunc, run func(ctx context.Context) error) {
+	err := run(ctx)
+	// Stop cancels ctx too, so whether a signal has cancelled the run is checked before it.
+	cancelled := ctx.Err() != nil
+	stop()
+	if err != nil {
+		if cancelled {
+			errorln("cancelled: " + err.Error())
+		} else {
+			errorln("Run: " + err.Error())
+		}
+		// A distinct exit code, so that CI can treat the retypes as breaking changes.
+		if errors.Is(err, errColumnsRetyped) {
+			exit(exitCodeColumnsRetyped)
+		}
+		exit(1)
+	}
+}
+
+// notifyContext returns a copy of the parent context that is cancelled when one of the signals arrives,
+// or when stop is called. After the first signal, the default behavior of the signals is restored,
+// so that a second signal terminates the program immediately.
+// The same as signal.NotifyContext, which is not available in go 1.15.
+func notifyContext(parent context.Context, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			warnln("received " + sig.String() + ". cancelling...")
+			signal.Stop(ch)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(ch)
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// Run is effectively a `main` function.
+// It is separated from the `main` function because of addressing an issue where` defer` is not executed when `os.Exit` is executed.
+func Run(ctx context.Context) (err error) {
+	flag.Parse()
+
+	var src schemaSource
+	src, err = getSchemaSource()
+	if err != nil {
+		return fmt.Errorf("getSchemaSource: %w", err)
+	}
+
+	var opts generator.Options
+	opts, err = getGeneratorOptions()
+	if err != nil {
+		return fmt.Errorf("getGeneratorOptions: %w", err)
+	}
+
+	var outputs outputFiles
+	outputs, err = getOutputFiles(src.limit)
+	if err != nil {
+		return fmt.Errorf("getOutputFiles: %w", err)
+	}
+
+	schemaProject := opts.DatasetProject
+	if schemaProject == "" {
+		schemaProject = src.project
+	}
+
+	// Fetch the metadata once, and render it in each output format
+	var schemas []generator.TableSchema
+	schemas, err = readTableSchemas(ctx, src, opts.DatasetProject, schemaProject)
+	if err != nil {
+		return fmt.Errorf("readTableSchemas: %w", err)
+	}
+	schemas = generator.PrepareSchemas(schemas, &opts)
+	warnMissingTableOutputs(schemas, outputs.tableOutputs)
+
+	tableIDs := make(map[string]bool)
+	for _, schema := range schemas {
+		tableIDs[schema.Table.TableID] = true
+	}
+	// The files of the removed tables are not generated, but pruned
+	if outputs.prune && len(outputs.tableOutputs) > 0 {
+		outputs.formats, outputs.paths = withoutMissingTableOutputs(outputs, tableIDs)
+	}
+
+	if !outputs.modifiedSince.IsZero() {
+		opts.PreservedStructs, err = readPreservedStructs(outputs.modifiedSincePaths, schemas, schemaProject, src.dataset, outputs.modifiedSince)
+		if err != nil {
+			return fmt.Errorf("readPreservedStructs: %w", err)
+		}
+		infoln(fmt.Sprintf("-%s=%s: keeping %d of %d tables as they are", optNameModifiedSince, outputs.modifiedSince.Format(time.RFC3339), len(opts.PreservedStructs), len(schemas)))
+	}
+
+	// Assign after the overrides, so that the numbers of the excluded columns are kept reserved
+	var protoNumbersCode []byte
+	if outputs.emitProtoNumbers {
+		var numbers protoNumbers
+		numbers, err = readProtoNumbers(outputs.protoNumbersFile)
+		if err != nil {
+			return fmt.Errorf("readProtoNumbers: %w", err)
+		}
+		numbers, opts.ProtoNumbers = assignProtoNumbers(schemas, numbers)
+		protoNumbersCode, err = marshalProtoNumbers(numbers)
+		if err != nil {
+			return fmt.Errorf("marshalProtoNumbers: %w", err)
+		}
+	}
+
+	var generatedCodes [][]byte
+	generatedCodes, err = generateOutputs(schemas, opts, outputs, protoNumbersCode)
+	if err != nil {
+		return fmt.Errorf("generateOutputs: %w", err)
+	}
+
+	if outputs.interactive && !outputs.check {
+		var confirmed bool
+		confirmed, err = confirmWrite(os.Stdin, os.Stdout, isTerminal(os.Stdin), outputs.yes, outputs.paths, outputs.formats, generatedCodes)
+		if err != nil {
+			return fmt.Errorf("confirmWrite: %w", err)
+		}
+		if !confirmed {
+			infoln("cancelled. no files have been written")
+			return nil
+		}
+	}
+
+	if outputs.check {
+		if err = checkOutputs(outputs, generatedCodes); err != nil {
+			return err
+		}
+	} else {
+		if err = writeOutputs(outputs, generatedCodes); err != nil {
+			return fmt.Errorf("writeOutputs: %w", err)
+		}
+	}
+
+	if outputs.prune {
+		if err = pruneOutputs(outputs, schemaProject, src.dataset, tableIDs); err != nil {
+			return fmt.Errorf("pruneOutputs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// schemaSource is where the table schemas are read from.
+type schemaSource struct {
+	project        string
+	dataset        string
+	tablesFile     string
+	gcsSchemas     string
+	schemaDir      string
+	fromTempTable  string
+	query          string
+	queryName      string
+	queryParams    []bigquery.QueryParameter
+	discover       string
+	discoverFilter string
+	location       string
+	listTimeout    time.Duration
+	listRetries    int
+	limit          int
+}
+
+// getSchemaSource returns the schemaSource of the options.
+func getSchemaSource() (src schemaSource, err error) {
+	var project string
+	project, err = getOptOrEnvOrDefault(optNameProjectID, *optValueProjectID, envNameGCloudProjectID, "")
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+
+	tablesFile := getOptOrEnv(optNameTablesFile, *optValueTablesFile, envNameTablesFile)
+	gcsSchemas := getOptOrEnv(optNameGCSSchemas, *optValueGCSSchemas, envNameGCSSchemas)
+	schemaDir := getOptOrEnv(optNameSchemaDir, *optValueSchemaDir, envNameSchemaDir)
+	fromTempTable := getOptOrEnv(optNameFromTempTable, *optValueFromTempTable, envNameFromTempTable)
+	query := getOptOrEnv(optNameQuery, *optValueQuery, envNameQuery)
+
+	var queryName string
+	if query != "" {
+		queryName, err = getOptOrEnvOrDefault(optNameQueryName, *optValueQueryName, envNameQueryName, defaultValueQueryName)
+		if err != nil {
+			return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+	}
+	var queryParams []bigquery.QueryParameter
+	queryParams, err = parseQueryParams(getOptOrEnv(optNameParam, optValueParams.String(), envNameParams))
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("parseQueryParams: %w", err)
+	}
+	if len(queryParams) > 0 && query == "" {
+		return schemaSource{}, fmt.Errorf("-%s requires -%s", optNameParam, optNameQuery)
+	}
+
+	var limitString string
+	limitString, err = getOptOrEnvOrDefault(optNameLimit, *optValueLimit, envNameLimit, defaultValueLimit)
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var limit int
+	limit, err = strconv.Atoi(limitString)
+	if err != nil || limit < 0 {
+		return schemaSource{}, fmt.Errorf("-%s=%s is not a non-negative integer", optNameLimit, limitString)
+	}
+
+	var listTimeoutString string
+	listTimeoutString, err = getOptOrEnvOrDefault(optNameListTimeout, *optValueListTimeout, envNameListTimeout, defaultValueListTimeout)
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var listTimeout time.Duration
+	listTimeout, err = time.ParseDuration(listTimeoutString)
+	if err != nil || listTimeout < 0 {
+		return schemaSource{}, fmt.Errorf("-%s=%s is not a non-negative duration", optNameListTimeout, listTimeoutString)
+	}
+
+	var listRetriesString string
+	listRetriesString, err = getOptOrEnvOrDefault(optNameListRetries, *optValueListRetries, envNameListRetries, defaultValueListRetries)
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var listRetries int
+	listRetries, err = strconv.Atoi(listRetriesString)
+	if err != nil || listRetries < 0 {
+		return schemaSource{}, fmt.Errorf("-%s=%s is not a non-negative integer", optNameListRetries, listRetriesString)
+	}
+
+	var dataset string
+	// The tables in tablesFile, fromTempTable and query are qualified, so the dataset is not required
+	if tablesFile == "" && fromTempTable == "" && query == "" {
+		dataset, err = getOptOrEnvOrDefault(optNameDataset, *optValueDataset, envNameBigQueryDataset, "")
+		if err != nil {
+			return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+	}
+
+	var discover string
+	discover, err = getOptOrEnvOrDefault(optNameDiscover, *optValueDiscover, envNameDiscover, defaultValueDiscover)
+	if err != nil {
+		return schemaSource{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	if discover != discoverIterator && discover != discoverInformationSchema {
+		return schemaSource{}, fmt.Errorf("-%s=%s is not supported. supported: %s, %s", optNameDiscover, discover, discoverIterator, discoverInformationSchema)
+	}
+	discoverFilter := getOptOrEnv(optNameDiscoverFilter, *optValueDiscoverFilter, envNameDiscoverFilter)
+
+	location := getOptOrEnv(optNameLocation, *optValueLocation, envNameLocation)
+
+	return schemaSource{
+		project:        project,
+		dataset:        dataset,
+		tablesFile:     tablesFile,
+		gcsSchemas:     gcsSchemas,
+		schemaDir:      schemaDir,
+		fromTempTable:  fromTempTable,
+		query:          query,
+		queryName:      queryName,
+		queryParams:    queryParams,
+		discover:       discover,
+		discoverFilter: discoverFilter,
+		location:       location,
+		listTimeout:    listTimeout,
+		listRetries:    listRetries,
+		limit:          limit,
+	}, nil
+}
+
+// getGeneratorOptions returns the generator.Options of the options.
+func getGeneratorOptions() (opts generator.Options, err error) {
+	var extraImports []string
+	extraImports, err = parseExtraImports(getOptOrEnv(optNameExtraImports, optValueExtraImports.String(), envNameExtraImports))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseExtraImports: %w", err)
+	}
+
+	var maxNameLengthString string
+	maxNameLengthString, err = getOptOrEnvOrDefault(optNameMaxNameLength, *optValueMaxNameLength, envNameMaxNameLength, defaultValueMaxNameLength)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var maxNameLength int
+	maxNameLength, err = strconv.Atoi(maxNameLengthString)
+	// At least the initial and the hash, so that the names stay exported
+	if err != nil || (maxNameLength != 0 && maxNameLength <= generator.NameHashLength) {
+		return generator.Options{}, fmt.Errorf("-%s=%s is not 0 or an integer greater than %d", optNameMaxNameLength, maxNameLengthString, generator.NameHashLength)
+	}
+
+	var viewQueryMaxLinesString string
+	viewQueryMaxLinesString, err = getOptOrEnvOrDefault(optNameViewQueryMaxLines, *optValueViewQueryMaxLines, envNameViewQueryMaxLines, defaultValueViewQueryMaxLines)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var viewQueryMaxLines int
+	viewQueryMaxLines, err = strconv.Atoi(viewQueryMaxLinesString)
+	if err != nil || viewQueryMaxLines < 0 {
+		return generator.Options{}, fmt.Errorf("-%s=%s is not a non-negative integer", optNameViewQueryMaxLines, viewQueryMaxLinesString)
+	}
+
+	var debugString string
+	debugString, err = getOptOrEnvOrDefault(optNameDebug, *optValueOutputPath, envNameDebug, defaultValueDebug)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	debug, _ := strconv.ParseBool(debugString)
+
+	var emitClustered bool
+	emitClustered, err = getOptOrEnvOrDefaultBool(optNameEmitClustered, *optValueEmitClustered, envNameEmitClustered, defaultValueEmitClustered)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var nullTypes bool
+	nullTypes, err = getOptOrEnvOrDefaultBool(optNameNullTypes, *optValueNullTypes, envNameNullTypes, defaultValueNullTypes)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var nullTypesFor map[bigquery.FieldType]bool
+	nullTypesFor, err = parseNullTypesFor(getOptOrEnv(optNameNullTypesFor, *optValueNullTypesFor, envNameNullTypesFor))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseNullTypesFor: %w", err)
+	}
+
+	var typeOverrides map[bigquery.FieldType]generator.GoType
+	typeOverrides, err = parseTypeOverrides(getOptOrEnv(optNameTypeOverride, *optValueTypeOverride, envNameTypeOverride))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseTypeOverrides: -%s: %w", optNameTypeOverride, err)
+	}
+
+	var nullableTypeOverrides map[bigquery.FieldType]generator.GoType
+	nullableTypeOverrides, err = parseTypeOverrides(getOptOrEnv(optNameNullableTypeOverride, *optValueNullableTypeOverride, envNameNullableTypeOverride))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseTypeOverrides: -%s: %w", optNameNullableTypeOverride, err)
+	}
+
+	var implements []generator.GoType
+	implements, err = parseImplements(getOptOrEnv(optNameImplements, *optValueImplements, envNameImplements))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseImplements: %w", err)
+	}
+
+	var tableOverrides map[string]generator.TableOverride
+	overridesFile := getOptOrEnv(optNameOverrides, *optValueOverrides, envNameOverrides)
+	if overridesFile != "" {
+		tableOverrides, err = readTableOverrides(overridesFile)
+		if err != nil {
+			return generator.Options{}, fmt.Errorf("readTableOverrides: %w", err)
+		}
+	}
+
+	var excludeAsIgnored bool
+	excludeAsIgnored, err = getOptOrEnvOrDefaultBool(optNameExcludeAsIgnored, *optValueExcludeAsIgnored, envNameExcludeAsIgnored, defaultValueExcludeAsIgnored)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	if excludeAsIgnored && overridesFile == "" {
+		return generator.Options{}, fmt.Errorf("-%s requires -%s", optNameExcludeAsIgnored, optNameOverrides)
+	}
+
+	var typeAliases map[bigquery.FieldType]bigquery.FieldType
+	if typeAliasesFile := getOptOrEnv(optNameTypeAliases, *optValueTypeAliases, envNameTypeAliases); typeAliasesFile != "" {
+		typeAliases, err = readTypeAliases(typeAliasesFile)
+		if err != nil {
+			return generator.Options{}, fmt.Errorf("readTypeAliases: %w", err)
+		}
+	}
+
+	var nameExceptions map[string]string
+	if nameExceptionsFile := getOptOrEnv(optNameNameExceptions, *optValueNameExceptions, envNameNameExceptions); nameExceptionsFile != "" {
+		nameExceptions, err = readNameExceptions(nameExceptionsFile)
+		if err != nil {
+			return generator.Options{}, fmt.Errorf("readNameExceptions: %w", err)
+		}
+	}
+
+	var registerFunc generator.GoType
+	registerFunc, err = parseRegisterFunc(getOptOrEnv(optNameRegisterFunc, *optValueRegisterFunc, envNameRegisterFunc))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseRegisterFunc: %w", err)
+	}
+
+	var gormTags bool
+	gormTags, err = getOptOrEnvOrDefaultBool(optNameGormTags, *optValueGormTags, envNameGormTags, defaultValueGormTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitConsoleLinks bool
+	emitConsoleLinks, err = getOptOrEnvOrDefaultBool(optNameEmitConsoleLinks, *optValueEmitConsoleLinks, envNameEmitConsoleLinks, defaultValueEmitConsoleLinks)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitTypeRegistry bool
+	emitTypeRegistry, err = getOptOrEnvOrDefaultBool(optNameEmitTypeRegistry, *optValueEmitTypeRegistry, envNameEmitTypeRegistry, defaultValueEmitTypeRegistry)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var failOnUnsupported bool
+	failOnUnsupported, err = getOptOrEnvOrDefaultBool(optNameFailOnUnsupported, *optValueFailOnUnsupported, envNameFailOnUnsupported, defaultValueFailOnUnsupported)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	datasetProject := getOptOrEnv(optNameDatasetProject, *optValueDatasetProject, envNameDatasetProject)
+
+	var receiverStyle string
+	receiverStyle, err = getOptOrEnvOrDefault(optNameReceiverStyle, *optValueReceiverStyle, envNameReceiverStyle, defaultValueReceiverStyle)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	if receiverStyle != generator.ReceiverStyleShort && receiverStyle != generator.ReceiverStyleFull {
+		return generator.Options{}, fmt.Errorf("-%s=%s is not supported. supported: %s, %s", optNameReceiverStyle, receiverStyle, generator.ReceiverStyleShort, generator.ReceiverStyleFull)
+	}
+
+	fieldGroup := getOptOrEnv(optNameFieldGroup, *optValueFieldGroup, envNameFieldGroup)
+	if fieldGroup != "" && fieldGroup != generator.FieldGroupMode {
+		return generator.Options{}, fmt.Errorf("-%s=%s is not supported. supported: %s", optNameFieldGroup, fieldGroup, generator.FieldGroupMode)
+	}
+
+	var embedPatterns []generator.EmbedPattern
+	embedPatterns, err = parseEmbedPatterns(getOptOrEnv(optNameEmbedPattern, *optValueEmbedPattern, envNameEmbedPattern))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("parseEmbedPatterns: %w", err)
+	}
+
+	var emitColumnMeta bool
+	emitColumnMeta, err = getOptOrEnvOrDefaultBool(optNameEmitColumnMeta, *optValueEmitColumnMeta, envNameEmitColumnMeta, defaultValueEmitColumnMeta)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var avroTags bool
+	avroTags, err = getOptOrEnvOrDefaultBool(optNameAvroTags, *optValueAvroTags, envNameAvroTags, defaultValueAvroTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var initialisms bool
+	initialisms, err = getOptOrEnvOrDefaultBool(optNameInitialisms, *optValueInitialisms, envNameInitialisms, defaultValueInitialisms)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitStructID bool
+	emitStructID, err = getOptOrEnvOrDefaultBool(optNameEmitStructID, *optValueEmitStructID, envNameEmitStructID, defaultValueEmitStructID)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitOrdinal bool
+	emitOrdinal, err = getOptOrEnvOrDefaultBool(optNameEmitOrdinal, *optValueEmitOrdinal, envNameEmitOrdinal, defaultValueEmitOrdinal)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var immutable bool
+	immutable, err = getOptOrEnvOrDefaultBool(optNameImmutable, *optValueImmutable, envNameImmutable, defaultValueImmutable)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var nestedNameTemplate *template.Template
+	nestedNameTemplate, err = generator.ParseNestedNameTemplate(getOptOrEnv(optNameNestedNameTemplate, *optValueNestedNameTemplate, envNameNestedNameTemplate))
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("generator.ParseNestedNameTemplate: -%s: %w", optNameNestedNameTemplate, err)
+	}
+
+	var emitValueMap bool
+	emitValueMap, err = getOptOrEnvOrDefaultBool(optNameEmitValueMap, *optValueEmitValueMap, envNameEmitValueMap, defaultValueEmitValueMap)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var unsupportedAsAny bool
+	unsupportedAsAny, err = getOptOrEnvOrDefaultBool(optNameUnsupportedAsAny, *optValueUnsupportedAsAny, envNameUnsupportedAsAny, defaultValueUnsupportedAsAny)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitAllColumns bool
+	emitAllColumns, err = getOptOrEnvOrDefaultBool(optNameEmitAllColumns, *optValueEmitAllColumns, envNameEmitAllColumns, defaultValueEmitAllColumns)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitModeTags bool
+	emitModeTags, err = getOptOrEnvOrDefaultBool(optNameEmitModeTags, *optValueEmitModeTags, envNameEmitModeTags, defaultValueEmitModeTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitCompareSchema bool
+	emitCompareSchema, err = getOptOrEnvOrDefaultBool(optNameEmitCompareSchema, *optValueEmitCompareSchema, envNameEmitCompareSchema, defaultValueEmitCompareSchema)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitInserter bool
+	emitInserter, err = getOptOrEnvOrDefaultBool(optNameEmitInserter, *optValueEmitInserter, envNameEmitInserter, defaultValueEmitInserter)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitFieldTypes bool
+	emitFieldTypes, err = getOptOrEnvOrDefaultBool(optNameEmitFieldTypes, *optValueEmitFieldTypes, envNameEmitFieldTypes, defaultValueEmitFieldTypes)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitVersion bool
+	emitVersion, err = getOptOrEnvOrDefaultBool(optNameEmitVersion, *optValueEmitVersion, envNameEmitVersion, defaultValueEmitVersion)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var noGoimports bool
+	noGoimports, err = getOptOrEnvOrDefaultBool(optNameNoGoimports, *optValueNoGoimports, envNameNoGoimports, defaultValueNoGoimports)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var yamlTags bool
+	yamlTags, err = getOptOrEnvOrDefaultBool(optNameYAMLTags, *optValueYAMLTags, envNameYAMLTags, defaultValueYAMLTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var annotateNullable bool
+	annotateNullable, err = getOptOrEnvOrDefaultBool(optNameAnnotateNullable, *optValueAnnotateNullable, envNameAnnotateNullable, defaultValueAnnotateNullable)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var spannerTags bool
+	spannerTags, err = getOptOrEnvOrDefaultBool(optNameSpannerTags, *optValueSpannerTags, envNameSpannerTags, defaultValueSpannerTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitSelect bool
+	emitSelect, err = getOptOrEnvOrDefaultBool(optNameEmitSelect, *optValueEmitSelect, envNameEmitSelect, defaultValueEmitSelect)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var annotateUTC bool
+	annotateUTC, err = getOptOrEnvOrDefaultBool(optNameAnnotateUTC, *optValueAnnotateUTC, envNameAnnotateUTC, defaultValueAnnotateUTC)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitInUTC bool
+	emitInUTC, err = getOptOrEnvOrDefaultBool(optNameEmitInUTC, *optValueEmitInUTC, envNameEmitInUTC, defaultValueEmitInUTC)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	bsonIDColumn := getOptOrEnv(optNameBSONIDColumn, *optValueBSONIDColumn, envNameBSONIDColumn)
+
+	var bsonTags bool
+	bsonTags, err = getOptOrEnvOrDefaultBool(optNameBSONTags, *optValueBSONTags, envNameBSONTags, defaultValueBSONTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var strictCase bool
+	strictCase, err = getOptOrEnvOrDefaultBool(optNameStrictCase, *optValueStrictCase, envNameStrictCase, defaultValueStrictCase)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitViewQuery bool
+	emitViewQuery, err = getOptOrEnvOrDefaultBool(optNameEmitViewQuery, *optValueEmitViewQuery, envNameEmitViewQuery, defaultValueEmitViewQuery)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitGeneratedFrom bool
+	emitGeneratedFrom, err = getOptOrEnvOrDefaultBool(optNameEmitGeneratedFrom, *optValueEmitGeneratedFrom, envNameEmitGeneratedFrom, defaultValueEmitGeneratedFrom)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	var omitGeneratedAt bool
+	omitGeneratedAt, err = getOptOrEnvOrDefaultBool(optNameOmitGeneratedAt, *optValueOmitGeneratedAt, envNameOmitGeneratedAt, defaultValueOmitGeneratedAt)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	var generatedAt time.Time
+	if !omitGeneratedAt {
+		generatedAt = time.Now()
+	}
+
+	var emitBQTypeComment bool
+	emitBQTypeComment, err = getOptOrEnvOrDefaultBool(optNameEmitBQTypeComment, *optValueEmitBQTypeComment, envNameEmitBQTypeComment, defaultValueEmitBQTypeComment)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var firestoreTags bool
+	firestoreTags, err = getOptOrEnvOrDefaultBool(optNameFirestoreTags, *optValueFirestoreTags, envNameFirestoreTags, defaultValueFirestoreTags)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var initNumericZero bool
+	initNumericZero, err = getOptOrEnvOrDefaultBool(optNameInitNumericZero, *optValueInitNumericZero, envNameInitNumericZero, defaultValueInitNumericZero)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitRowInterface bool
+	emitRowInterface, err = getOptOrEnvOrDefaultBool(optNameEmitRowInterface, *optValueEmitRowInterface, envNameEmitRowInterface, defaultValueEmitRowInterface)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var emitValidate bool
+	emitValidate, err = getOptOrEnvOrDefaultBool(optNameEmitValidate, *optValueEmitValidate, envNameEmitValidate, defaultValueEmitValidate)
+	if err != nil {
+		return generator.Options{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	return generator.Options{
+		AnnotateNullable:      annotateNullable,
+		AnnotateUTC:           annotateUTC,
+		AvroTags:              avroTags,
+		BSONTags:              bsonTags,
+		BSONIDColumn:          bsonIDColumn,
+		DatasetProject:        datasetProject,
+		Debug:                 debug,
+		EmbedPatterns:         embedPatterns,
+		EmitAllColumns:        emitAllColumns,
+		EmitBQTypeComment:     emitBQTypeComment,
+		EmitClustered:         emitClustered,
+		EmitColumnMeta:        emitColumnMeta,
+		EmitCompareSchema:     emitCompareSchema,
+		EmitConsoleLinks:      emitConsoleLinks,
+		EmitFieldTypes:        emitFieldTypes,
+		EmitGeneratedFrom:     emitGeneratedFrom,
+		EmitInUTC:             emitInUTC,
+		EmitInserter:          emitInserter,
+		EmitModeTags:          emitModeTags,
+		EmitOrdinal:           emitOrdinal,
+		EmitRowInterface:      emitRowInterface,
+		EmitSelect:            emitSelect,
+		EmitStructID:          emitStructID,
+		EmitTypeRegistry:      emitTypeRegistry,
+		EmitValidate:          emitValidate,
+		EmitValueMap:          emitValueMap,
+		EmitVersion:           emitVersion,
+		EmitViewQuery:         emitViewQuery,
+		FirestoreTags:         firestoreTags,
+		ViewQueryMaxLines:     viewQueryMaxLines,
+		ExtraImports:          extraImports,
+		FailOnUnsupported:     failOnUnsupported,
+		FieldGroup:            fieldGroup,
+		GeneratedAt:           generatedAt,
+		GormTags:              gormTags,
+		Immutable:             immutable,
+		InitNumericZero:       initNumericZero,
+		Implements:            implements,
+		Initialisms:           initialisms,
+		MaxNameLength:         maxNameLength,
+		NestedNameTemplate:    nestedNameTemplate,
+		NoGoimports:           noGoimports,
+		NameExceptions:        nameExceptions,
+		NullTypes:             nullTypes,
+		NullTypesFor:          nullTypesFor,
+		ReceiverStyle:         receiverStyle,
+		RegisterFunc:          registerFunc,
+		SpannerTags:           spannerTags,
+		StrictCase:            strictCase,
+		TableOverrides:        tableOverrides,
+		ExcludeAsIgnored:      excludeAsIgnored,
+		TypeAliases:           typeAliases,
+		TypeOverrides:         typeOverrides,
+		NullableTypeOverrides: nullableTypeOverrides,
+		UnsupportedAsAny:      unsupportedAsAny,
+		YAMLTags:              yamlTags,
+	}, nil
+}
+
+// outputFiles is the files the command generates, and how it writes them.
+// The file of paths[i] is generated in formats[i].
+type outputFiles struct {
+	formats []string
+	paths   []string
+	// tableOutputs is the files of -table-output keyed by table ID, whose tables are routed from mainGoPath.
+	tableOutputs map[string]string
+	mainGoPath   string
+	// helpersSources and fakeSources are the Go files the helpers and the fakes are generated for,
+	// keyed by the paths of the helpers and the fakes.
+	helpersSources     map[string]string
+	fakeSources        map[string]string
+	emitProtoNumbers   bool
+	protoNumbersFile   string
+	modifiedSince      time.Time
+	modifiedSincePaths []string
+	prune              bool
+	pruneDir           string
+	lineEnding         string
+	typecheck          bool
+	check              bool
+	checkChangelog     string
+	interactive        bool
+	yes                bool
+	archive            string
+	archiveFormat      string
+}
+
+// getOutputFiles returns the outputFiles of the options. limit is the one of the schemaSource.
+func getOutputFiles(limit int) (outputs outputFiles, err error) {
+	var filePath string
+	filePath, err = getOptOrEnvOrDefault(optNameOutputFile, *optValueOutputPath, envNameOutputFile, defaultValueOutputFile)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+
+	var check bool
+	check, err = getOptOrEnvOrDefaultBool(optNameCheck, *optValueCheck, envNameCheck, defaultValueCheck)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	checkChangelog := getOptOrEnv(optNameCheckChangelog, *optValueCheckChangelog, envNameCheckChangelog)
+
+	var interactive bool
+	interactive, err = getOptOrEnvOrDefaultBool(optNameInteractive, *optValueInteractive, envNameInteractive, defaultValueInteractive)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	outputArchive := getOptOrEnv(optNameOutputArchive, *optValueOutputArchive, envNameOutputArchive)
+	var archiveFmt string
+	if outputArchive != "" {
+		// Both compare the generated files with the ones in the filesystem
+		if check || interactive {
+			return outputFiles{}, fmt.Errorf("-%s cannot be used with -%s or -%s", optNameOutputArchive, optNameCheck, optNameInteractive)
+		}
+		archiveFmt, err = archiveFormat(outputArchive)
+		if err != nil {
+			return outputFiles{}, fmt.Errorf("archiveFormat: %w", err)
+		}
+	}
+
+	var yes bool
+	yes, err = getOptOrEnvOrDefaultBool(optNameYes, *optValueYes, envNameYes, defaultValueYes)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	var lineEnding string
+	lineEnding, err = getOptOrEnvOrDefault(optNameLineEnding, *optValueLineEnding, envNameLineEnding, defaultValueLineEnding)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	if lineEnding != lineEndingLF && lineEnding != lineEndingCRLF {
+		return outputFiles{}, fmt.Errorf("-%s=%s is not supported. supported: %s, %s", optNameLineEnding, lineEnding, lineEndingLF, lineEndingCRLF)
+	}
+
+	var outputFormat string
+	outputFormat, err = getOptOrEnvOrDefault(optNameOutputFormat, *optValueOutputFormat, envNameOutputFormat, defaultValueOutputFormat)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	var outputFormats []string
+	for _, format := range strings.Split(outputFormat, ",") {
+		format = strings.TrimSpace(format)
+		if format != generator.OutputFormatGo && format != generator.OutputFormatProto {
+			return outputFiles{}, fmt.Errorf("-%s=%s is not supported. supported: %s, %s", optNameOutputFormat, format, generator.OutputFormatGo, generator.OutputFormatProto)
+		}
+		outputFormats = append(outputFormats, format)
+	}
+	// One output path per output format
+	filePaths := strings.Split(filePath, ",")
+	if len(filePaths) != len(outputFormats) {
+		return outputFiles{}, fmt.Errorf("-%s=%s and -%s=%s must have the same number of comma-separated values", optNameOutputFormat, outputFormat, optNameOutputFile, filePath)
+	}
+
+	var tableOutputs map[string]string
+	tableOutputs, err = parseTableOutputs(getOptOrEnv(optNameTableOutput, optValueTableOutput.String(), envNameTableOutput))
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("parseTableOutputs: %w", err)
+	}
+	var mainGoPath string
+	if len(tableOutputs) > 0 {
+		var routedPaths []string
+		mainGoPath, routedPaths, err = tableOutputPaths(outputFormats, filePaths, tableOutputs)
+		if err != nil {
+			return outputFiles{}, fmt.Errorf("tableOutputPaths: %w", err)
+		}
+		for _, routedPath := range routedPaths {
+			outputFormats = append(outputFormats, generator.OutputFormatGo)
+			filePaths = append(filePaths, routedPath)
+		}
+	}
+
+	var prune bool
+	prune, err = getOptOrEnvOrDefaultBool(optNamePrune, *optValuePrune, envNamePrune, defaultValuePrune)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	var pruneDir string
+	if prune {
+		// The tables not generated are treated as removed, so all the tables must be generated.
+		if limit > 0 {
+			return outputFiles{}, fmt.Errorf("-%s cannot be used with -%s", optNamePrune, optNameLimit)
+		}
+		if outputArchive != "" {
+			return outputFiles{}, fmt.Errorf("-%s cannot be used with -%s", optNamePrune, optNameOutputArchive)
+		}
+		for i, format := range outputFormats {
+			if format == generator.OutputFormatGo {
+				pruneDir = filepath.Dir(filePaths[i])
+				break
+			}
+		}
+		if pruneDir == "" {
+			return outputFiles{}, fmt.Errorf("-%s needs an output of -%s=%s", optNamePrune, optNameOutputFormat, generator.OutputFormatGo)
+		}
+	}
+
+	var modifiedSince time.Time
+	modifiedSince, err = parseModifiedSince(getOptOrEnv(optNameModifiedSince, *optValueModifiedSince, envNameModifiedSince))
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("parseModifiedSince: %w", err)
+	}
+	var modifiedSincePaths []string
+	if !modifiedSince.IsZero() {
+		for i, format := range outputFormats {
+			if format == generator.OutputFormatGo {
+				modifiedSincePaths = append(modifiedSincePaths, filePaths[i])
+			}
+		}
+		if len(modifiedSincePaths) == 0 {
+			return outputFiles{}, fmt.Errorf("-%s needs an output of -%s=%s", optNameModifiedSince, optNameOutputFormat, generator.OutputFormatGo)
+		}
+	}
+
+	var splitHelpers bool
+	splitHelpers, err = getOptOrEnvOrDefaultBool(optNameSplitHelpers, *optValueSplitHelpers, envNameSplitHelpers, defaultValueSplitHelpers)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	// The helpers are split from each Go file after generating it, into a separate file next to it
+	helpersSources := make(map[string]string)
+	if splitHelpers {
+		// The code of a table is split into the two files, which -modified-since cannot put together
+		if !modifiedSince.IsZero() {
+			return outputFiles{}, fmt.Errorf("-%s cannot be used with -%s", optNameSplitHelpers, optNameModifiedSince)
+		}
+		for i, format := range outputFormats {
+			if format == generator.OutputFormatGo {
+				outputFormats = append(outputFormats, outputFormatGoHelpers)
+				filePaths = append(filePaths, helpersFilePath(filePaths[i]))
+				helpersSources[helpersFilePath(filePaths[i])] = filePaths[i]
+			}
+		}
+	}
+
+	var emitFakes bool
+	emitFakes, err = getOptOrEnvOrDefaultBool(optNameEmitFakes, *optValueEmitFakes, envNameEmitFakes, defaultValueEmitFakes)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	// The fakes are generated into a separate file next to each Go file
+	fakeSources := make(map[string]string)
+	if emitFakes {
+		for i, format := range outputFormats {
+			if format == generator.OutputFormatGo {
+				outputFormats = append(outputFormats, generator.OutputFormatGoFake)
+				filePaths = append(filePaths, fakeFilePath(filePaths[i]))
+				fakeSources[fakeFilePath(filePaths[i])] = filePaths[i]
+			}
+		}
+	}
+
+	var emitProtoNumbers bool
+	emitProtoNumbers, err = getOptOrEnvOrDefaultBool(optNameEmitProtoNumbers, *optValueEmitProtoNumbers, envNameEmitProtoNumbers, defaultValueEmitProtoNumbers)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+	var protoNumbersFile string
+	protoNumbersFile, err = getOptOrEnvOrDefault(optNameProtoNumbersFile, *optValueProtoNumbersFile, envNameProtoNumbersFile, defaultValueProtoNumbersFile)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	}
+	// The assignments are written with the generated files, so that they are checked and written together
+	if emitProtoNumbers {
+		outputFormats = append(outputFormats, outputFormatProtoNumbers)
+		filePaths = append(filePaths, protoNumbersFile)
+	}
+
+	var typecheck bool
+	typecheck, err = getOptOrEnvOrDefaultBool(optNameTypecheck, *optValueTypecheck, envNameTypecheck, defaultValueTypecheck)
+	if err != nil {
+		return outputFiles{}, fmt.Errorf("getOptOrEnvOrDefaultBool: %w", err)
+	}
+
+	return outputFiles{
+		formats:            outputFormats,
+		paths:              filePaths,
+		tableOutputs:       tableOutputs,
+		mainGoPath:         mainGoPath,
+		helpersSources:     helpersSources,
+		fakeSources:        fakeSources,
+		emitProtoNumbers:   emitProtoNumbers,
+		protoNumbersFile:   protoNumbersFile,
+		modifiedSince:      modifiedSince,
+		modifiedSincePaths: modifiedSincePaths,
+		prune:              prune,
+		pruneDir:           pruneDir,
+		lineEnding:         lineEnding,
+		typecheck:          typecheck,
+		check:              check,
+		checkChangelog:     checkChangelog,
+		interactive:        interactive,
+		yes:                yes,
+		archive:            outputArchive,
+		archiveFormat:      archiveFmt,
+	}, nil
+}
+
+// newClientOptions returns the options of the BigQuery client, and the ones of the Cloud Storage client.
+func newClientOptions(ctx context.Context, location string) (clientOpts, storageClientOpts []option.ClientOption, err error) {
+	if tokenCache := getOptOrEnv(optNameTokenCache, *optValueTokenCache, envNameTokenCache); tokenCache != "" {
+		var tokenSource oauth2.TokenSource
+		tokenSource, err = newCachedTokenSource(ctx, tokenCache)
+		if err != nil {
+			return nil, nil, fmt.Errorf("newCachedTokenSource: %w", err)
+		}
+		clientOpts = append(clientOpts, option.WithTokenSource(tokenSource))
+	}
+	// The endpoint below is for BigQuery, so the storage client uses only the credentials.
+	storageClientOpts = clientOpts
+
+	endpoint := getOptOrEnv(optNameEndpoint, *optValueEndpoint, envNameEndpoint)
+	if endpoint == "" {
+		endpoint = regionalEndpoint(location)
+		if endpoint != "" {
+			infoln("use regional endpoint: " + endpoint)
+		}
+	}
+	if endpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(endpoint))
+	}
+
+	return clientOpts, storageClientOpts, nil
+}
+
+// readTableSchemas reads the schemas of the tables from src. datasetProject is the one of generator.Options, and
+// schemaProject is the project of the tables of the schema files.
+func readTableSchemas(ctx context.Context, src schemaSource, datasetProject, schemaProject string) (schemas []generator.TableSchema, err error) {
+	switch {
+	case src.schemaDir != "":
+		// Offline, so no client is created.
+		schemas, err = getTableSchemasFromDir(src.schemaDir, schemaProject, src.dataset)
+		if err != nil {
+			return nil, fmt.Errorf("getTableSchemasFromDir: %w", err)
+		}
+	case src.gcsSchemas != "":
+		var storageClientOpts []option.ClientOption
+		_, storageClientOpts, err = newClientOptions(ctx, src.location)
+		if err != nil {
+			return nil, fmt.Errorf("newClientOptions: %w", err)
+		}
+		var storageClient *storage.Client
+		storageClient, err = storage.NewClient(ctx, storageClientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("storage.NewClient: %w", err)
+		}
+		defer func() {
+			if closeErr := storageClient.Close(); closeErr != nil {
+				warnln("storageClient.Close: " + closeErr.Error())
+			}
+		}()
+
+		schemas, err = getTableSchemasFromGCS(ctx, storageClient, src.gcsSchemas, schemaProject, src.dataset)
+		if err != nil {
+			return nil, fmt.Errorf("getTableSchemasFromGCS: %w", err)
+		}
+	default:
+		var clientOpts []option.ClientOption
+		clientOpts, _, err = newClientOptions(ctx, src.location)
+		if err != nil {
+			return nil, fmt.Errorf("newClientOptions: %w", err)
+		}
+		var client *bigquery.Client
+		client, err = bigquery.NewClient(ctx, src.project, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("bigquery.NewClient: %w", err)
+		}
+		defer func() {
+			if closeErr := client.Close(); closeErr != nil {
+				warnln("client.Close: " + closeErr.Error())
+			}
+		}()
+		client.Location = src.location
+
+		if src.query != "" {
+			schemas, err = getQuerySchemas(ctx, client, src.query, src.queryName, src.queryParams)
+			if err != nil {
+				return nil, fmt.Errorf("getQuerySchemas: %w", err)
+			}
+			break
+		}
+
+		var tables []*bigquery.Table
+		switch {
+		case src.fromTempTable != "":
+			var projectID, datasetID, tableID string
+			projectID, datasetID, tableID, err = parseTempTableReference(src.fromTempTable, src.project)
+			if err != nil {
+				return nil, fmt.Errorf("parseTempTableReference: %w", err)
+			}
+			tables = []*bigquery.Table{client.DatasetInProject(projectID, datasetID).Table(tableID)}
+		case src.tablesFile != "":
+			tables, err = readTablesFile(client, src.tablesFile)
+			if err != nil {
+				return nil, fmt.Errorf("readTablesFile: %w", err)
+			}
+		case src.discover == discoverInformationSchema:
+			tables, err = getTablesFromInformationSchema(ctx, client, datasetProject, src.dataset, src.location, src.discoverFilter)
+			if err != nil {
+				return nil, fmt.Errorf("getTablesFromInformationSchema: %w", err)
+			}
+		default:
+			tables, err = getAllTablesWithRetry(ctx, client, datasetProject, src.dataset, src.listTimeout, src.listRetries)
+			if err != nil {
+				return nil, fmt.Errorf("getAllTablesWithRetry: %w", err)
+			}
+		}
+
+		// Limit before fetching the metadata, which takes most of the time.
+		// Tables skipped later (e.g. for unsupported types) count toward the limit.
+		if src.limit > 0 && len(tables) > src.limit {
+			infoln(fmt.Sprintf("-%s=%d: generating %d of %d tables", optNameLimit, src.limit, src.limit, len(tables)))
+			tables = tables[:src.limit]
+		}
+
+		schemas, err = generator.GetTableSchemas(ctx, tables)
+		if err != nil {
+			return nil, fmt.Errorf("generator.GetTableSchemas: %w", err)
+		}
+	}
+	// The schema files are read all at once, so limit after reading them.
+	if src.limit > 0 && len(schemas) > src.limit {
+		infoln(fmt.Sprintf("-%s=%d: generating %d of %d tables", optNameLimit, src.limit, src.limit, len(schemas)))
+		schemas = schemas[:src.limit]
+	}
+
+	return schemas, nil
+}
+
+// withoutMissingTableOutputs returns the formats and the paths of the outputs without the files of -table-output
+// all of whose tables are not in tableIDs, and without their helpers and fakes.
+func withoutMissingTableOutputs(outputs outputFiles, tableIDs map[string]bool) (formats, paths []string) {
+	missingPaths := missingTableOutputPaths(outputs.tableOutputs, tableIDs)
+	for i, format := range outputs.formats {
+		sourcePath := outputs.paths[i]
+		if format == generator.OutputFormatGoFake {
+			sourcePath = outputs.fakeSources[outputs.paths[i]]
+		}
+		if format == outputFormatGoHelpers {
+			sourcePath = outputs.helpersSources[outputs.paths[i]]
+		}
+		if missingPaths[sourcePath] {
+			continue
+		}
+		formats = append(formats, format)
+		paths = append(paths, outputs.paths[i])
+	}
+	return formats, paths
+}
+
+// generateOutputs generates the code of each of the outputs from the schemas. The code of the proto numbers file is
+// protoNumbersCode as it is.
+func generateOutputs(schemas []generator.TableSchema, opts generator.Options, outputs outputFiles, protoNumbersCode []byte) (generatedCodes [][]byte, err error) {
+	// Generate all the files before writing any of them,
+	// so that an error in a later format leaves the output files untouched.
+	generatedCodes = make([][]byte, len(outputs.formats))
+	var goFiles map[string][]byte
+	for i, format := range outputs.formats {
+		formatOpts := opts
+		formatOpts.OutputFormat = format
+
+		switch {
+		case format == outputFormatProtoNumbers:
+			generatedCodes[i] = protoNumbersCode
+		case format == outputFormatGoHelpers:
+			// Split from the Go file below
+		// The main and the routed Go files are generated at once, for the package-level declarations
+		case format == generator.OutputFormatGo && len(outputs.tableOutputs) > 0:
+			if goFiles == nil {
+				goFiles, err = generator.GenerateGoFiles(schemas, outputs.mainGoPath, outputs.tableOutputs, formatOpts)
+				if err != nil {
+					return nil, fmt.Errorf("generator.GenerateGoFiles: %w", err)
+				}
+			}
+			generatedCodes[i] = goFiles[outputs.paths[i]]
+		case format == generator.OutputFormatGoFake && len(outputs.tableOutputs) > 0:
+			generatedCodes[i], err = generator.GenerateCode(tableOutputSchemas(schemas, outputs.fakeSources[outputs.paths[i]], outputs.mainGoPath, outputs.tableOutputs), formatOpts)
+			if err != nil {
+				return nil, fmt.Errorf("generator.GenerateCode: format=%s: %w", format, err)
+			}
+		default:
+			generatedCodes[i], err = generator.GenerateCode(schemas, formatOpts)
+			if err != nil {
+				return nil, fmt.Errorf("generator.GenerateCode: format=%s: %w", format, err)
+			}
+		}
+	}
+
+	if len(outputs.helpersSources) > 0 {
+		fileIndexes := make(map[string]int, len(outputs.paths))
+		for i, filePath := range outputs.paths {
+			fileIndexes[filePath] = i
+		}
+		for i, format := range outputs.formats {
+			if format != outputFormatGoHelpers {
+				continue
+			}
+			source := fileIndexes[outputs.helpersSources[outputs.paths[i]]]
+			generatedCodes[source], generatedCodes[i], err = generator.SplitHelpersCode(generatedCodes[source], opts)
+			if err != nil {
+				return nil, fmt.Errorf("generator.SplitHelpersCode: %s: %w", outputs.paths[source], err)
+			}
+		}
+	}
+
+	// Type-check after generating all the files, so that the generated files refer to each other
+	// instead of the ones in the directory.
+	if outputs.typecheck {
+		goCodes := make(map[string][]byte)
+		for i, format := range outputs.formats {
+			if format == generator.OutputFormatGo || format == generator.OutputFormatGoFake || format == outputFormatGoHelpers {
+				goCodes[outputs.paths[i]] = generatedCodes[i]
+			}
+		}
+		for i, format := range outputs.formats {
+			if format != generator.OutputFormatGo {
+				continue
+			}
+			if err = typecheckGoCode(outputs.paths[i], generatedCodes[i], goCodes); err != nil {
+				return nil, fmt.Errorf("typecheckGoCode: %w", err)
+			}
+		}
+	}
+
+	for i, format := range outputs.formats {
+		generatedCodes[i] = ensureTrailingNewline(generatedCodes[i])
+		if format != outputFormatProtoNumbers {
+			generatedCodes[i] = convertLineEnding(generatedCodes[i], outputs.lineEnding)
+		}
+	}
+
+	return generatedCodes, nil
+}
+
+// checkOutputs checks that the output files are up to date with the generated codes, and writes the changelog of
+// -check-changelog.
+func checkOutputs(outputs outputFiles, generatedCodes [][]byte) (err error) {
+	// The changelog has the changes of all the Go outputs, so it is written before any check fails.
+	if outputs.checkChangelog != "" {
+		var goPaths []string
+		var goCodes [][]byte
+		for i, format := range outputs.formats {
+			if format == generator.OutputFormatGo {
+				goPaths = append(goPaths, outputs.paths[i])
+				goCodes = append(goCodes, generatedCodes[i])
+			}
+		}
+		if err = writeSchemaChangelog(outputs.checkChangelog, goPaths, goCodes); err != nil {
+			return fmt.Errorf("writeSchemaChangelog: %w", err)
+		}
+	}
+
+	return checkGeneratedCodes(outputs.paths, generatedCodes, outputs.formats)
+}
+
+// writeOutputs writes the generated codes to the output files, or into the archive of -output-archive.
+func writeOutputs(outputs outputFiles, generatedCodes [][]byte) (err error) {
+	var archivePaths []string
+	var archiveContents [][]byte
+	for i, format := range outputs.formats {
+		// The field numbers are the state of the next run, so they stay in the filesystem.
+		if outputs.archive != "" && format != outputFormatProtoNumbers {
+			archivePaths = append(archivePaths, outputs.paths[i])
+			archiveContents = append(archiveContents, generatedCodes[i])
+			continue
+		}
+
+		// NOTE(ginokent): output
+		if err = writeFileAtomic(outputs.paths[i], generatedCodes[i], 0644); err != nil {
+			return fmt.Errorf("writeFileAtomic: %w", err)
+		}
+	}
+
+	if outputs.archive != "" {
+		var archive []byte
+		archive, err = generateArchive(outputs.archiveFormat, archivePaths, archiveContents)
+		if err != nil {
+			return fmt.Errorf("generateArchive: %w", err)
+		}
+		if err = writeFileAtomic(outputs.archive, archive, 0644); err != nil {
+			return fmt.Errorf("writeFileAtomic: %w", err)
+		}
+	}
 
-	ctx := context.Background()
+	return nil
+}
 
-	if err := Run(ctx); err != nil {
-		errorln("Run: " + err.Error())
-		exit(1)
+// pruneOutputs removes the stale generated files next to the outputs. With -check, it fails if any would be removed
+// instead.
+func pruneOutputs(outputs outputFiles, schemaProject, dataset string, tableIDs map[string]bool) (err error) {
+	keep := make(map[string]bool)
+	for _, filePath := range outputs.paths {
+		keep[filepath.Clean(filePath)] = true
 	}
+	var stale []string
+	stale, err = staleGeneratedFiles(outputs.pruneDir, schemaProject, dataset, tableIDs, keep)
+	if err != nil {
+		return fmt.Errorf("staleGeneratedFiles: %w", err)
+	}
+	if outputs.check {
+		if len(stale) > 0 {
+			return fmt.Errorf("-%s: %s would be removed", optNamePrune, strings.Join(stale, ", "))
+		}
+		return nil
+	}
+	if err = removeFiles(stale); err != nil {
+		return fmt.Errorf("removeFiles: %w", err)
+	}
+	return nil
 }
 
-// Run is effectively a `main` function.
-// It is separated from the `main` function because of addressing an issue where` defer` is not executed when `os.Exit` is executed.
-func Run(ctx context.Context) (err error) {
-	flag.Parse()
+// convertLineEnding converts the line endings of the formatted code to lineEnding.
+func convertLineEnding(code []byte, lineEnding string) (converted []byte) {
+	if lineEnding != lineEndingCRLF {
+		return code
+	}
+	// Normalize first so that existing CRLF are not doubled
+	lf := bytes.ReplaceAll(code, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+}
 
-	var project string
-	project, err = getOptOrEnvOrDefault(optNameProjectID, *optValueProjectID, envNameGCloudProjectID, "")
-	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+// ensureTrailingNewline returns the code that ends with exactly one newline.
+// format.Source keeps the trailing newlines of the source as they are.
+func ensureTrailingNewline(code []byte) (ensured []byte) {
+	trimmed := bytes.TrimRight(code, "\r\n")
+	ensured = make([]byte, len(trimmed), len(trimmed)+1)
+	copy(ensured, trimmed)
+	return append(ensured, '\n')
+}
+
+var errNotConfirmed = errors.New("input is not a terminal. use -" + optNameYes + " to confirm")
+
+// confirmWrite prints a summary of the changes to the output files to out, and asks for confirmation on in.
+// If yes is true, it returns true without asking. If in is not a terminal, it returns errNotConfirmed instead of asking.
+func confirmWrite(in io.Reader, out io.Writer, terminal, yes bool, filePaths, formats []string, generatedCodes [][]byte) (confirmed bool, err error) {
+	for i, filePath := range filePaths {
+		var summary string
+		summary, err = writeSummary(filePath, formats[i], generatedCodes[i])
+		if err != nil {
+			return false, fmt.Errorf("writeSummary: %w", err)
+		}
+		fmt.Fprint(out, summary)
 	}
 
-	var dataset string
-	dataset, err = getOptOrEnvOrDefault(optNameDataset, *optValueDataset, envNameBigQueryDataset, "")
-	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+	if yes {
+		return true, nil
+	}
+	if !terminal {
+		return false, errNotConfirmed
 	}
 
-	var filePath string
-	filePath, err = getOptOrEnvOrDefault(optNameOutputFile, *optValueOutputPath, envNameOutputFile, defaultValueOutputFile)
+	fmt.Fprint(out, "write the files? [y/N]: ")
+	var answer string
+	answer, err = bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("ReadString: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// writeSummary returns a summary of the changes generatedCode makes to the file at filePath.
+// The structs created, changed and removed are listed only for generator.OutputFormatGo.
+func writeSummary(filePath, format string, generatedCode []byte) (summary string, err error) {
+	var current []byte
+	current, err = readFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return filePath + ": new file\n", nil
+	}
 	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		return "", fmt.Errorf("readFile: %w", err)
 	}
 
-	var debugString string
-	debugString, err = getOptOrEnvOrDefault(optNameDebug, *optValueOutputPath, envNameDebug, defaultValueDebug)
+	if bytes.Equal(current, generatedCode) {
+		return filePath + ": up to date\n", nil
+	}
+
+	if format != generator.OutputFormatGo {
+		return filePath + ": changed\n", nil
+	}
+
+	var created, changed, removed []string
+	created, changed, removed, err = structSummary(current, generatedCode)
 	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		return "", fmt.Errorf("structSummary: %w", err)
 	}
-	debug, _ := strconv.ParseBool(debugString)
 
-	client, err := bigquery.NewClient(ctx, project)
+	summary = filePath + ": changed\n"
+	for _, name := range created {
+		summary = summary + "\t+ " + name + "\n"
+	}
+	for _, name := range changed {
+		summary = summary + "\t~ " + name + "\n"
+	}
+	for _, name := range removed {
+		summary = summary + "\t- " + name + "\n"
+	}
+
+	return summary, nil
+}
+
+// isTerminal returns true if file is a character device, e.g. a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("bigquery.NewClient: %w", err)
+		return false
 	}
-	defer func() {
-		if closeErr := client.Close(); closeErr != nil {
-			warnln("client.Close: " + closeErr.Error())
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeSchemaChangelog writes the column changes between the file at each of filePaths and the generatedCodes of it
+// to changelogPath as a JSON array of the files.
+func writeSchemaChangelog(changelogPath string, filePaths []string, generatedCodes [][]byte) (err error) {
+	files := make([]schemaChangelogFile, 0, len(filePaths))
+	for i, filePath := range filePaths {
+		var current []byte
+		current, err = readFile(filePath)
+		if err != nil {
+			return fmt.Errorf("readFile: %w", err)
 		}
-	}()
 
-	generatedCode, err := Generate(ctx, client, dataset, debug)
+		var changes []structChange
+		changes, err = schemaChangelog(current, generatedCodes[i])
+		if err != nil {
+			return fmt.Errorf("schemaChangelog: %s: %w", filePath, err)
+		}
+		files = append(files, schemaChangelogFile{File: filePath, Structs: changes})
+	}
+
+	var changelog []byte
+	changelog, err = json.MarshalIndent(files, "", "  ")
 	if err != nil {
-		return fmt.Errorf("Generate: %w", err)
+		return fmt.Errorf("json.MarshalIndent: %w", err)
 	}
 
-	// NOTE(ginokent): output
-	if err = ioutil.WriteFile(filePath, generatedCode, 0644); err != nil {
-		return fmt.Errorf("ioutil.WriteFile: %w", err)
+	if err = writeFileAtomic(changelogPath, append(changelog, '\n'), 0644); err != nil {
+		return fmt.Errorf("writeFileAtomic: %w", err)
 	}
 
 	return nil
 }
 
-func Generate(ctx context.Context, client *bigquery.Client, dataset string, debug bool) (generatedCode []byte, err error) {
+// checkGeneratedCodes checks every output by checkGeneratedCode, and returns an error with the diffs of all the outputs
+// that are not up to date. The error wraps errColumnsRetyped if columns have changed type in any of them.
+func checkGeneratedCodes(filePaths []string, generatedCodes [][]byte, formats []string) (err error) {
+	var errs []error
+	var retyped bool
+	for i, format := range formats {
+		if checkErr := checkGeneratedCode(filePaths[i], generatedCodes[i], format); checkErr != nil {
+			errs = append(errs, checkErr)
+			retyped = retyped || errors.Is(checkErr, errColumnsRetyped)
+		}
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(errs) == 1:
+		return errs[0]
+	}
 
-	const head = `// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.
+	messages := make([]string, 0, len(errs))
+	for _, checkErr := range errs {
+		messages = append(messages, checkErr.Error())
+	}
+	if retyped {
+		return fmt.Errorf("%d files are not up to date, and %w:\n%s", len(errs), errColumnsRetyped, strings.Join(messages, "\n"))
+	}
+	return fmt.Errorf("%d files are not up to date:\n%s", len(errs), strings.Join(messages, "\n"))
+}
 
-//go:generate go run github.com/ginokent/bqschema-gen-go
+// checkGeneratedCode returns an error containing a diff of the struct definitions
+// if the file at filePath differs from generatedCode.
+// The diff is only available for generator.OutputFormatGo.
+func checkGeneratedCode(filePath string, generatedCode []byte, format string) (err error) {
+	var current []byte
+	current, err = readFile(filePath)
+	if err != nil {
+		return fmt.Errorf("readFile: %w", err)
+	}
 
-package bqschema
+	if bytes.Equal(current, generatedCode) {
+		infoln(filePath + " is up to date")
+		return nil
+	}
 
-`
+	if format != generator.OutputFormatGo {
+		return fmt.Errorf("%s is not up to date", filePath)
+	}
 
-	tables, err := getAllTables(ctx, client, dataset)
+	var diff string
+	diff, err = diffStructs(current, generatedCode)
 	if err != nil {
-		return nil, fmt.Errorf("getAllTables: %w", err)
+		return fmt.Errorf("diffStructs: %w", err)
+	}
+	if diff == "" {
+		diff = "(no differences in struct definitions)\n"
 	}
 
-	var tail string
-	var importPackages []string
-	for _, table := range tables {
-		var structCode string
-		var pkgs []string
-		structCode, pkgs, err = generateTableSchemaCode(ctx, table)
-		if err != nil {
-			warnln("generateTableSchemaCode: " + err.Error())
-			continue
+	var changes []structChange
+	changes, err = schemaChangelog(current, generatedCode)
+	if err != nil {
+		return fmt.Errorf("schemaChangelog: %w", err)
+	}
+	if retyped := retypedColumns(changes); retyped != "" {
+		return fmt.Errorf("%s is not up to date: %w:\n%s--- %s\n+++ %s (generated)\n%s", filePath, errColumnsRetyped, retyped, filePath, filePath, diff)
+	}
+
+	return fmt.Errorf("%s is not up to date:\n--- %s\n+++ %s (generated)\n%s", filePath, filePath, filePath, diff)
+}
+
+// parseEmbedPatterns parses a comma-separated list of `[BIGQUERY_TYPE:]glob`.
+func parseEmbedPatterns(s string) (embedPatterns []generator.EmbedPattern, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+
+		var embedPattern generator.EmbedPattern
+		if idx := strings.Index(p, ":"); idx >= 0 {
+			embedPattern = generator.EmbedPattern{Type: generator.NormalizeFieldType(bigquery.FieldType(strings.ToUpper(p[:idx]))), Pattern: p[idx+1:]}
+		} else {
+			embedPattern = generator.EmbedPattern{Pattern: p}
 		}
 
-		if len(pkgs) > 0 {
-			importPackages = append(importPackages, pkgs...)
+		if embedPattern.Pattern == "" {
+			return nil, fmt.Errorf("invalid embed pattern `%s`. format: [BIGQUERY_TYPE:]glob", p)
+		}
+		if _, err = path.Match(embedPattern.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("path.Match: %s: %w", embedPattern.Pattern, err)
 		}
-		tail = tail + structCode
+
+		embedPatterns = append(embedPatterns, embedPattern)
 	}
 
-	importCode := generateImportPackagesCode(importPackages)
+	return embedPatterns, nil
+}
+
+// listRetryInitialBackoff is the wait before the first retry of getAllTablesWithRetry, which doubles on each retry.
+var listRetryInitialBackoff = time.Second
+
+// getAllTablesWithRetry is the same as generator.GetAllTables, but times out each attempt after timeout, and retries the listing
+// from the beginning up to retries times on retryable errors. A zero timeout disables the timeout.
+func getAllTablesWithRetry(ctx context.Context, client *bigquery.Client, projectID, datasetID string, timeout time.Duration, retries int) (tables []*bigquery.Table, err error) {
+	backoff := listRetryInitialBackoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		tables, err = generator.GetAllTables(attemptCtx, client, projectID, datasetID)
+		cancel()
+		if err == nil {
+			return tables, nil
+		}
+		if attempt >= retries || !isRetryableError(ctx, err) {
+			return nil, fmt.Errorf("generator.GetAllTables: %w", err)
+		}
+
+		warnln(fmt.Sprintf("generator.GetAllTables: %v. retrying in %s (%d/%d)", err, backoff, attempt+1, retries))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("ctx.Done: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
 
-	// NOTE(ginokent): combine
-	code := head + importCode + tail
+// isRetryableError returns true if err is a timeout of an attempt, a rate limit, a server error or a broken connection.
+// It returns false if ctx itself is done.
+func isRetryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+	}
+	return false
+}
 
-	if debug {
-		fmt.Println(">>>> DEBUG >>>>>>>>>>>>>>>>")
-		fmt.Println(code)
-		fmt.Println("<<<< DEBUG <<<<<<<<<<<<<<<<")
+// getTablesFromInformationSchema is the same as generator.GetAllTables, but discovers the tables by a query to INFORMATION_SCHEMA.TABLES.
+// The view is region-qualified if location is set, otherwise dataset-qualified.
+// filter is a SQL predicate on the columns of the view to select the tables. e.g. `table_name LIKE 'events_%'`
+func getTablesFromInformationSchema(ctx context.Context, client *bigquery.Client, projectID, datasetID, location, filter string) (tables []*bigquery.Table, err error) {
+	ds := client.Dataset(datasetID)
+	if projectID != "" {
+		ds = client.DatasetInProject(projectID, datasetID)
 	}
 
-	gen := []byte(code)
+	q := client.Query(informationSchemaTablesQuery(projectID, datasetID, location, filter))
+	q.Parameters = []bigquery.QueryParameter{{Name: "dataset", Value: datasetID}}
 
-	genFmt, err := format.Source(gen)
+	var it *bigquery.RowIterator
+	it, err = q.Read(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("format.Source: %w", err)
+		return nil, fmt.Errorf("q.Read: %w", err)
 	}
 
-	if debug {
-		fmt.Println(">>>> DEBUG >>>>>>>>>>>>>>>>")
-		fmt.Println(string(genFmt))
-		fmt.Println("<<<< DEBUG <<<<<<<<<<<<<<<<")
+	for {
+		var row struct {
+			TableName string `bigquery:"table_name"`
+		}
+		err = it.Next(&row)
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("it.Next: %w", err)
+		}
+		tables = append(tables, ds.Table(row.TableName))
 	}
 
-	genImports, err := imports.Process("", genFmt, nil)
-	if err != nil {
-		return nil, fmt.Errorf("imports.Process: %w", err)
+	return tables, nil
+}
+
+func informationSchemaTablesQuery(projectID, datasetID, location, filter string) (query string) {
+	view := "`" + datasetID + "`.INFORMATION_SCHEMA.TABLES"
+	if location != "" {
+		view = "`region-" + strings.ToLower(location) + "`.INFORMATION_SCHEMA.TABLES"
+	}
+	if projectID != "" {
+		view = "`" + projectID + "`." + view
 	}
 
-	return genImports, nil
+	query = "SELECT table_name FROM " + view + " WHERE table_schema = @dataset"
+	if filter != "" {
+		query = query + " AND (" + filter + ")"
+	}
+	return query + " ORDER BY table_name"
 }
 
-func generateImportPackagesCode(importPackages []string) (generatedCode string) {
-	importPackagesUniq := make(map[string]bool)
-	for _, pkg := range importPackages {
-		importPackagesUniq[pkg] = true
+// readTablesFile returns the tables listed in the file, one `project.dataset.table` per line.
+// Blank lines and lines starting with `#` are ignored.
+func readTablesFile(client *bigquery.Client, filePath string) (tables []*bigquery.Table, err error) {
+	var content []byte
+	content, err = readFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
 	}
 
-	// NOTE(ginokent): fix order
-	importPackagesUniqSort := make([]string, len(importPackagesUniq))
-	idx := 0
-	for _, pkg := range importPackages {
-		if importPackagesUniq[pkg] {
-			importPackagesUniq[pkg] = false
-			importPackagesUniqSort[idx] = pkg
-			idx++
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-	}
 
-	switch {
-	case len(importPackagesUniq) == 0:
-		generatedCode = ""
-	case len(importPackagesUniq) == 1:
-		for pkg := range importPackagesUniq {
-			generatedCode = "import \"" + pkg + "\"\n"
+		var projectID, datasetID, tableID string
+		projectID, datasetID, tableID, err = parseTableReference(line)
+		if err != nil {
+			return nil, fmt.Errorf("parseTableReference: %s:%d: %w", filePath, i+1, err)
 		}
-		generatedCode = generatedCode + "\n"
-	case len(importPackagesUniq) >= 2:
-		generatedCode = "import (\n"
-		for _, pkg := range importPackagesUniqSort {
-			generatedCode = generatedCode + "\t\"" + pkg + "\"\n"
+		tables = append(tables, client.DatasetInProject(projectID, datasetID).Table(tableID))
+	}
+
+	return tables, nil
+}
+
+// parseTableReference parses `project.dataset.table`.
+// The project ID may contain `.`, like the domain-scoped project `example.com:project`.
+func parseTableReference(ref string) (projectID, datasetID, tableID string, err error) {
+	tableIdx := strings.LastIndex(ref, ".")
+	if tableIdx < 0 {
+		return "", "", "", fmt.Errorf("invalid table reference `%s`. format: project.dataset.table", ref)
+	}
+	datasetIdx := strings.LastIndex(ref[:tableIdx], ".")
+	if datasetIdx < 0 {
+		return "", "", "", fmt.Errorf("invalid table reference `%s`. format: project.dataset.table", ref)
+	}
+
+	projectID, datasetID, tableID = ref[:datasetIdx], ref[datasetIdx+1:tableIdx], ref[tableIdx+1:]
+	if projectID == "" || datasetID == "" || tableID == "" {
+		return "", "", "", fmt.Errorf("invalid table reference `%s`. format: project.dataset.table", ref)
+	}
+
+	return projectID, datasetID, tableID, nil
+}
+
+// parseTempTableReference parses the reference of a temporary table, such as the destination table of a query job.
+// In addition to `project.dataset.table`, it accepts `project:dataset.table` as shown by `bq show -j`,
+// `dataset.table` in defaultProjectID, and the references quoted with backquotes.
+// Session-scoped temporary tables (`_SESSION.table`) are only visible within the session, so they are not supported.
+func parseTempTableReference(ref, defaultProjectID string) (projectID, datasetID, tableID string, err error) {
+	ref = strings.Trim(strings.TrimSpace(ref), "`")
+
+	if strings.HasPrefix(strings.ToUpper(ref), "_SESSION.") {
+		return "", "", "", fmt.Errorf("session-scoped temporary table `%s` is not supported. use the destination table of the query job instead", ref)
+	}
+
+	// `project:dataset.table`. the project ID may contain `:`, like the domain-scoped project `example.com:project`,
+	// so `example.com:project.dataset.table` is parsed as `project.dataset.table` below.
+	if idx := strings.LastIndex(ref, ":"); idx >= 0 && strings.Count(ref[idx+1:], ".") == 1 {
+		projectID = ref[:idx]
+		datasetAndTable := strings.SplitN(ref[idx+1:], ".", 2)
+		if projectID == "" || datasetAndTable[0] == "" || datasetAndTable[1] == "" {
+			return "", "", "", fmt.Errorf("invalid table reference `%s`. format: project:dataset.table", ref)
 		}
-		generatedCode = generatedCode + ")\n\n"
+		return projectID, datasetAndTable[0], datasetAndTable[1], nil
+	}
+
+	if strings.Count(ref, ".") == 1 {
+		ref = defaultProjectID + "." + ref
+	}
+
+	projectID, datasetID, tableID, err = parseTableReference(ref)
+	if err != nil {
+		return "", "", "", fmt.Errorf("parseTableReference: %w", err)
 	}
 
-	return generatedCode
+	return projectID, datasetID, tableID, nil
 }
 
-func generateTableSchemaCode(ctx context.Context, table *bigquery.Table) (generatedCode string, importPackages []string, err error) {
-	tableID := table.TableID
-	if len(tableID) == 0 {
-		return "", nil, fmt.Errorf("*bigquery.Table.TableID is empty. *bigquery.Table struct dump: %#v", table)
+// regionalEndpoint returns the regional endpoint of the location, which keeps requests within the region.
+// It returns an empty string for an empty location or a multi-region, which has no regional endpoint.
+func regionalEndpoint(location string) (endpoint string) {
+	location = strings.ToLower(location)
+	switch location {
+	case "", "us", "eu":
+		return ""
+	default:
+		return "https://bigquery." + location + ".rep.googleapis.com/bigquery/v2/"
 	}
+}
+
+// cachedTokenSource is an oauth2.TokenSource that saves every new token to a cache file.
+type cachedTokenSource struct {
+	cacheFile string
+	source    oauth2.TokenSource
+	saved     string
+}
 
-	if strings.Contains(tableID, "-") {
-		replaced := strings.ReplaceAll(tableID, "-", "_")
-		warnln(fmt.Sprintf("tableID `%s` contains invalid character `-`. replacing `%s` to `%s`", tableID, tableID, replaced))
-		tableID = replaced
+// newCachedTokenSource returns an oauth2.TokenSource that reuses the token cached in cacheFile until it expires,
+// and then falls back to the Application Default Credentials.
+func newCachedTokenSource(ctx context.Context, cacheFile string) (tokenSource oauth2.TokenSource, err error) {
+	var defaultTokenSource oauth2.TokenSource
+	defaultTokenSource, err = google.DefaultTokenSource(ctx, bigquery.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("google.DefaultTokenSource: %w", err)
 	}
 
-	structName := capitalizeInitial(tableID)
+	return newCachedTokenSourceFrom(cacheFile, defaultTokenSource), nil
+}
 
-	var md *bigquery.TableMetadata
-	md, err = table.Metadata(ctx)
+func newCachedTokenSourceFrom(cacheFile string, source oauth2.TokenSource) (tokenSource *cachedTokenSource) {
+	cached, err := loadCachedToken(cacheFile)
 	if err != nil {
-		return "", nil, fmt.Errorf("table.Metadata: %w", err)
+		infoln("token cache is not used: loadCachedToken: " + err.Error())
 	}
 
-	// NOTE(ginokent): structs
-	generatedCode = "// " + structName + " is BigQuery Table `" + md.FullID + "` schema struct.\n" +
-		"// Description: " + md.Description + "\n" +
-		"type " + structName + " struct {\n"
+	var saved string
+	if cached != nil {
+		saved = cached.AccessToken
+	}
 
-	schemas := []*bigquery.FieldSchema(md.Schema)
+	return &cachedTokenSource{
+		cacheFile: cacheFile,
+		source:    oauth2.ReuseTokenSource(cached, source),
+		saved:     saved,
+	}
+}
 
-	for _, schema := range schemas {
-		var goTypeStr, pkg string
-		goTypeStr, pkg, err = bigqueryFieldTypeToGoType(schema.Type)
-		if err != nil {
-			return "", nil, fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
-		}
-		if pkg != "" {
-			importPackages = append(importPackages, pkg)
+func (s *cachedTokenSource) Token() (token *oauth2.Token, err error) {
+	token, err = s.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("source.Token: %w", err)
+	}
+
+	if token.AccessToken != s.saved {
+		if saveErr := saveCachedToken(s.cacheFile, token); saveErr != nil {
+			warnln("saveCachedToken: " + saveErr.Error())
+		} else {
+			s.saved = token.AccessToken
 		}
-		generatedCode = generatedCode + "\t" + capitalizeInitial(schema.Name) + " " + goTypeStr + " `bigquery:\"" + schema.Name + "\"`\n"
 	}
-	generatedCode = generatedCode + "}\n"
 
-	return generatedCode, importPackages, nil
+	return token, nil
 }
 
-func getAllTables(ctx context.Context, client *bigquery.Client, datasetID string) (tables []*bigquery.Table, err error) {
-	tableIterator := client.Dataset(datasetID).Tables(ctx)
-	for {
-		var table *bigquery.Table
-		table, err = tableIterator.Next()
-		if err != nil {
-			if err == iterator.Done {
-				break
-			}
-			return nil, fmt.Errorf("tableIterator.Next: %w", err)
-		}
-		tables = append(tables, table)
+func loadCachedToken(cacheFile string) (token *oauth2.Token, err error) {
+	var content []byte
+	content, err = readFile(cacheFile)
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
 	}
-	return tables, nil
+
+	token = new(oauth2.Token)
+	if err = json.Unmarshal(content, token); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return token, nil
+}
+
+func saveCachedToken(cacheFile string, token *oauth2.Token) (err error) {
+	var content []byte
+	content, err = json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	// The token is a credential, so that only the owner can read it.
+	if err = writeFileAtomic(cacheFile, content, 0600); err != nil {
+		return fmt.Errorf("writeFileAtomic: %w", err)
+	}
+
+	return nil
 }
 
 func readFile(path string) (content []byte, err error) {
@@ -284,35 +2173,94 @@ func readFile(path string) (content []byte, err error) {
 	return bytea, nil
 }
 
+// writeFileAtomic writes content to a temporary file in the same directory as filePath,
+// and renames it to filePath only after all content has been written, so that filePath is never left half-written.
+func writeFileAtomic(filePath string, content []byte, perm os.FileMode) (err error) {
+	// Special files such as /dev/null cannot be replaced by renaming.
+	if info, statErr := os.Stat(filePath); statErr == nil && !info.Mode().IsRegular() {
+		if err = ioutil.WriteFile(filePath, content, perm); err != nil {
+			return fmt.Errorf("ioutil.WriteFile: %w", err)
+		}
+		return nil
+	}
+
+	var tmp *os.File
+	tmp, err = ioutil.TempFile(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("ioutil.TempFile: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return fmt.Errorf("tmp.Write: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("tmp.Sync: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("tmp.Close: %w", err)
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("os.Chmod: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
+
 func getOptOrEnvOrDefault(optName, optValue, envName, defaultValue string) (value string, err error) {
 	if optName == "" {
 		return "", fmt.Errorf("optName is empty")
 	}
 
+	if value = getOptOrEnv(optName, optValue, envName); value != "" {
+		return value, nil
+	}
+
+	if defaultValue != "" {
+		infoln("use default option value: -" + optName + "=" + defaultValue)
+		return defaultValue, nil
+	}
+
+	return "", fmt.Errorf("set option -%s, or set environment variable %s", optName, envName)
+}
+
+// getOptOrEnv is the same as getOptOrEnvOrDefault, but returns an empty string for the option that is not set.
+func getOptOrEnv(optName, optValue, envName string) (value string) {
 	if optValue != "" {
 		infoln("use option value: -" + optName + "=" + optValue)
-		return optValue, nil
+		return optValue
 	}
 
 	envValue := os.Getenv(envName)
 	if envValue != "" {
 		infoln("use environment variable: " + envName + "=" + envValue)
-		return envValue, nil
-	}
-
-	if defaultValue != "" {
-		infoln("use default option value: -" + optName + "=" + defaultValue)
-		return defaultValue, nil
+		return envValue
 	}
 
-	return "", fmt.Errorf("set option -%s, or set environment variable %s", optName, envName)
+	return ""
 }
 
-func capitalizeInitial(s string) (capitalized string) {
-	if len(s) == 0 {
-		return ""
+func getOptOrEnvOrDefaultBool(optName, optValue, envName, defaultValue string) (value bool, err error) {
+	var s string
+	s, err = getOptOrEnvOrDefault(optName, optValue, envName, defaultValue)
+	if err != nil {
+		return false, fmt.Errorf("getOptOrEnvOrDefault: %w", err)
 	}
-	return strings.ToUpper(s[:1]) + s[1:]
+
+	value, err = strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("strconv.ParseBool: -%s=%s: %w", optName, s, err)
+	}
+
+	return value, nil
 }
 
 func infoln(content string) {
@@ -334,57 +2282,108 @@ func exit(code int) {
 	os.Exit(code)
 }
 
-// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L216
-var typeOfByteSlice = reflect.TypeOf([]byte{})
+// parseTypeOverrides parses a comma-separated list of `BIGQUERY_TYPE=[import/path:]GoType`.
+func parseTypeOverrides(s string) (typeOverrides map[bigquery.FieldType]generator.GoType, err error) {
+	typeOverrides = make(map[bigquery.FieldType]generator.GoType)
+	if strings.TrimSpace(s) == "" {
+		return typeOverrides, nil
+	}
 
-// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/params.go#L81-L87
-var (
-	typeOfDate     = reflect.TypeOf(civil.Date{})
-	typeOfTime     = reflect.TypeOf(civil.Time{})
-	typeOfDateTime = reflect.TypeOf(civil.DateTime{})
-	typeOfGoTime   = reflect.TypeOf(time.Time{})
-	typeOfRat      = reflect.TypeOf(&big.Rat{})
-)
+	for _, override := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(override), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid type override `%s`. format: BIGQUERY_TYPE=[import/path:]GoType", override)
+		}
 
-func bigqueryFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType string, pkg string, err error) {
-	switch bigqueryFieldType {
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L342-L343
-	case bigquery.BytesFieldType:
-		return typeOfByteSlice.String(), "", nil
-
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L344-L358
-	case bigquery.DateFieldType:
-		return typeOfDate.String(), typeOfDate.PkgPath(), nil
-	case bigquery.TimeFieldType:
-		return typeOfTime.String(), typeOfTime.PkgPath(), nil
-	case bigquery.DateTimeFieldType:
-		return typeOfDateTime.String(), typeOfDateTime.PkgPath(), nil
-	case bigquery.TimestampFieldType:
-		return typeOfGoTime.String(), typeOfGoTime.PkgPath(), nil
-	case bigquery.NumericFieldType:
-		// NOTE(ginokent): The *T (pointer type) does not return the package path.
-		//               ref. https://github.com/golang/go/blob/f0ff6d4a67ec9a956aa655d487543da034cf576b/src/reflect/type.go#L83
-		return typeOfRat.String(), reflect.TypeOf(big.Rat{}).PkgPath(), nil
-
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L362-L364
-	case bigquery.IntegerFieldType:
-		return reflect.Int64.String(), "", nil
-
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L368-L371
-	case bigquery.RecordFieldType:
-		// TODO(ginokent): support bigquery.RecordFieldType
-		return "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
-
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L394-L399
-	case bigquery.StringFieldType, bigquery.GeographyFieldType:
-		return reflect.String.String(), "", nil
-	case bigquery.BooleanFieldType:
-		return reflect.Bool.String(), "", nil
-	case bigquery.FloatFieldType:
-		return reflect.Float64.String(), "", nil
-
-	// NOTE(ginokent): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L400-L401
-	default:
-		return "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
+		goType := parseGoType(kv[1])
+		if goType.Name == "" {
+			return nil, fmt.Errorf("invalid type override `%s`. Go type is empty", override)
+		}
+
+		typeOverrides[generator.NormalizeFieldType(bigquery.FieldType(strings.ToUpper(kv[0])))] = goType
+	}
+
+	return typeOverrides, nil
+}
+
+// parseGoType parses `[import/path:]pkg.Name`.
+func parseGoType(s string) (goType generator.GoType) {
+	if idx := strings.LastIndex(s, ":"); idx >= 0 {
+		return generator.GoType{Name: s[idx+1:], PkgPath: s[:idx]}
+	}
+	return generator.GoType{Name: s}
+}
+
+// parseRegisterFunc parses `[import/path:]pkg.Func`. It returns the zero GoType if s is empty.
+func parseRegisterFunc(s string) (registerFunc generator.GoType, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return generator.GoType{}, nil
+	}
+
+	registerFunc = parseGoType(s)
+	if registerFunc.Name == "" {
+		return generator.GoType{}, fmt.Errorf("invalid function `%s`. format: [import/path:]pkg.Func", s)
+	}
+
+	return registerFunc, nil
+}
+
+// parseImplements parses a comma-separated list of `[import/path:]pkg.Interface`.
+func parseImplements(s string) (interfaces []generator.GoType, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	for _, implement := range strings.Split(s, ",") {
+		implement = strings.TrimSpace(implement)
+
+		iface := parseGoType(implement)
+		if iface.Name == "" {
+			return nil, fmt.Errorf("invalid interface `%s`. format: [import/path:]pkg.Interface", implement)
+		}
+
+		interfaces = append(interfaces, iface)
+	}
+
+	return interfaces, nil
+}
+
+// parseExtraImports parses a comma-separated list of `path` or `name path`, and returns the import specs.
+func parseExtraImports(s string) (importSpecs []string, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	for _, extraImport := range strings.Split(s, ",") {
+		fields := strings.Fields(extraImport)
+		switch len(fields) {
+		case 1:
+			importSpecs = append(importSpecs, fields[0])
+		case 2:
+			importSpecs = append(importSpecs, fields[0]+" "+strconv.Quote(fields[1]))
+		default:
+			return nil, fmt.Errorf("invalid import `%s`. format: path or name path", extraImport)
+		}
 	}
+
+	return importSpecs, nil
+}
+
+// parseNullTypesFor parses a comma-separated list of BigQuery types. e.g. `TIMESTAMP,RECORD`
+func parseNullTypesFor(s string) (nullTypesFor map[bigquery.FieldType]bool, err error) {
+	nullTypesFor = make(map[bigquery.FieldType]bool)
+	if strings.TrimSpace(s) == "" {
+		return nullTypesFor, nil
+	}
+
+	for _, t := range strings.Split(s, ",") {
+		fieldType := generator.NormalizeFieldType(bigquery.FieldType(strings.ToUpper(strings.TrimSpace(t))))
+		if _, _, err = generator.BigQueryFieldTypeToGoType(fieldType); err != nil && fieldType != bigquery.RecordFieldType {
+			return nil, fmt.Errorf("generator.BigQueryFieldTypeToGoType: %w", err)
+		}
+		nullTypesFor[fieldType] = true
+	}
+
+	return nullTypesFor, nil
 }
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/bigquery"
-	"google.golang.org/api/iterator"
+	"github.com/ginokent/bqschema-gen-go/generator"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
 )
 
 const (
@@ -16,16 +28,14 @@ const (
 	testEmptyString                = ""
 	GOOGLE_APPLICATION_CREDENTIALS = "GOOGLE_APPLICATION_CREDENTIALS"
 
-	// generateTableSchemaCode, getAllTables
-	testPublicDataProjectID         = "bigquery-public-data"
-	testSupportedDatasetID          = "hacker_news"
-	testNotSupportedDatasetID       = "samples"
-	testProjectNotFound             = "projectnotfound"
-	testDatasetNotFound             = "datasetnotfound"
-	testSubStrFieldTypeNotSupported = "bigquery.FieldType not supported."
+	// getAllTableSchemas, getTableMetadata, generator.GetAllTables
+	testPublicDataProjectID = "bigquery-public-data"
+	testSupportedDatasetID  = "hacker_news"
+	testProjectNotFound     = "projectnotfound"
+	testDatasetNotFound     = "datasetnotfound"
 
-	// getAllTables
-	testGoogleApplicationCredentials = "test/serviceaccountnotfound@projectnotfound.iam.gserviceaccount.com.json"
+	// generateStructCode
+	testTableID = "test_table"
 
 	// readFile
 	testErrNoSuchFileOrDirectoryPath = "/no/such/file/or/directory"
@@ -39,11 +49,11 @@ const (
 	testEnvValue     = "testEnvValue"
 	testDefaultValue = "testDefaultValue"
 
-	// capitalizeInitial
-	testNotCapitalized = "a"
-	testCapitalized    = "A"
+	// getOptOrEnvOrDefaultBool
+	testBoolValue    = "true"
+	testNotBoolValue = "notBoolValue"
 
-	// bigqueryFieldTypeToGoType
+	// generator.BigQueryFieldTypeToGoType
 	testNotSupportedFieldType = "notSupportedFieldType"
 )
 
@@ -84,237 +94,623 @@ func Test_Run(t *testing.T) {
 	})
 }
 
-func Test_Generate(t *testing.T) {
-	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
-		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
-			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+func Test_checkGeneratedCode(t *testing.T) {
+	var (
+		testFilePath = filepath.Join(t.TempDir(), "bqschema.generated.go")
+	)
+	if err := ioutil.WriteFile(testFilePath, []byte(testCommittedCode), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("正常系_up_to_date", func(t *testing.T) {
+		if err := checkGeneratedCode(testFilePath, []byte(testCommittedCode), generator.OutputFormatGo); err != nil {
+			t.Error(err)
 		}
+	})
 
-		var (
-			ctx       = context.Background()
-			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
-		)
+	t.Run("異常系_not_up_to_date", func(t *testing.T) {
+		err := checkGeneratedCode(testFilePath, []byte(testGeneratedCode), generator.OutputFormatGo)
+		if err == nil {
+			t.Error(err)
+			return
+		}
+		if !strings.Contains(err.Error(), "+\tAge string `bigquery:\"age\"`") {
+			t.Error(err)
+		}
+		if !errors.Is(err, errColumnsRetyped) || !strings.Contains(err.Error(), "~ Users.age: int64 -> string\n") {
+			t.Errorf("checkGeneratedCode: want=%v current=%v", errColumnsRetyped, err)
+		}
+	})
 
-		_, err := Generate(ctx, client, testSupportedDatasetID)
-		if err != nil {
+	t.Run("異常系_not_up_to_date_not_retyped", func(t *testing.T) {
+		generatedCode := strings.Replace(testCommittedCode, "type Removed struct", "type Added struct", 1)
+		err := checkGeneratedCode(testFilePath, []byte(generatedCode), generator.OutputFormatGo)
+		if err == nil || errors.Is(err, errColumnsRetyped) {
+			t.Errorf("checkGeneratedCode: current=%v", err)
+		}
+	})
+
+	t.Run("異常系_not_up_to_date_outputFormatProto", func(t *testing.T) {
+		err := checkGeneratedCode(testFilePath, []byte(testGeneratedCode), generator.OutputFormatProto)
+		if err == nil {
+			t.Error(err)
+			return
+		}
+		if strings.Contains(err.Error(), "+\tAge string `bigquery:\"age\"`") {
 			t.Error(err)
 		}
 	})
 
-	t.Run("正常系_testNotSupportedDatasetID_"+testNotSupportedDatasetID, func(t *testing.T) {
-		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
-			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if err := checkGeneratedCode(testErrNoSuchFileOrDirectoryPath, []byte(testGeneratedCode), generator.OutputFormatGo); err == nil {
+			t.Error(err)
 		}
+	})
+}
 
-		var (
-			ctx       = context.Background()
-			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+func Test_checkGeneratedCodes(t *testing.T) {
+	var (
+		dir             = t.TempDir()
+		upToDatePath    = filepath.Join(dir, "up_to_date.generated.go")
+		retypedPath     = filepath.Join(dir, "retyped.generated.go")
+		notUpToDatePath = filepath.Join(dir, "not_up_to_date.generated.go")
+		addedCode       = strings.Replace(testCommittedCode, "type Removed struct", "type Added struct", 1)
+		formats         = []string{generator.OutputFormatGo, generator.OutputFormatGo, generator.OutputFormatGo}
+	)
+	for _, filePath := range []string{upToDatePath, retypedPath, notUpToDatePath} {
+		if err := ioutil.WriteFile(filePath, []byte(testCommittedCode), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("正常系_up_to_date", func(t *testing.T) {
+		if err := checkGeneratedCodes([]string{upToDatePath}, [][]byte{[]byte(testCommittedCode)}, formats[:1]); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_retyped_after_not_up_to_date", func(t *testing.T) {
+		err := checkGeneratedCodes(
+			[]string{notUpToDatePath, upToDatePath, retypedPath},
+			[][]byte{[]byte(addedCode), []byte(testCommittedCode), []byte(testGeneratedCode)},
+			formats,
 		)
+		if !errors.Is(err, errColumnsRetyped) {
+			t.Fatalf("checkGeneratedCodes: want=%v current=%v", errColumnsRetyped, err)
+		}
+		for _, want := range []string{"2 files are not up to date", notUpToDatePath + " is not up to date", retypedPath + " is not up to date", "~ Users.age: int64 -> string\n"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Error("checkGeneratedCodes: want=`" + want + "` current=`" + err.Error() + "`")
+			}
+		}
+		if strings.Contains(err.Error(), upToDatePath) {
+			t.Error("checkGeneratedCodes: " + err.Error())
+		}
+	})
 
-		_, err := Generate(ctx, client, testNotSupportedDatasetID)
+	t.Run("異常系_not_retyped", func(t *testing.T) {
+		err := checkGeneratedCodes([]string{notUpToDatePath}, [][]byte{[]byte(addedCode)}, formats[:1])
+		if err == nil || errors.Is(err, errColumnsRetyped) {
+			t.Errorf("checkGeneratedCodes: current=%v", err)
+		}
+	})
+}
+
+func Test_writeSchemaChangelog(t *testing.T) {
+	var (
+		testDir           = t.TempDir()
+		testFilePath      = filepath.Join(testDir, "bqschema.generated.go")
+		testOtherFilePath = filepath.Join(testDir, "other.generated.go")
+		testChangelogPath = filepath.Join(testDir, "changelog.json")
+	)
+	for _, filePath := range []string{testFilePath, testOtherFilePath} {
+		if err := ioutil.WriteFile(filePath, []byte(testCommittedCode), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("正常系", func(t *testing.T) {
+		if err := writeSchemaChangelog(testChangelogPath, []string{testFilePath, testOtherFilePath}, [][]byte{[]byte(testGeneratedCode), []byte(testCommittedCode)}); err != nil {
+			t.Error(err)
+		}
+		changelog, err := ioutil.ReadFile(testChangelogPath)
 		if err != nil {
+			t.Fatal(err)
+		}
+		var current []schemaChangelogFile
+		if err := json.Unmarshal(changelog, &current); err != nil {
+			t.Fatal(err)
+		}
+		// The changes of all the files, not only the last one
+		if len(current) != 2 || current[0].File != testFilePath || len(current[0].Structs) != 3 || current[1].File != testOtherFilePath || len(current[1].Structs) != 0 {
+			t.Error("writeSchemaChangelog: current=" + string(changelog))
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if err := writeSchemaChangelog(testChangelogPath, []string{testErrNoSuchFileOrDirectoryPath}, [][]byte{[]byte(testGeneratedCode)}); err == nil {
 			t.Error(err)
 		}
 	})
 }
 
-func Test_generateImportPackagesCode(t *testing.T) {
-	t.Run("正常系_import_nothing", func(t *testing.T) {
-		const (
-			// 正しい出力
-			testImportCode = ""
-		)
-		var (
-			testImportsSlice = []string{}
-		)
+func Test_convertLineEnding(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			code       string
+			lineEnding string
+			converted  string
+		}{
+			{"package main\n\ntype A struct{}\n", lineEndingLF, "package main\n\ntype A struct{}\n"},
+			{"package main\n\ntype A struct{}\n", lineEndingCRLF, "package main\r\n\r\ntype A struct{}\r\n"},
+			{"package main\r\n", lineEndingCRLF, "package main\r\n"},
+		} {
+			if converted := string(convertLineEnding([]byte(tt.code), tt.lineEnding)); converted != tt.converted {
+				t.Errorf("convertLineEnding: %q %s: want=%q current=%q", tt.code, tt.lineEnding, tt.converted, converted)
+			}
+		}
+	})
+}
 
-		generatedCode := generateImportPackagesCode(testImportsSlice)
-		if generatedCode != testImportCode {
-			t.Error()
+func Test_ensureTrailingNewline(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			code    string
+			ensured string
+		}{
+			{"package main\n", "package main\n"},
+			{"package main", "package main\n"},
+			{"package main\n\n\n", "package main\n"},
+			{"package main\r\n\r\n", "package main\n"},
+		} {
+			if ensured := string(ensureTrailingNewline([]byte(tt.code))); ensured != tt.ensured {
+				t.Errorf("ensureTrailingNewline: %q: want=%q current=%q", tt.code, tt.ensured, ensured)
+			}
+		}
+	})
+
+	t.Run("正常系_generateCode", func(t *testing.T) {
+		for _, format := range []string{generator.OutputFormatGo, generator.OutputFormatProto} {
+			schemas := []generator.TableSchema{{
+				Table: &bigquery.Table{ProjectID: testProjectNotFound, DatasetID: testDatasetNotFound, TableID: testTableID},
+				Metadata: &bigquery.TableMetadata{
+					Schema: bigquery.Schema{
+						{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					},
+				},
+			}}
+			generatedCode, err := generator.GenerateCode(schemas, generator.Options{OutputFormat: format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ensured := ensureTrailingNewline(generatedCode); !bytes.HasSuffix(ensured, []byte("}\n")) || bytes.HasSuffix(ensured, []byte("\n\n")) {
+				t.Errorf("ensureTrailingNewline: %s: not end with exactly one newline: %q", format, ensured)
+			}
 		}
 	})
+}
+
+func Test_confirmWrite(t *testing.T) {
+	var (
+		testDir      = t.TempDir()
+		testFilePath = filepath.Join(testDir, "bqschema.generated.go")
+		testNewPath  = filepath.Join(testDir, "new.go")
+		filePaths    = []string{testFilePath, testNewPath}
+		formats      = []string{generator.OutputFormatGo, generator.OutputFormatGo}
+		codes        = [][]byte{[]byte(testGeneratedCode), []byte(testGeneratedCode)}
+	)
+	if err := ioutil.WriteFile(testFilePath, []byte(testCommittedCode), 0644); err != nil {
+		t.Fatal(err)
+	}
 
-	t.Run("正常系_import_time", func(t *testing.T) {
+	t.Run("正常系_summary", func(t *testing.T) {
 		const (
 			// 正しい出力
-			testImportCode = "import \"time\"\n\n"
+			testSummary = "\t+ Added\n\t~ Users\n\t- Removed\n"
 		)
-		var (
-			testImportsSlice = []string{"time"}
-		)
-		generatedCode := generateImportPackagesCode(testImportsSlice)
-
-		if generatedCode != testImportCode {
-			var (
-				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
-				want    = rr.Replace(testImportCode)
-				current = rr.Replace(generatedCode)
-			)
-			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		var out bytes.Buffer
+		confirmed, err := confirmWrite(strings.NewReader("y\n"), &out, true, false, filePaths, formats, codes)
+		if err != nil {
+			t.Error(err)
+		}
+		if !confirmed {
+			t.Error("confirmWrite: not confirmed")
+		}
+		if !strings.Contains(out.String(), testFilePath+": changed\n"+testSummary) || !strings.Contains(out.String(), testNewPath+": new file\n") {
+			t.Error("confirmWrite: current=`" + out.String() + "`")
 		}
 	})
 
-	t.Run("正常系_import_math/big_time", func(t *testing.T) {
-		const (
-			// 正しい出力
-			testImportCode = `import (
-	"math/big"
-	"time"
-)
+	t.Run("正常系_declined", func(t *testing.T) {
+		for _, answer := range []string{"n\n", "\n", ""} {
+			if confirmed, err := confirmWrite(strings.NewReader(answer), ioutil.Discard, true, false, filePaths, formats, codes); err != nil || confirmed {
+				t.Errorf("confirmWrite: answer=%q confirmed=%v err=%v", answer, confirmed, err)
+			}
+		}
+	})
 
-`
-		)
-		var (
-			testImportsSlice = []string{"math/big", "time"}
-		)
-		generatedCode := generateImportPackagesCode(testImportsSlice)
+	t.Run("正常系_yes", func(t *testing.T) {
+		if confirmed, err := confirmWrite(strings.NewReader(""), ioutil.Discard, false, true, filePaths, formats, codes); err != nil || !confirmed {
+			t.Errorf("confirmWrite: confirmed=%v err=%v", confirmed, err)
+		}
+	})
 
-		if generatedCode != testImportCode {
-			var (
-				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
-				want    = rr.Replace(testImportCode)
-				current = rr.Replace(generatedCode)
-			)
-			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+	t.Run("異常系_not_terminal", func(t *testing.T) {
+		if _, err := confirmWrite(strings.NewReader("y\n"), ioutil.Discard, false, false, filePaths, formats, codes); !errors.Is(err, errNotConfirmed) {
+			t.Errorf("confirmWrite: want=errNotConfirmed current=%v", err)
 		}
 	})
 }
 
-func Test_generateTableSchemaCode(t *testing.T) {
-	t.Run("正常系_testPublicDataProjectID_testPublicDataProjectID", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+func Test_notifyContext(t *testing.T) {
+	t.Run("正常系_signal", func(t *testing.T) {
+		ctx, stop := notifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
-		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
-			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Signal(os.Interrupt); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Error("notifyContext: not cancelled by the signal")
 		}
+	})
 
-		var (
-			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTableIterator = ngClient.Dataset(testSupportedDatasetID).Tables(ctx)
-		)
+	t.Run("正常系_stop", func(t *testing.T) {
+		ctx, stop := notifyContext(context.Background(), os.Interrupt)
+		stop()
+		if ctx.Err() == nil {
+			t.Error("notifyContext: not cancelled by stop")
+		}
+	})
+}
 
-		for {
-			table, err := ngTableIterator.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				t.Error(err)
+func Test_parseRegisterFunc(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			s            string
+			registerFunc generator.GoType
+		}{
+			{"example.com/mypkg:mypkg.Register", generator.GoType{Name: "mypkg.Register", PkgPath: "example.com/mypkg"}},
+			{"Register", generator.GoType{Name: "Register"}},
+			{testEmptyString, generator.GoType{}},
+		} {
+			registerFunc, err := parseRegisterFunc(tt.s)
+			if err != nil || registerFunc != tt.registerFunc {
+				t.Errorf("parseRegisterFunc: %s: want=%v current=%v,%v", tt.s, tt.registerFunc, registerFunc, err)
 			}
-			if _, _, err := generateTableSchemaCode(ctx, table); err != nil {
-				t.Error(err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		if _, err := parseRegisterFunc("example.com/mypkg:"); err == nil {
+			t.Error("parseRegisterFunc: err == nil")
+		}
+	})
+}
+
+func Test_parseImplements(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		interfaces, err := parseImplements("example.com/mypkg:mypkg.Record, fmt.Stringer")
+		if err != nil {
+			t.Errorf("parseImplements: %v", err)
+		}
+		expect := []generator.GoType{{Name: "mypkg.Record", PkgPath: "example.com/mypkg"}, {Name: "fmt.Stringer"}}
+		if !reflect.DeepEqual(interfaces, expect) {
+			t.Errorf("parseImplements: want=%v current=%v", expect, interfaces)
+		}
+	})
+
+	t.Run("正常系_empty", func(t *testing.T) {
+		interfaces, err := parseImplements(testEmptyString)
+		if err != nil || interfaces != nil {
+			t.Errorf("parseImplements: want=nil,nil current=%v,%v", interfaces, err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		if _, err := parseImplements("example.com/mypkg:"); err == nil {
+			t.Error("parseImplements: err == nil")
+		}
+	})
+}
+
+func Test_parseEmbedPatterns(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		embedPatterns, err := parseEmbedPatterns("timestamp:*_at, *_by")
+		if err != nil {
+			t.Error(err)
+		}
+		want := []generator.EmbedPattern{{Type: bigquery.TimestampFieldType, Pattern: "*_at"}, {Pattern: "*_by"}}
+		if !reflect.DeepEqual(embedPatterns, want) {
+			t.Errorf("parseEmbedPatterns: want=%#v current=%#v", want, embedPatterns)
+		}
+	})
+
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		if embedPatterns, err := parseEmbedPatterns(testEmptyString); err != nil || embedPatterns != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_invalid_pattern", func(t *testing.T) {
+		for _, s := range []string{"TIMESTAMP:", "[", "TIMESTAMP:["} {
+			if _, err := parseEmbedPatterns(s); err == nil {
+				t.Error("parseEmbedPatterns: " + s)
 			}
 		}
 	})
+}
 
-	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
-		var (
-			ctx     = context.Background()
-			ngTable = &bigquery.Table{
-				ProjectID: testProjectNotFound,
-				DatasetID: testDatasetNotFound,
-				TableID:   testEmptyString,
+func Test_isRetryableError(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		ctx := context.Background()
+		for _, tt := range []struct {
+			err       error
+			retryable bool
+		}{
+			{fmt.Errorf("tableIterator.Next: %w", &googleapi.Error{Code: http.StatusServiceUnavailable}), true},
+			{fmt.Errorf("tableIterator.Next: %w", &googleapi.Error{Code: http.StatusTooManyRequests}), true},
+			{fmt.Errorf("tableIterator.Next: %w", &googleapi.Error{Code: http.StatusNotFound}), false},
+			{fmt.Errorf("tableIterator.Next: %w", context.DeadlineExceeded), true},
+			{io.ErrUnexpectedEOF, true},
+			{errors.New("unknown"), false},
+		} {
+			if retryable := isRetryableError(ctx, tt.err); retryable != tt.retryable {
+				t.Errorf("isRetryableError: %v: want=%t current=%t", tt.err, tt.retryable, retryable)
 			}
-		)
-		if _, _, err := generateTableSchemaCode(ctx, ngTable); err == nil {
-			t.Error(err)
 		}
 	})
 
-	t.Run("異常系_testProjectNotFound_testNotSupportedDatasetID", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+	t.Run("正常系_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if isRetryableError(ctx, context.DeadlineExceeded) {
+			t.Error("isRetryableError: want=false current=true")
+		}
+	})
+}
+
+func Test_getTablesFromInformationSchema(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
 
 		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
 			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
 		}
 
 		var (
-			ngClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTable, _  = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx).Next()
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
 		)
 
-		ngTable.ProjectID = testProjectNotFound
-		if _, _, err := generateTableSchemaCode(ctx, ngTable); err == nil {
+		tables, err := getTablesFromInformationSchema(ctx, okClient, testEmptyString, testSupportedDatasetID, testEmptyString, "table_name LIKE 'c%'")
+		if err != nil {
 			t.Error(err)
 		}
+		for _, table := range tables {
+			if !strings.HasPrefix(table.TableID, "c") {
+				t.Error("getTablesFromInformationSchema: unexpected table: " + table.TableID)
+			}
+		}
 	})
+}
 
-	t.Run("異常系_testPublicDataProjectID_testNotSupportedDatasetID_testSubStrFieldTypeNotSupported", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+func Test_informationSchemaTablesQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		projectID string
+		location  string
+		filter    string
+		want      string
+	}{
+		{"正常系_dataset_qualified", "", "", "", "SELECT table_name FROM `d`.INFORMATION_SCHEMA.TABLES WHERE table_schema = @dataset ORDER BY table_name"},
+		{"正常系_region_qualified", "p", "US", "", "SELECT table_name FROM `p`.`region-us`.INFORMATION_SCHEMA.TABLES WHERE table_schema = @dataset ORDER BY table_name"},
+		{"正常系_filter", "", "asia-northeast1", "table_name LIKE 'events_%'", "SELECT table_name FROM `region-asia-northeast1`.INFORMATION_SCHEMA.TABLES WHERE table_schema = @dataset AND (table_name LIKE 'events_%') ORDER BY table_name"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if query := informationSchemaTablesQuery(tt.projectID, "d", tt.location, tt.filter); query != tt.want {
+				t.Error("informationSchemaTablesQuery: want=`" + tt.want + "` current=`" + query + "`")
+			}
+		})
+	}
+}
 
-		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
-			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+func Test_readTablesFile(t *testing.T) {
+	var (
+		client = &bigquery.Client{}
+	)
+
+	t.Run("正常系", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "tables.txt")
+		if err := ioutil.WriteFile(testFilePath, []byte("# comment\n\np1.d1.t1\n  p2.d2.t2  \n"), 0644); err != nil {
+			t.Fatal(err)
 		}
+		tables, err := readTablesFile(client, testFilePath)
+		if err != nil {
+			t.Error(err)
+		}
+		var refs []string
+		for _, table := range tables {
+			refs = append(refs, table.ProjectID+"."+table.DatasetID+"."+table.TableID)
+		}
+		if want := []string{"p1.d1.t1", "p2.d2.t2"}; !reflect.DeepEqual(refs, want) {
+			t.Errorf("readTablesFile: want=%v current=%v", want, refs)
+		}
+	})
 
-		var (
-			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTableIterator = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx)
-		)
+	t.Run("異常系_invalid_table_reference", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "tables.txt")
+		if err := ioutil.WriteFile(testFilePath, []byte("p1.d1.t1\nd2.t2\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := readTablesFile(client, testFilePath)
+		if err == nil || !strings.Contains(err.Error(), testFilePath+":2:") {
+			t.Error(err)
+		}
+	})
 
-		for {
-			table, err := ngTableIterator.Next()
-			if err == iterator.Done {
-				break
-			}
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := readTablesFile(client, testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_parseTableReference(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			ref                           string
+			projectID, datasetID, tableID string
+		}{
+			{"p.d.t", "p", "d", "t"},
+			{"example.com:p.d.t", "example.com:p", "d", "t"},
+		} {
+			projectID, datasetID, tableID, err := parseTableReference(tt.ref)
 			if err != nil {
 				t.Error(err)
 			}
-			if _, _, err := generateTableSchemaCode(ctx, table); err != nil {
-				// NOTE(ginokent): "bigquery.FieldType not supported." 以外のエラーが出たら Fail
-				if !strings.Contains(err.Error(), testSubStrFieldTypeNotSupported) {
-					t.Error(err)
-				}
-				// NOTE(ginokent): ここまで来たら、確認したいことは確認済み。
-				// ref. https://github.com/ginokent/bqschema-gen-go/blob/260524ce0ae2dd5bdcbdd57446cdd8c140326ca4/main.go#L212
-				return
+			if projectID != tt.projectID || datasetID != tt.datasetID || tableID != tt.tableID {
+				t.Errorf("parseTableReference: ref=%s current=%s,%s,%s", tt.ref, projectID, datasetID, tableID)
+			}
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, ref := range []string{"t", "d.t", ".d.t", "p..t", "p.d."} {
+			if _, _, _, err := parseTableReference(ref); err == nil {
+				t.Error("parseTableReference: ref=" + ref)
 			}
 		}
 	})
 }
 
-func Test_getAllTables(t *testing.T) {
-	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+func Test_parseTempTableReference(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			ref                           string
+			projectID, datasetID, tableID string
+		}{
+			{"p:_0a1b2c.anon3d4e", "p", "_0a1b2c", "anon3d4e"},
+			{"example.com:p:_0a1b2c.anon3d4e", "example.com:p", "_0a1b2c", "anon3d4e"},
+			{"example.com:p._0a1b2c.anon3d4e", "example.com:p", "_0a1b2c", "anon3d4e"},
+			{"`p._0a1b2c.anon3d4e`", "p", "_0a1b2c", "anon3d4e"},
+			{"_0a1b2c.anon3d4e", "default", "_0a1b2c", "anon3d4e"},
+		} {
+			projectID, datasetID, tableID, err := parseTempTableReference(tt.ref, "default")
+			if err != nil {
+				t.Error(err)
+			}
+			if projectID != tt.projectID || datasetID != tt.datasetID || tableID != tt.tableID {
+				t.Errorf("parseTempTableReference: ref=%s current=%s,%s,%s", tt.ref, projectID, datasetID, tableID)
+			}
+		}
+	})
 
-		if os.Getenv(GOOGLE_APPLICATION_CREDENTIALS) == "" {
-			t.Skip("WARN: " + GOOGLE_APPLICATION_CREDENTIALS + " is not set")
+	t.Run("異常系", func(t *testing.T) {
+		for _, ref := range []string{"_SESSION.t", "_session.t", "t", ":d.t", "p:.t", "p:d."} {
+			if _, _, _, err := parseTempTableReference(ref, "default"); err == nil {
+				t.Error("parseTempTableReference: ref=" + ref)
+			}
+		}
+	})
+}
+
+func Test_regionalEndpoint(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for location, endpoint := range map[string]string{
+			testEmptyString:   testEmptyString,
+			"US":              testEmptyString,
+			"eu":              testEmptyString,
+			"europe-west3":    "https://bigquery.europe-west3.rep.googleapis.com/bigquery/v2/",
+			"ASIA-NORTHEAST1": "https://bigquery.asia-northeast1.rep.googleapis.com/bigquery/v2/",
+		} {
+			if v := regionalEndpoint(location); v != endpoint {
+				t.Error("regionalEndpoint: want=" + endpoint + " current=" + v)
+			}
 		}
+	})
+}
 
-		var (
-			ctx         = context.Background()
-			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
-		)
+func Test_cachedTokenSource(t *testing.T) {
+	var (
+		validToken   = &oauth2.Token{AccessToken: "valid", Expiry: time.Now().Add(time.Hour)}
+		expiredToken = &oauth2.Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Hour)}
+		newToken     = &oauth2.Token{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}
+	)
 
-		if _, err := getAllTables(ctx, okClient, testSupportedDatasetID); err != nil {
+	t.Run("正常系_no_cache", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "token.json")
+		token, err := newCachedTokenSourceFrom(cacheFile, oauth2.StaticTokenSource(newToken)).Token()
+		if err != nil {
 			t.Error(err)
 		}
+		if token.AccessToken != newToken.AccessToken {
+			t.Error("Token: want=" + newToken.AccessToken + " current=" + token.AccessToken)
+		}
+		cached, err := loadCachedToken(cacheFile)
+		if err != nil {
+			t.Error(err)
+		}
+		if cached == nil || cached.AccessToken != newToken.AccessToken {
+			t.Errorf("loadCachedToken: current=%#v", cached)
+		}
 	})
 
-	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
-
-		backupValue, exist := os.LookupEnv(GOOGLE_APPLICATION_CREDENTIALS)
-		_ = os.Setenv(GOOGLE_APPLICATION_CREDENTIALS, testGoogleApplicationCredentials)
-		defer func() {
-			if exist {
-				_ = os.Setenv(GOOGLE_APPLICATION_CREDENTIALS, backupValue)
-				return
-			}
-			_ = os.Unsetenv(GOOGLE_APPLICATION_CREDENTIALS)
-		}()
+	t.Run("正常系_valid_cache", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "token.json")
+		if err := saveCachedToken(cacheFile, validToken); err != nil {
+			t.Fatal(err)
+		}
+		token, err := newCachedTokenSourceFrom(cacheFile, oauth2.StaticTokenSource(newToken)).Token()
+		if err != nil {
+			t.Error(err)
+		}
+		if token.AccessToken != validToken.AccessToken {
+			t.Error("Token: want=" + validToken.AccessToken + " current=" + token.AccessToken)
+		}
+	})
 
-		var (
-			ctx         = context.Background()
-			ngClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
-		)
+	t.Run("正常系_expired_cache", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "token.json")
+		if err := saveCachedToken(cacheFile, expiredToken); err != nil {
+			t.Fatal(err)
+		}
+		token, err := newCachedTokenSourceFrom(cacheFile, oauth2.StaticTokenSource(newToken)).Token()
+		if err != nil {
+			t.Error(err)
+		}
+		if token.AccessToken != newToken.AccessToken {
+			t.Error("Token: want=" + newToken.AccessToken + " current=" + token.AccessToken)
+		}
+		cached, err := loadCachedToken(cacheFile)
+		if err != nil {
+			t.Error(err)
+		}
+		if cached == nil || cached.AccessToken != newToken.AccessToken {
+			t.Errorf("loadCachedToken: current=%#v", cached)
+		}
+	})
 
-		if _, err := getAllTables(ctx, ngClient, testDatasetNotFound); err == nil {
+	t.Run("異常系_broken_cache", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "token.json")
+		if err := ioutil.WriteFile(cacheFile, []byte("broken"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadCachedToken(cacheFile); err == nil {
+			t.Error(err)
+		}
+		token, err := newCachedTokenSourceFrom(cacheFile, oauth2.StaticTokenSource(newToken)).Token()
+		if err != nil {
 			t.Error(err)
 		}
+		if token.AccessToken != newToken.AccessToken {
+			t.Error("Token: want=" + newToken.AccessToken + " current=" + token.AccessToken)
+		}
 	})
 }
 
@@ -338,6 +734,70 @@ func Test_readFile(t *testing.T) {
 	})
 }
 
+func Test_writeFileAtomic(t *testing.T) {
+	const (
+		testContent = "testContent"
+	)
+
+	t.Run("正常系_new_file", func(t *testing.T) {
+		var (
+			dir          = t.TempDir()
+			testFilePath = filepath.Join(dir, "bqschema.generated.go")
+		)
+		if err := writeFileAtomic(testFilePath, []byte(testContent), 0644); err != nil {
+			t.Error(err)
+		}
+		content, err := readFile(testFilePath)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(content) != testContent {
+			t.Error("writeFileAtomic: want=" + testContent + " current=" + string(content))
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(files) != 1 {
+			t.Errorf("writeFileAtomic: temporary file is left: %d files", len(files))
+		}
+	})
+
+	t.Run("正常系_overwrite", func(t *testing.T) {
+		var (
+			testFilePath = filepath.Join(t.TempDir(), "bqschema.generated.go")
+		)
+		if err := ioutil.WriteFile(testFilePath, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFileAtomic(testFilePath, []byte(testContent), 0644); err != nil {
+			t.Error(err)
+		}
+		content, err := readFile(testFilePath)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(content) != testContent {
+			t.Error("writeFileAtomic: want=" + testContent + " current=" + string(content))
+		}
+	})
+
+	t.Run("正常系_dev_null", func(t *testing.T) {
+		if _, err := os.Stat(os.DevNull); err != nil {
+			t.Skip("WARN: " + os.DevNull + " is not found")
+		}
+		if err := writeFileAtomic(os.DevNull, []byte(testContent), 0644); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if err := writeFileAtomic(testErrNoSuchFileOrDirectoryPath, []byte(testContent), 0644); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
 func Test_getOptOrEnvOrDefault(t *testing.T) {
 	t.Run("正常系_testOptValue", func(t *testing.T) {
 		v, err := getOptOrEnvOrDefault(testOptName, testOptValue, testEnvName, testDefaultValue)
@@ -396,28 +856,58 @@ func Test_getOptOrEnvOrDefault(t *testing.T) {
 	})
 }
 
-func Test_capitalizeInitial(t *testing.T) {
+func Test_getOptOrEnv(t *testing.T) {
+	t.Run("正常系_testOptValue", func(t *testing.T) {
+		if v := getOptOrEnv(testOptName, testOptValue, testEnvName); v != testOptValue {
+			t.Error("getOptOrEnv: want=" + testOptValue + " current=" + v)
+		}
+	})
+
+	t.Run("正常系_testEnvValue", func(t *testing.T) {
+		if err := os.Setenv(testEnvName, testEnvValue); err != nil {
+			t.Error(err)
+		}
+		defer func() { _ = os.Unsetenv(testEnvName) }()
+		if v := getOptOrEnv(testOptName, testEmptyString, testEnvName); v != testEnvValue {
+			t.Error("getOptOrEnv: want=" + testEnvValue + " current=" + v)
+		}
+	})
+
 	t.Run("正常系_testEmptyString", func(t *testing.T) {
-		if capitalizeInitial(testEmptyString) != testEmptyString {
-			t.Error()
+		if v := getOptOrEnv(testOptName, testEmptyString, testEnvName); v != testEmptyString {
+			t.Error("getOptOrEnv: want= current=" + v)
 		}
 	})
+}
 
-	t.Run("正常系_testCapitalized", func(t *testing.T) {
-		if capitalizeInitial(testNotCapitalized) != testCapitalized {
+func Test_getOptOrEnvOrDefaultBool(t *testing.T) {
+	t.Run("正常系_testBoolValue", func(t *testing.T) {
+		v, err := getOptOrEnvOrDefaultBool(testOptName, testBoolValue, testEnvName, testDefaultValue)
+		if err != nil {
+			t.Error(err)
+		}
+		if !v {
 			t.Error()
 		}
 	})
+
+	t.Run("異常系_testNotBoolValue", func(t *testing.T) {
+		if _, err := getOptOrEnvOrDefaultBool(testOptName, testNotBoolValue, testEnvName, testDefaultValue); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testEmptyString", func(t *testing.T) {
+		if _, err := getOptOrEnvOrDefaultBool(testOptName, testEmptyString, testEnvName, testEmptyString); err == nil {
+			t.Error(err)
+		}
+	})
 }
 
 func Test_infoln(t *testing.T) {
 	infoln("test")
 }
 
-func Test_warnln(t *testing.T) {
-	warnln("test")
-}
-
 func Test_errorln(t *testing.T) {
 	errorln("test")
 }
@@ -441,50 +931,120 @@ func Test_exit(t *testing.T) {
 	exit(1)
 }
 
-func Test_bigqueryFieldTypeToGoType(t *testing.T) {
-	var (
-		supportedBigqueryFieldTypes = map[bigquery.FieldType]string{
-			bigquery.StringFieldType:    reflect.String.String(),
-			bigquery.BytesFieldType:     typeOfByteSlice.String(),
-			bigquery.IntegerFieldType:   reflect.Int64.String(),
-			bigquery.FloatFieldType:     reflect.Float64.String(),
-			bigquery.BooleanFieldType:   reflect.Bool.String(),
-			bigquery.TimestampFieldType: typeOfGoTime.String(),
-			// TODO(ginokent): support bigquery.RecordFieldType
-			//bigquery.RecordFieldType: "",
-			bigquery.DateFieldType:      typeOfDate.String(),
-			bigquery.TimeFieldType:      typeOfTime.String(),
-			bigquery.DateTimeFieldType:  typeOfDateTime.String(),
-			bigquery.NumericFieldType:   typeOfRat.String(),
-			bigquery.GeographyFieldType: reflect.String.String(),
-		}
-
-		unsupportedBigqueryFieldTypes = map[bigquery.FieldType]string{
-			bigquery.RecordFieldType:                      testEmptyString,
-			bigquery.FieldType(testNotSupportedFieldType): testEmptyString,
+func Test_runMain(t *testing.T) {
+	backupGoTest, existGoTest := os.LookupEnv("GOTEST")
+	_ = os.Setenv("GOTEST", "true")
+	backupMaxNameLength, existMaxNameLength := os.LookupEnv(envNameMaxNameLength)
+	_ = os.Setenv(envNameMaxNameLength, "invalid")
+	defer func() {
+		for name, backup := range map[string]struct {
+			value string
+			exist bool
+		}{"GOTEST": {backupGoTest, existGoTest}, envNameMaxNameLength: {backupMaxNameLength, existMaxNameLength}} {
+			if backup.exist {
+				_ = os.Setenv(name, backup.value)
+				continue
+			}
+			_ = os.Unsetenv(name)
 		}
-	)
+	}()
 
-	t.Run("正常系_supportedBigqueryFieldTypes", func(t *testing.T) {
-		for bigqueryFieldType, typeOf := range supportedBigqueryFieldTypes {
-			goType, _, err := bigqueryFieldTypeToGoType(bigqueryFieldType)
-			if err != nil {
-				t.Error(err)
-			}
-			if goType != typeOf {
-				t.Error()
-			}
+	buf := bytes.NewBuffer(nil)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	t.Run("異常系_Run", func(t *testing.T) {
+		buf.Reset()
+		ctx, stop := context.WithCancel(context.Background())
+		runMain(ctx, stop, Run)
+		if !strings.Contains(buf.String(), "ERROR: Run: ") || strings.Contains(buf.String(), "cancelled") {
+			t.Error("runMain: want=`ERROR: Run: ` current=`" + buf.String() + "`")
 		}
 	})
 
-	t.Run("異常系_unsupportedBigqueryFieldTypes", func(t *testing.T) {
-		for bigqueryFieldType, typeOf := range unsupportedBigqueryFieldTypes {
-			goType, _, err := bigqueryFieldTypeToGoType(bigqueryFieldType)
-			if err == nil {
-				t.Error(err)
-			}
-			if goType != typeOf {
-				t.Error()
+	t.Run("異常系_cancelled", func(t *testing.T) {
+		buf.Reset()
+		ctx, stop := context.WithCancel(context.Background())
+		runMain(ctx, stop, func(ctx context.Context) error {
+			stop()
+			return ctx.Err()
+		})
+		if !strings.Contains(buf.String(), "ERROR: cancelled: "+context.Canceled.Error()) {
+			t.Error("runMain: want=`ERROR: cancelled: ` current=`" + buf.String() + "`")
+		}
+	})
+}
+
+func Test_parseExtraImports(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		importSpecs, err := parseExtraImports("github.com/foo/helper, _ github.com/lib/pq,geom github.com/twpayne/go-geom")
+		if err != nil {
+			t.Error(err)
+		}
+		if want := []string{"github.com/foo/helper", `_ "github.com/lib/pq"`, `geom "github.com/twpayne/go-geom"`}; !reflect.DeepEqual(importSpecs, want) {
+			t.Errorf("parseExtraImports: want=%v current=%v", want, importSpecs)
+		}
+	})
+
+	t.Run("正常系_empty", func(t *testing.T) {
+		if importSpecs, err := parseExtraImports(""); err != nil || importSpecs != nil {
+			t.Errorf("parseExtraImports: importSpecs=%v err=%v", importSpecs, err)
+		}
+	})
+
+	t.Run("異常系_invalid", func(t *testing.T) {
+		if _, err := parseExtraImports("a b c"); err == nil {
+			t.Error("parseExtraImports: want=error current=nil")
+		}
+	})
+}
+
+func Test_parseNullTypesFor(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		nullTypesFor, err := parseNullTypesFor("timestamp, NUMERIC,RECORD")
+		if err != nil {
+			t.Error(err)
+		}
+		if want := map[bigquery.FieldType]bool{bigquery.TimestampFieldType: true, bigquery.NumericFieldType: true, bigquery.RecordFieldType: true}; !reflect.DeepEqual(nullTypesFor, want) {
+			t.Errorf("parseNullTypesFor: want=%v current=%v", want, nullTypesFor)
+		}
+	})
+
+	t.Run("異常系_testNotSupportedFieldType", func(t *testing.T) {
+		if _, err := parseNullTypesFor("TIMESTAMP," + testNotSupportedFieldType); !errors.Is(err, generator.ErrFieldTypeNotSupported) {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_parseTypeOverrides(t *testing.T) {
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		typeOverrides, err := parseTypeOverrides(testEmptyString)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(typeOverrides) != 0 {
+			t.Errorf("parseTypeOverrides: current=%#v", typeOverrides)
+		}
+	})
+
+	t.Run("正常系_type_overrides", func(t *testing.T) {
+		typeOverrides, err := parseTypeOverrides("geography=github.com/twpayne/go-geom:geom.T, TIMESTAMP=int64")
+		if err != nil {
+			t.Error(err)
+		}
+		if v := typeOverrides[bigquery.GeographyFieldType]; v != (generator.GoType{Name: "geom.T", PkgPath: "github.com/twpayne/go-geom"}) {
+			t.Errorf("parseTypeOverrides: current=%#v", v)
+		}
+		if v := typeOverrides[bigquery.TimestampFieldType]; v != (generator.GoType{Name: "int64"}) {
+			t.Errorf("parseTypeOverrides: current=%#v", v)
+		}
+	})
+
+	t.Run("異常系_invalid_format", func(t *testing.T) {
+		for _, s := range []string{"TIMESTAMP", "TIMESTAMP=", "=int64", "TIMESTAMP=database/sql:"} {
+			if _, err := parseTypeOverrides(s); err == nil {
+				t.Error("parseTypeOverrides: " + s)
 			}
 		}
 	})
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// parseModifiedSince parses the RFC 3339 timestamp of -modified-since. The empty string is the zero time.
+func parseModifiedSince(s string) (since time.Time, err error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	since, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time.Parse: -%s must be an RFC 3339 timestamp. e.g. 2006-01-02T15:04:05Z: %w", optNameModifiedSince, err)
+	}
+	return since, nil
+}
+
+// isModifiedSince returns true if the table has been modified after since.
+// The schema files have no last modified time, so the tables of them are always modified.
+func isModifiedSince(md *bigquery.TableMetadata, since time.Time) bool {
+	return md.LastModifiedTime.IsZero() || md.LastModifiedTime.After(since)
+}
+
+// readPreservedStructs returns the code in the existing Go outputs of the tables in schemas not modified after since,
+// keyed by table ID. The tables not found in the outputs are not in it, so they are generated.
+func readPreservedStructs(goPaths []string, schemas []generator.TableSchema, projectID, datasetID string, since time.Time) (preserved map[string]generator.PreservedStruct, err error) {
+	existing := make(map[string]generator.PreservedStruct)
+	for _, goPath := range goPaths {
+		var content []byte
+		content, err = readFile(goPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("readFile: %w", err)
+		}
+		var structs map[string]generator.PreservedStruct
+		structs, err = generator.PreservedStructs(content, projectID, datasetID)
+		if err != nil {
+			return nil, fmt.Errorf("preservedStructs: %s: %w", goPath, err)
+		}
+		for tableID, s := range structs {
+			existing[tableID] = s
+		}
+	}
+
+	preserved = make(map[string]generator.PreservedStruct)
+	for _, schema := range schemas {
+		if s, exist := existing[schema.Table.TableID]; exist && !isModifiedSince(schema.Metadata, since) {
+			preserved[schema.Table.TableID] = s
+		}
+	}
+
+	return preserved, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+func newModifiedSinceTestSchemas(lastModifiedTime time.Time) (schemas []generator.TableSchema) {
+	return []generator.TableSchema{
+		{
+			Table: &bigquery.Table{ProjectID: "project", DatasetID: "dataset", TableID: "users"},
+			Metadata: &bigquery.TableMetadata{
+				FullID:           "project:dataset.users",
+				LastModifiedTime: lastModifiedTime,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "created_at", Type: bigquery.TimestampFieldType},
+					{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+						{Name: "city", Type: bigquery.StringFieldType},
+					}},
+				},
+			},
+		},
+		{
+			Table: &bigquery.Table{ProjectID: "project", DatasetID: "dataset", TableID: "events"},
+			Metadata: &bigquery.TableMetadata{
+				FullID:           "project:dataset.events",
+				LastModifiedTime: lastModifiedTime,
+				Schema: bigquery.Schema{
+					{Name: "name", Type: bigquery.StringFieldType},
+				},
+			},
+		},
+	}
+}
+
+func Test_parseModifiedSince(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		since, err := parseModifiedSince("2020-11-01T09:00:00+09:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC); !since.Equal(expect) {
+			t.Errorf("parseModifiedSince: want=%s current=%s", expect, since)
+		}
+	})
+
+	t.Run("正常系_empty", func(t *testing.T) {
+		since, err := parseModifiedSince("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !since.IsZero() {
+			t.Errorf("parseModifiedSince: want=zero current=%s", since)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		if _, err := parseModifiedSince("2020-11-01"); err == nil {
+			t.Error("parseModifiedSince: err == nil")
+		}
+	})
+}
+
+func Test_readPreservedStructs(t *testing.T) {
+	var (
+		testDir      = t.TempDir()
+		testFilePath = filepath.Join(testDir, "bqschema.generated.go")
+		since        = time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	)
+	generatedCode, err := generator.GenerateCode(newModifiedSinceTestSchemas(time.Time{}), generator.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(testFilePath, generatedCode, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("正常系", func(t *testing.T) {
+		schemas := newModifiedSinceTestSchemas(since.Add(-time.Hour))
+		schemas[1].Metadata.LastModifiedTime = since.Add(time.Hour)
+		schemas = append(schemas, generator.TableSchema{
+			Table:    &bigquery.Table{ProjectID: "project", DatasetID: "dataset", TableID: "logs"},
+			Metadata: &bigquery.TableMetadata{FullID: "project:dataset.logs", LastModifiedTime: since.Add(-time.Hour)},
+		})
+		preserved, err := readPreservedStructs([]string{testFilePath, filepath.Join(testDir, "not_found.go")}, schemas, "project", "dataset", since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// events is modified, and logs is not in the file
+		if _, exist := preserved["users"]; len(preserved) != 1 || !exist {
+			t.Errorf("readPreservedStructs: want=[users] current=%v", preserved)
+		}
+	})
+
+	t.Run("正常系_no_last_modified_time", func(t *testing.T) {
+		preserved, err := readPreservedStructs([]string{testFilePath}, newModifiedSinceTestSchemas(time.Time{}), "project", "dataset", since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(preserved) != 0 {
+			t.Errorf("readPreservedStructs: want=0 current=%d", len(preserved))
+		}
+	})
+}
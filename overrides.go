@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// readTableOverrides reads the per-table overrides keyed by table ID from the JSON file.
+func readTableOverrides(filePath string) (overrides map[string]generator.TableOverride, err error) {
+	var content []byte
+	content, err = ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile: %w", err)
+	}
+
+	// Reject unknown keys, so that a typo is not silently ignored
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("decoder.Decode: %s: %w", filePath, err)
+	}
+
+	for tableID, override := range overrides {
+		if len(override.IncludeColumns) > 0 && len(override.ExcludeColumns) > 0 {
+			return nil, fmt.Errorf("%s: table %s: include_columns and exclude_columns are mutually exclusive", filePath, tableID)
+		}
+		for column, tag := range override.ColumnTags {
+			if _, err = generator.SplitStructTag(tag); err != nil {
+				return nil, fmt.Errorf("%s: table %s: column_tags: column %s: %w", filePath, tableID, column, err)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+// readNameExceptions reads the output casings keyed by snake_case segment from the JSON file. e.g. {"ios": "IOS"}
+// The keys are normalized to lower case, and the values must differ from the keys only in case.
+func readNameExceptions(filePath string) (exceptions map[string]string, err error) {
+	var content []byte
+	content, err = ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile: %w", err)
+	}
+
+	var raw map[string]string
+	if err = json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %s: %w", filePath, err)
+	}
+
+	exceptions = make(map[string]string, len(raw))
+	for segment, casing := range raw {
+		if segment == "" || strings.Contains(segment, "_") || !strings.EqualFold(segment, casing) {
+			return nil, fmt.Errorf("%s: %q: %q is not a casing of a segment", filePath, segment, casing)
+		}
+		exceptions[strings.ToLower(segment)] = casing
+	}
+
+	return exceptions, nil
+}
+
+// readTypeAliases reads the BigQuery field types keyed by the type names of non-standard sources from the JSON file.
+// e.g. {"DATETIME2": "DATETIME"} The keys are normalized to upper case, and must not be the built-in aliases
+// (e.g. INT64), which are normalized before the aliases are applied. The values must be the supported types or their aliases.
+func readTypeAliases(filePath string) (aliases map[bigquery.FieldType]bigquery.FieldType, err error) {
+	var content []byte
+	content, err = ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile: %w", err)
+	}
+
+	var raw map[string]string
+	if err = json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %s: %w", filePath, err)
+	}
+
+	aliases = make(map[bigquery.FieldType]bigquery.FieldType, len(raw))
+	for typeName, fieldType := range raw {
+		alias := bigquery.FieldType(strings.ToUpper(strings.TrimSpace(typeName)))
+		if alias == "" {
+			return nil, fmt.Errorf("%s: the type name is empty", filePath)
+		}
+		if builtin := generator.NormalizeFieldType(alias); builtin != alias {
+			return nil, fmt.Errorf("%s: %q is a built-in alias of %s", filePath, typeName, builtin)
+		}
+		if _, exist := aliases[alias]; exist {
+			return nil, fmt.Errorf("%s: %q is given more than once", filePath, typeName)
+		}
+		normalized := generator.NormalizeFieldType(bigquery.FieldType(fieldType))
+		if _, _, goTypeErr := generator.BigQueryFieldTypeToGoType(normalized); goTypeErr != nil && normalized != bigquery.RecordFieldType {
+			return nil, fmt.Errorf("%s: %q: %q is not a supported BigQuery type", filePath, typeName, fieldType)
+		}
+		aliases[alias] = normalized
+	}
+
+	return aliases, nil
+}
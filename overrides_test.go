@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+func Test_readTableOverrides(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "overrides.json")
+		if err := ioutil.WriteFile(testFilePath, []byte(`{"users": {"struct_name": "User", "exclude_columns": ["password"], "column_tags": {"email": "validate:\"email\""}}, "events": {"include_columns": ["id"], "set_columns": ["tags"]}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		overrides, err := readTableOverrides(testFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]generator.TableOverride{
+			"users":  {StructName: "User", ExcludeColumns: []string{"password"}, ColumnTags: map[string]string{"email": `validate:"email"`}},
+			"events": {IncludeColumns: []string{"id"}, SetColumns: []string{"tags"}},
+		}
+		if !reflect.DeepEqual(overrides, expect) {
+			t.Errorf("readTableOverrides: want=%v current=%v", expect, overrides)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, content := range []string{
+			`{"users": {"structname": "User"}}`,
+			`{"users": {"include_columns": ["id"], "exclude_columns": ["password"]}}`,
+			`{"users": {"column_tags": {"email": "validate:email"}}}`,
+			`[]`,
+		} {
+			testFilePath := filepath.Join(t.TempDir(), "overrides.json")
+			if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readTableOverrides(testFilePath); err == nil {
+				t.Errorf("readTableOverrides: %s: err == nil", content)
+			}
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := readTableOverrides(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error("readTableOverrides: err == nil")
+		}
+	})
+}
+
+func Test_readNameExceptions(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "name_exceptions.json")
+		if err := ioutil.WriteFile(testFilePath, []byte(`{"ios": "IOS", "OAuth": "OAuth"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		exceptions, err := readNameExceptions(testFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]string{"ios": "IOS", "oauth": "OAuth"}
+		if !reflect.DeepEqual(exceptions, expect) {
+			t.Errorf("readNameExceptions: want=%v current=%v", expect, exceptions)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, content := range []string{
+			`{"ios": "iPhoneOS"}`,
+			`{"ios_app": "IOS_App"}`,
+			`{"": ""}`,
+			`[]`,
+		} {
+			testFilePath := filepath.Join(t.TempDir(), "name_exceptions.json")
+			if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readNameExceptions(testFilePath); err == nil {
+				t.Errorf("readNameExceptions: %s: err == nil", content)
+			}
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := readNameExceptions(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error("readNameExceptions: err == nil")
+		}
+	})
+}
+
+func Test_readTypeAliases(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "type_aliases.json")
+		content := `{
+			"datetime2": "DATETIME",
+			"TIMESTAMP WITHOUT TIME ZONE": "DATETIME",
+			"TIMESTAMP WITH TIME ZONE": "TIMESTAMP",
+			"TIMESTAMP": "DATETIME",
+			"VARCHAR": "string",
+			"DOUBLE": "FLOAT64",
+			"NESTED": "RECORD"
+		}`
+		if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		aliases, err := readTypeAliases(testFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[bigquery.FieldType]bigquery.FieldType{
+			"DATETIME2":                   bigquery.DateTimeFieldType,
+			"TIMESTAMP WITHOUT TIME ZONE": bigquery.DateTimeFieldType,
+			"TIMESTAMP WITH TIME ZONE":    bigquery.TimestampFieldType,
+			"TIMESTAMP":                   bigquery.DateTimeFieldType,
+			"VARCHAR":                     bigquery.StringFieldType,
+			"DOUBLE":                      bigquery.FloatFieldType,
+			"NESTED":                      bigquery.RecordFieldType,
+		}
+		if !reflect.DeepEqual(aliases, expect) {
+			t.Errorf("readTypeAliases: want=%v current=%v", expect, aliases)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, content := range []string{
+			`{"DATETIME2": "DATETIME2"}`,
+			`{"INT64": "STRING"}`,
+			`{"varchar": "STRING", "VARCHAR": "STRING"}`,
+			`{"": "STRING"}`,
+			`[]`,
+		} {
+			testFilePath := filepath.Join(t.TempDir(), "type_aliases.json")
+			if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readTypeAliases(testFilePath); err == nil {
+				t.Errorf("readTypeAliases: %s: err == nil", content)
+			}
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := readTypeAliases(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error("readTypeAliases: err == nil")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+const outputFormatProtoNumbers = "proto-numbers"
+
+const (
+	// ref. https://protobuf.dev/programming-guides/proto3/#assigning
+	protoNumberReservedMin = 19000
+	protoNumberReservedMax = 19999
+	protoNumberMax         = 1<<29 - 1
+)
+
+// protoNumbers is the protobuf field numbers persisted in -proto-numbers-file, keyed by message and lower-cased column name.
+// The message of the top-level columns is the table ID, and the one of the fields of a RECORD column is the path of
+// the column. e.g. `users.address`
+type protoNumbers map[string]map[string]int
+
+// readProtoNumbers reads the field numbers from the JSON file. It returns empty numbers if the file does not exist.
+func readProtoNumbers(filePath string) (numbers protoNumbers, err error) {
+	var content []byte
+	content, err = readFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return protoNumbers{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&numbers); err != nil {
+		return nil, fmt.Errorf("decoder.Decode: %s: %w", filePath, err)
+	}
+	if numbers == nil {
+		numbers = protoNumbers{}
+	}
+
+	// A broken file would silently renumber the fields, so reject it instead
+	for message, columns := range numbers {
+		used := make(map[int]string)
+		for column, number := range columns {
+			if column != strings.ToLower(column) {
+				return nil, fmt.Errorf("%s: message %s: column %s is not lower-cased", filePath, message, column)
+			}
+			if !isValidProtoNumber(number) {
+				return nil, fmt.Errorf("%s: message %s: column %s: %d is not a valid field number", filePath, message, column, number)
+			}
+			if other, exist := used[number]; exist {
+				return nil, fmt.Errorf("%s: message %s: columns %s and %s have the same field number %d", filePath, message, other, column, number)
+			}
+			used[number] = column
+		}
+	}
+
+	return numbers, nil
+}
+
+// assignProtoNumbers returns the numbers with the field numbers of the columns in the schemas not in them yet,
+// and the field numbers of all the columns in the schemas. The numbers are not modified.
+// A new column gets the number next to the largest one of the message, including the numbers of the removed columns,
+// so that the numbers are never reused.
+func assignProtoNumbers(schemas []generator.TableSchema, numbers protoNumbers) (assigned protoNumbers, fieldNumbers map[*bigquery.FieldSchema]int) {
+	assigned = make(protoNumbers, len(numbers))
+	for message, columns := range numbers {
+		assigned[message] = make(map[string]int, len(columns))
+		for column, number := range columns {
+			assigned[message][column] = number
+		}
+	}
+	fieldNumbers = make(map[*bigquery.FieldSchema]int)
+
+	for _, schema := range schemas {
+		assignMessageProtoNumbers(schema.Table.TableID, schema.Metadata.Schema, assigned, fieldNumbers)
+	}
+
+	return assigned, fieldNumbers
+}
+
+func assignMessageProtoNumbers(message string, schema bigquery.Schema, assigned protoNumbers, fieldNumbers map[*bigquery.FieldSchema]int) {
+	columns, exist := assigned[message]
+	if !exist {
+		columns = make(map[string]int)
+		assigned[message] = columns
+	}
+
+	var next int
+	for _, number := range columns {
+		if number > next {
+			next = number
+		}
+	}
+
+	for _, field := range schema {
+		// BigQuery column names are case-insensitive.
+		column := strings.ToLower(field.Name)
+		number, exist := columns[column]
+		if !exist {
+			next++
+			if next >= protoNumberReservedMin && next <= protoNumberReservedMax {
+				next = protoNumberReservedMax + 1
+			}
+			number = next
+			columns[column] = number
+		}
+		fieldNumbers[field] = number
+
+		if field.Type == bigquery.RecordFieldType {
+			assignMessageProtoNumbers(message+"."+column, field.Schema, assigned, fieldNumbers)
+		}
+	}
+}
+
+// marshalProtoNumbers returns the JSON of the numbers written to -proto-numbers-file, with the keys sorted.
+func marshalProtoNumbers(numbers protoNumbers) (content []byte, err error) {
+	content, err = json.MarshalIndent(numbers, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	return append(content, '\n'), nil
+}
+
+// isValidProtoNumber returns true if number is a protobuf field number that can be assigned to a field.
+func isValidProtoNumber(number int) bool {
+	return number >= 1 && number <= protoNumberMax && (number < protoNumberReservedMin || number > protoNumberReservedMax)
+}
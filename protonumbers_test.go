@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+func Test_readProtoNumbers(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testFilePath := filepath.Join(t.TempDir(), "bqschema.protonumbers.json")
+		if err := ioutil.WriteFile(testFilePath, []byte(`{"users": {"id": 1, "name": 3}, "users.address": {"city": 1}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		numbers, err := readProtoNumbers(testFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := protoNumbers{"users": {"id": 1, "name": 3}, "users.address": {"city": 1}}
+		if !reflect.DeepEqual(numbers, expect) {
+			t.Errorf("readProtoNumbers: want=%v current=%v", expect, numbers)
+		}
+	})
+
+	t.Run("正常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		numbers, err := readProtoNumbers(testErrNoSuchFileOrDirectoryPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(numbers) != 0 {
+			t.Errorf("readProtoNumbers: want=empty current=%v", numbers)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, content := range []string{
+			`{"users": {"id": 1, "name": 1}}`,
+			`{"users": {"id": 0}}`,
+			`{"users": {"id": 19000}}`,
+			`{"users": {"ID": 1}}`,
+			`[]`,
+		} {
+			testFilePath := filepath.Join(t.TempDir(), "bqschema.protonumbers.json")
+			if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readProtoNumbers(testFilePath); err == nil {
+				t.Errorf("readProtoNumbers: %s: err == nil", content)
+			}
+		}
+	})
+}
+
+func Test_assignProtoNumbers(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		id := &bigquery.FieldSchema{Name: "id", Type: bigquery.IntegerFieldType}
+		email := &bigquery.FieldSchema{Name: "Email", Type: bigquery.StringFieldType}
+		city := &bigquery.FieldSchema{Name: "city", Type: bigquery.StringFieldType}
+		address := &bigquery.FieldSchema{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{city}}
+		schemas := []generator.TableSchema{{
+			Table:    &bigquery.Table{TableID: "users"},
+			Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{email, id, address}},
+		}}
+		// `name` is removed, and `email` and `address` are added
+		numbers := protoNumbers{"users": {"id": 1, "name": 2}}
+
+		assigned, fieldNumbers := assignProtoNumbers(schemas, numbers)
+
+		expectAssigned := protoNumbers{
+			"users":         {"id": 1, "name": 2, "email": 3, "address": 4},
+			"users.address": {"city": 1},
+		}
+		if !reflect.DeepEqual(assigned, expectAssigned) {
+			t.Errorf("assignProtoNumbers: want=%v current=%v", expectAssigned, assigned)
+		}
+		expectFieldNumbers := map[*bigquery.FieldSchema]int{id: 1, email: 3, address: 4, city: 1}
+		if !reflect.DeepEqual(fieldNumbers, expectFieldNumbers) {
+			t.Errorf("assignProtoNumbers: want=%v current=%v", expectFieldNumbers, fieldNumbers)
+		}
+		if !reflect.DeepEqual(numbers, protoNumbers{"users": {"id": 1, "name": 2}}) {
+			t.Errorf("assignProtoNumbers: numbers are modified: %v", numbers)
+		}
+
+		// The numbers are stable across runs
+		reassigned, _ := assignProtoNumbers(schemas, assigned)
+		if !reflect.DeepEqual(reassigned, expectAssigned) {
+			t.Errorf("assignProtoNumbers: want=%v current=%v", expectAssigned, reassigned)
+		}
+	})
+
+	t.Run("正常系_reserved", func(t *testing.T) {
+		schemas := []generator.TableSchema{{
+			Table:    &bigquery.Table{TableID: "users"},
+			Metadata: &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}}},
+		}}
+		assigned, _ := assignProtoNumbers(schemas, protoNumbers{"users": {"name": 18999}})
+		if number := assigned["users"]["id"]; number != 20000 {
+			t.Errorf("assignProtoNumbers: want=20000 current=%d", number)
+		}
+	})
+}
+
+func Test_marshalProtoNumbers(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		content, err := marshalProtoNumbers(protoNumbers{"users": {"name": 2, "id": 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		const expect = "{\n  \"users\": {\n    \"id\": 1,\n    \"name\": 2\n  }\n}\n"
+		if string(content) != expect {
+			t.Errorf("marshalProtoNumbers: want=%q current=%q", expect, content)
+		}
+	})
+}
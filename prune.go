@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// staleGeneratedFiles returns the `.generated.go` files in dir, and their fakes and helpers, that have been generated by this
+// command only for tables of the dataset that are not in tableIDs. The files in keep are never returned.
+// Files without the `DO NOT EDIT` header of this command, e.g. hand-written ones, are never returned either.
+func staleGeneratedFiles(dir, projectID, datasetID string, tableIDs, keep map[string]bool) (stale []string, err error) {
+	var paths []string
+	paths, err = filepath.Glob(filepath.Join(dir, "*.generated.go"))
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Glob: %w", err)
+	}
+
+	for _, path := range paths {
+		if keep[filepath.Clean(path)] {
+			continue
+		}
+
+		var content []byte
+		content, err = readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("readFile: %w", err)
+		}
+		if !isGeneratedFile(content) || !isStaleGeneratedFile(content, projectID, datasetID, tableIDs) {
+			continue
+		}
+		stale = append(stale, path)
+
+		// The fakes and the helpers of the structs, which have no struct comments
+		for _, companionPath := range []string{fakeFilePath(path), helpersFilePath(path)} {
+			if keep[filepath.Clean(companionPath)] {
+				continue
+			}
+			var companionContent []byte
+			companionContent, err = readFile(companionPath)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("readFile: %w", err)
+			}
+			if isGeneratedFile(companionContent) {
+				stale = append(stale, companionPath)
+			}
+		}
+	}
+	sort.Strings(stale)
+
+	return stale, nil
+}
+
+// isGeneratedFile returns true if the content starts with the `DO NOT EDIT` header of this command.
+func isGeneratedFile(content []byte) bool {
+	firstLine := content
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+	return string(bytes.TrimSuffix(firstLine, []byte("\r"))) == generator.GeneratedFileHeader
+}
+
+// isStaleGeneratedFile returns true if the content has structs, all of which are of the tables of the dataset
+// that are not in tableIDs.
+func isStaleGeneratedFile(content []byte, projectID, datasetID string, tableIDs map[string]bool) bool {
+	matches := generator.StructTableRegexp.FindAllSubmatch(content, -1)
+	if len(matches) == 0 {
+		return false
+	}
+	for _, match := range matches {
+		if string(match[1]) != projectID || string(match[2]) != datasetID || tableIDs[string(match[3])] {
+			return false
+		}
+	}
+	return true
+}
+
+// removeFiles removes the files, and logs each of them.
+func removeFiles(paths []string) (err error) {
+	for _, path := range paths {
+		if err = os.Remove(path); err != nil {
+			return fmt.Errorf("os.Remove: %w", err)
+		}
+		infoln("-" + optNamePrune + ": removed " + path)
+	}
+	return nil
+}
+
+// missingTableOutputPaths returns the paths of -table-output all of whose tables are not in tableIDs.
+func missingTableOutputPaths(tableOutputs map[string]string, tableIDs map[string]bool) (missingPaths map[string]bool) {
+	missingPaths = make(map[string]bool)
+	for tableID, routedPath := range tableOutputs {
+		if _, exist := missingPaths[routedPath]; !exist {
+			missingPaths[routedPath] = true
+		}
+		if tableIDs[tableID] {
+			missingPaths[routedPath] = false
+		}
+	}
+	for routedPath, missing := range missingPaths {
+		if !missing {
+			delete(missingPaths, routedPath)
+		}
+	}
+	return missingPaths
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+func Test_staleGeneratedFiles(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testDir := t.TempDir()
+		generated := func(tables ...string) string {
+			content := generator.GeneratedFileHeader + "\n\npackage bqschema\n"
+			for _, table := range tables {
+				content = content + "\n// S is BigQuery Table `p:d." + table + "` schema struct.\ntype S struct{}\n"
+			}
+			return content
+		}
+		for name, content := range map[string]string{
+			"bqschema.generated.go":       generated("users"),
+			"events.generated.go":         generated("events"),
+			"events_fake.generated.go":    generator.GeneratedFileHeader + "\n\npackage bqschema\n",
+			"events_helpers.generated.go": generator.GeneratedFileHeader + "\n\npackage bqschema\n",
+			"logs.generated.go":           generated("logs", "users"),
+			"crlf.generated.go":           "// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.\r\n\r\npackage bqschema\r\n\r\n// S is BigQuery Table `p:d.crlf` schema struct.\r\ntype S struct{}\r\n",
+			"other_dataset.generated.go":  "// Code generated by go run github.com/ginokent/bqschema-gen-go; DO NOT EDIT.\n\n// S is BigQuery Table `p:other.old` schema struct.\n",
+			"handwritten.generated.go":    "package bqschema\n\n// S is BigQuery Table `p:d.old` schema struct.\ntype S struct{}\n",
+			"other_tool.generated.go":     "// Code generated by other-tool; DO NOT EDIT.\n\n// S is BigQuery Table `p:d.old` schema struct.\n",
+			"empty.generated.go":          generated(),
+			"old.go":                      generated("old"),
+		} {
+			if err := ioutil.WriteFile(filepath.Join(testDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keep := map[string]bool{filepath.Join(testDir, "bqschema.generated.go"): true}
+		stale, err := staleGeneratedFiles(testDir, "p", "d", map[string]bool{"users": true}, keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []string{
+			filepath.Join(testDir, "crlf.generated.go"),
+			filepath.Join(testDir, "events.generated.go"),
+			filepath.Join(testDir, "events_fake.generated.go"),
+			filepath.Join(testDir, "events_helpers.generated.go"),
+		}
+		if !reflect.DeepEqual(stale, expect) {
+			t.Errorf("staleGeneratedFiles: want=%v current=%v", expect, stale)
+		}
+	})
+}
+
+func Test_missingTableOutputPaths(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		tableOutputs := map[string]string{"events": "events.go", "event_logs": "events.go", "old": "old.go", "logs": "logs.go"}
+		missingPaths := missingTableOutputPaths(tableOutputs, map[string]bool{"events": true, "logs": true})
+		if expect := map[string]bool{"old.go": true}; !reflect.DeepEqual(missingPaths, expect) {
+			t.Errorf("missingTableOutputPaths: want=%v current=%v", expect, missingPaths)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// parseQueryParams parses a comma-separated list of the named parameters of -query. e.g. `day:DATE:2020-11-01,limit:INT64:10`
+func parseQueryParams(s string) (params []bigquery.QueryParameter, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	names := make(map[string]bool)
+	for _, param := range strings.Split(s, ",") {
+		// The value may contain `:`, e.g. TIME and TIMESTAMP
+		parts := strings.SplitN(strings.TrimSpace(param), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid parameter `%s`. format: name:type:value", param)
+		}
+		if names[parts[0]] {
+			return nil, fmt.Errorf("parameter %s is given more than once", parts[0])
+		}
+		names[parts[0]] = true
+
+		var value interface{}
+		value, err = queryParamValue(generator.NormalizeFieldType(bigquery.FieldType(parts[1])), parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("queryParamValue: parameter %s: %w", parts[0], err)
+		}
+		params = append(params, bigquery.QueryParameter{Name: parts[0], Value: value})
+	}
+
+	return params, nil
+}
+
+// queryParamValue converts the value to the Go type the bigquery package sends as a parameter of fieldType.
+func queryParamValue(fieldType bigquery.FieldType, value string) (paramValue interface{}, err error) {
+	switch fieldType {
+	case bigquery.StringFieldType:
+		return value, nil
+	case bigquery.BytesFieldType:
+		return []byte(value), nil
+	case bigquery.IntegerFieldType:
+		return strconv.ParseInt(value, 10, 64)
+	case bigquery.FloatFieldType:
+		return strconv.ParseFloat(value, 64)
+	case bigquery.BooleanFieldType:
+		return strconv.ParseBool(value)
+	case bigquery.NumericFieldType:
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC `%s`", value)
+		}
+		return r, nil
+	case bigquery.TimestampFieldType:
+		return time.Parse(time.RFC3339Nano, value)
+	case bigquery.DateFieldType:
+		return civil.ParseDate(value)
+	case bigquery.TimeFieldType:
+		return civil.ParseTime(value)
+	case bigquery.DateTimeFieldType:
+		return civil.ParseDateTime(value)
+	}
+	return nil, fmt.Errorf("type %s is not supported as a parameter", fieldType)
+}
+
+// getQuerySchemas returns the result schema of the query as the schema of the table named name.
+// The query is validated by a dry run, which returns the schema without executing the query.
+func getQuerySchemas(ctx context.Context, client *bigquery.Client, query, name string, params []bigquery.QueryParameter) (schemas []generator.TableSchema, err error) {
+	q := client.Query(query)
+	q.Parameters = params
+	q.DryRun = true
+
+	var job *bigquery.Job
+	job, err = q.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("q.Run: %w", err)
+	}
+
+	status := job.LastStatus()
+	if status == nil || status.Statistics == nil {
+		return nil, fmt.Errorf("the dry run of the query returned no statistics")
+	}
+	stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics)
+	if !ok || len(stats.Schema) == 0 {
+		return nil, fmt.Errorf("the dry run of the query returned no result schema")
+	}
+
+	return []generator.TableSchema{{
+		Table:    &bigquery.Table{TableID: name},
+		Metadata: &bigquery.TableMetadata{Name: name, FullID: name, Schema: stats.Schema},
+	}}, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+)
+
+func Test_parseQueryParams(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		params, err := parseQueryParams("day:DATE:2020-11-01, at:TIMESTAMP:2020-11-01T09:00:00Z,limit:INT64:10,name:STRING:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []bigquery.QueryParameter{
+			{Name: "day", Value: civil.Date{Year: 2020, Month: 11, Day: 1}},
+			{Name: "at", Value: time.Date(2020, 11, 1, 9, 0, 0, 0, time.UTC)},
+			{Name: "limit", Value: int64(10)},
+			{Name: "name", Value: ""},
+		}
+		if !reflect.DeepEqual(params, expect) {
+			t.Errorf("parseQueryParams: want=%v current=%v", expect, params)
+		}
+	})
+
+	t.Run("正常系_empty", func(t *testing.T) {
+		params, err := parseQueryParams("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if params != nil {
+			t.Errorf("parseQueryParams: want=nil current=%v", params)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"day", "day:DATE", ":DATE:2020-11-01", "day:DATE:2020-11-01,day:DATE:2020-11-02", "day:DATE:20201101", "address:RECORD:x"} {
+			if _, err := parseQueryParams(s); err == nil {
+				t.Errorf("parseQueryParams: %s: err == nil", s)
+			}
+		}
+	})
+}
+
+func Test_queryParamValue(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			fieldType bigquery.FieldType
+			value     string
+			expect    interface{}
+		}{
+			{bigquery.StringFieldType, "a:b", "a:b"},
+			{bigquery.BytesFieldType, "ab", []byte("ab")},
+			{bigquery.IntegerFieldType, "-1", int64(-1)},
+			{bigquery.FloatFieldType, "1.5", float64(1.5)},
+			{bigquery.BooleanFieldType, "true", true},
+			{bigquery.NumericFieldType, "1.25", big.NewRat(5, 4)},
+			{bigquery.TimeFieldType, "09:00:00", civil.Time{Hour: 9}},
+			{bigquery.DateTimeFieldType, "2020-11-01T09:00:00", civil.DateTime{Date: civil.Date{Year: 2020, Month: 11, Day: 1}, Time: civil.Time{Hour: 9}}},
+		} {
+			value, err := queryParamValue(tt.fieldType, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, tt.expect) {
+				t.Errorf("queryParamValue: %s: want=%v current=%v", tt.fieldType, tt.expect, value)
+			}
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for fieldType, value := range map[bigquery.FieldType]string{
+			bigquery.IntegerFieldType:   "1.5",
+			bigquery.NumericFieldType:   "one",
+			bigquery.GeographyFieldType: "POINT(0 0)",
+		} {
+			if _, err := queryParamValue(fieldType, value); err == nil {
+				t.Errorf("queryParamValue: %s: err == nil", fieldType)
+			}
+		}
+	})
+}
+
+func Test_getQuerySchemas(t *testing.T) {
+	newClient := func(t *testing.T, response interface{}, request *map[string]interface{}) *bigquery.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(request)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := bigquery.NewClient(context.Background(), "project", option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	}
+
+	t.Run("正常系", func(t *testing.T) {
+		var request map[string]interface{}
+		client := newClient(t, map[string]interface{}{
+			"jobReference": map[string]interface{}{"projectId": "project", "jobId": "job"},
+			"status":       map[string]interface{}{"state": "DONE"},
+			"statistics": map[string]interface{}{"query": map[string]interface{}{"schema": map[string]interface{}{"fields": []map[string]interface{}{
+				{"name": "day", "type": "DATE", "mode": "NULLABLE"},
+				{"name": "sales", "type": "INTEGER", "mode": "REQUIRED"},
+			}}}},
+		}, &request)
+
+		params := []bigquery.QueryParameter{{Name: "day", Value: civil.Date{Year: 2020, Month: 11, Day: 1}}}
+		schemas, err := getQuerySchemas(context.Background(), client, "SELECT day, sales FROM dataset.sales WHERE day = @day", "daily_sales", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schemas) != 1 || schemas[0].Table.TableID != "daily_sales" || len(schemas[0].Metadata.Schema) != 2 || schemas[0].Metadata.Schema[1].Type != bigquery.IntegerFieldType {
+			t.Errorf("getQuerySchemas: current=%v", schemas)
+		}
+
+		configuration, _ := request["configuration"].(map[string]interface{})
+		if configuration["dryRun"] != true {
+			t.Errorf("getQuerySchemas: the query is not a dry run: %v", request)
+		}
+		query, _ := configuration["query"].(map[string]interface{})
+		if parameters, _ := query["queryParameters"].([]interface{}); len(parameters) != 1 {
+			t.Errorf("getQuerySchemas: the parameters are not sent: %v", request)
+		}
+	})
+
+	t.Run("異常系_no_schema", func(t *testing.T) {
+		var request map[string]interface{}
+		client := newClient(t, map[string]interface{}{
+			"jobReference": map[string]interface{}{"projectId": "project", "jobId": "job"},
+			"status":       map[string]interface{}{"state": "DONE"},
+		}, &request)
+
+		if _, err := getQuerySchemas(context.Background(), client, "SELECT 1", "query_result", nil); err == nil {
+			t.Error("getQuerySchemas: err == nil")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// getTableSchemasFromDir is the same as getTableSchemasFromGCS, but reads the schema JSON files in dir.
+// It does not access BigQuery. Files in sub-directories are ignored.
+func getTableSchemasFromDir(dir, projectID, datasetID string) (schemas []generator.TableSchema, err error) {
+	var paths []string
+	paths, err = filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Glob: %w", err)
+	}
+	// Fix the order
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		var content []byte
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ioutil.ReadFile: %w", err)
+		}
+
+		tableID := strings.TrimSuffix(filepath.Base(path), ".json")
+
+		var schema generator.TableSchema
+		schema, err = tableSchemaFromJSON(content, projectID, datasetID, tableID)
+		if err != nil {
+			return nil, fmt.Errorf("tableSchemaFromJSON: %s: %w", path, err)
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+// tableSchemaFromJSON returns the schema of the table in the JSON exported by `bq show --schema`.
+func tableSchemaFromJSON(content []byte, projectID, datasetID, tableID string) (schema generator.TableSchema, err error) {
+	var fields bigquery.Schema
+	fields, err = bigquery.SchemaFromJSON(content)
+	if err != nil {
+		return generator.TableSchema{}, fmt.Errorf("bigquery.SchemaFromJSON: %w", err)
+	}
+	generator.NormalizeSchema(fields)
+
+	return generator.TableSchema{
+		Table: &bigquery.Table{ProjectID: projectID, DatasetID: datasetID, TableID: tableID},
+		Metadata: &bigquery.TableMetadata{
+			FullID: projectID + ":" + datasetID + "." + tableID,
+			Schema: fields,
+		},
+	}, nil
+}
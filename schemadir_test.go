@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func Test_getTableSchemasFromDir(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testDir := t.TempDir()
+		for name, content := range map[string]string{
+			"users.json":        `[{"name": "id", "type": "INT64", "mode": "REQUIRED"}, {"name": "address", "type": "STRUCT", "fields": [{"name": "city", "type": "STRING"}]}]`,
+			"events.json":       `[{"name": "ts", "type": "TIMESTAMP"}]`,
+			"README.md":         "not a schema",
+			"sub/ignored.json":  `[{"name": "id", "type": "INTEGER"}]`,
+			"sub/.keep.ignored": "",
+		} {
+			testFilePath := filepath.Join(testDir, name)
+			if err := os.MkdirAll(filepath.Dir(testFilePath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(testFilePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		schemas, err := getTableSchemasFromDir(testDir, "p", "d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schemas) != 2 {
+			t.Fatalf("getTableSchemasFromDir: want=2 current=%d", len(schemas))
+		}
+		if schemas[0].Table.TableID != "events" || schemas[1].Table.TableID != "users" {
+			t.Errorf("getTableSchemasFromDir: want=events,users current=%s,%s", schemas[0].Table.TableID, schemas[1].Table.TableID)
+		}
+		if fullID := schemas[1].Metadata.FullID; fullID != "p:d.users" {
+			t.Errorf("getTableSchemasFromDir: want=p:d.users current=%s", fullID)
+		}
+		users := schemas[1].Metadata.Schema
+		if users[0].Type != bigquery.IntegerFieldType || !users[0].Required || users[1].Schema[0].Type != bigquery.StringFieldType {
+			t.Errorf("getTableSchemasFromDir: schema is not normalized: %v", users)
+		}
+	})
+
+	t.Run("異常系_invalid_json", func(t *testing.T) {
+		testDir := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(testDir, "users.json"), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := getTableSchemasFromDir(testDir, "p", "d"); err == nil {
+			t.Error("getTableSchemasFromDir: err == nil")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"strings"
+)
+
+const outputFormatGoHelpers = "go-helpers"
+
+// helpersFilePath returns the path of the file the helpers of the Go file of filePath are split into.
+// e.g. `bqschema_helpers.generated.go` for `bqschema.generated.go`
+func helpersFilePath(filePath string) string {
+	if strings.HasSuffix(filePath, ".generated.go") {
+		return strings.TrimSuffix(filePath, ".generated.go") + "_helpers.generated.go"
+	}
+	return strings.TrimSuffix(filePath, ".go") + "_helpers.go"
+}
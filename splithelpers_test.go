@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_helpersFilePath(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for filePath, expect := range map[string]string{
+			"bqschema.generated.go":        "bqschema_helpers.generated.go",
+			"models/users.generated.go":    "models/users_helpers.generated.go",
+			"bqschema.go":                  "bqschema_helpers.go",
+			"models.generated/bqschema.go": "models.generated/bqschema_helpers.go",
+		} {
+			if helpersPath := helpersFilePath(filePath); helpersPath != expect {
+				t.Errorf("helpersFilePath: %s: want=%s current=%s", filePath, expect, helpersPath)
+			}
+		}
+	})
+}
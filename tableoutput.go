@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+// parseTableOutputs parses a comma-separated list of `table=path`, and returns the file paths by table ID.
+// The paths are cleaned, so that e.g. `a.go` and `./a.go` are the same file.
+func parseTableOutputs(s string) (tableOutputs map[string]string, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	tableOutputs = make(map[string]string)
+	for _, tableOutput := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(tableOutput), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid table output `%s`. format: table=path", tableOutput)
+		}
+		if _, exist := tableOutputs[kv[0]]; exist {
+			return nil, fmt.Errorf("table %s is given more than once", kv[0])
+		}
+		tableOutputs[kv[0]] = filepath.Clean(kv[1])
+	}
+
+	return tableOutputs, nil
+}
+
+// tableOutputPaths returns the path of the main Go output, which the tables in tableOutputs are routed from,
+// and the paths of the files the tables are routed to, sorted.
+// The routed files must be in the directory of the main Go output, so that they are in the same package.
+func tableOutputPaths(outputFormats, filePaths []string, tableOutputs map[string]string) (mainPath string, routedPaths []string, err error) {
+	var goOutputs int
+	for i, format := range outputFormats {
+		if format == generator.OutputFormatGo {
+			mainPath = filePaths[i]
+			goOutputs++
+		}
+	}
+	if goOutputs != 1 {
+		return "", nil, fmt.Errorf("-%s needs exactly one output of -%s=%s", optNameTableOutput, optNameOutputFormat, generator.OutputFormatGo)
+	}
+
+	outputs := make(map[string]bool)
+	for _, filePath := range filePaths {
+		outputs[filepath.Clean(filePath)] = true
+	}
+	routed := make(map[string]bool)
+	for tableID, routedPath := range tableOutputs {
+		if filepath.Dir(routedPath) != filepath.Dir(mainPath) {
+			return "", nil, fmt.Errorf("table %s: %s is not in the directory of %s", tableID, routedPath, mainPath)
+		}
+		if outputs[routedPath] {
+			return "", nil, fmt.Errorf("table %s: %s is another output", tableID, routedPath)
+		}
+		if !routed[routedPath] {
+			routed[routedPath] = true
+			routedPaths = append(routedPaths, routedPath)
+		}
+	}
+	sort.Strings(routedPaths)
+
+	return mainPath, routedPaths, nil
+}
+
+// tableOutputSchemas returns the schemas of the tables generated into the file of filePath.
+func tableOutputSchemas(schemas []generator.TableSchema, filePath, mainPath string, tableOutputs map[string]string) (fileSchemas []generator.TableSchema) {
+	for _, schema := range schemas {
+		routedPath, exist := tableOutputs[schema.Table.TableID]
+		if !exist {
+			routedPath = mainPath
+		}
+		if routedPath == filePath {
+			fileSchemas = append(fileSchemas, schema)
+		}
+	}
+	return fileSchemas
+}
+
+func warnMissingTableOutputs(schemas []generator.TableSchema, tableOutputs map[string]string) {
+	tableIDs := make(map[string]bool)
+	for _, schema := range schemas {
+		tableIDs[schema.Table.TableID] = true
+	}
+	for tableID, routedPath := range tableOutputs {
+		if !tableIDs[tableID] {
+			warnln(fmt.Sprintf("-%s: table %s is not found. %s has no structs", optNameTableOutput, tableID, routedPath))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/ginokent/bqschema-gen-go/generator"
+)
+
+func Test_parseTableOutputs(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		tableOutputs, err := parseTableOutputs("events=events.generated.go, logs=logs.generated.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]string{"events": "events.generated.go", "logs": "logs.generated.go"}
+		if !reflect.DeepEqual(tableOutputs, expect) {
+			t.Errorf("parseTableOutputs: want=%v current=%v", expect, tableOutputs)
+		}
+	})
+
+	t.Run("正常系_cleaned", func(t *testing.T) {
+		tableOutputs, err := parseTableOutputs("events=a.go,logs=./a.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]string{"events": "a.go", "logs": "a.go"}
+		if !reflect.DeepEqual(tableOutputs, expect) {
+			t.Errorf("parseTableOutputs: want=%v current=%v", expect, tableOutputs)
+		}
+
+		_, routedPaths, err := tableOutputPaths([]string{generator.OutputFormatGo}, []string{"bqschema.generated.go"}, tableOutputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := []string{"a.go"}; !reflect.DeepEqual(routedPaths, expect) {
+			t.Errorf("tableOutputPaths: want=%v current=%v", expect, routedPaths)
+		}
+	})
+
+	t.Run("正常系_empty", func(t *testing.T) {
+		tableOutputs, err := parseTableOutputs("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tableOutputs != nil {
+			t.Errorf("parseTableOutputs: want=nil current=%v", tableOutputs)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"events", "=events.go", "events=", "events=a.go,events=b.go"} {
+			if _, err := parseTableOutputs(s); err == nil {
+				t.Error("parseTableOutputs: " + s + ": err == nil")
+			}
+		}
+	})
+}
+
+func Test_tableOutputPaths(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		mainPath, routedPaths, err := tableOutputPaths(
+			[]string{generator.OutputFormatProto, generator.OutputFormatGo},
+			[]string{"bqschema.proto", "bqschema.generated.go"},
+			map[string]string{"logs": "logs.go", "events": "events.go", "event_logs": "events.go"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mainPath != "bqschema.generated.go" {
+			t.Error("tableOutputPaths: want=bqschema.generated.go current=" + mainPath)
+		}
+		if expect := []string{"events.go", "logs.go"}; !reflect.DeepEqual(routedPaths, expect) {
+			t.Errorf("tableOutputPaths: want=%v current=%v", expect, routedPaths)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			formats      []string
+			filePaths    []string
+			tableOutputs map[string]string
+		}{
+			{[]string{generator.OutputFormatProto}, []string{"bqschema.proto"}, map[string]string{"events": "events.go"}},
+			{[]string{generator.OutputFormatGo}, []string{"bqschema.generated.go"}, map[string]string{"events": filepath.Join("events", "events.go")}},
+			{[]string{generator.OutputFormatGo}, []string{"./bqschema.generated.go"}, map[string]string{"events": "bqschema.generated.go"}},
+		} {
+			if _, _, err := tableOutputPaths(tt.formats, tt.filePaths, tt.tableOutputs); err == nil {
+				t.Errorf("tableOutputPaths: %v: err == nil", tt.tableOutputs)
+			}
+		}
+	})
+}
+
+func Test_tableOutputSchemas(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			users        = generator.TableSchema{Table: &bigquery.Table{TableID: "users"}}
+			events       = generator.TableSchema{Table: &bigquery.Table{TableID: "events"}}
+			schemas      = []generator.TableSchema{users, events}
+			tableOutputs = map[string]string{"events": "events.go"}
+		)
+		if fileSchemas := tableOutputSchemas(schemas, "bqschema.generated.go", "bqschema.generated.go", tableOutputs); !reflect.DeepEqual(fileSchemas, []generator.TableSchema{users}) {
+			t.Errorf("tableOutputSchemas: want=%v current=%v", []generator.TableSchema{users}, fileSchemas)
+		}
+		if fileSchemas := tableOutputSchemas(schemas, "events.go", "bqschema.generated.go", tableOutputs); !reflect.DeepEqual(fileSchemas, []generator.TableSchema{events}) {
+			t.Errorf("tableOutputSchemas: want=%v current=%v", []generator.TableSchema{events}, fileSchemas)
+		}
+	})
+}
+
+func Test_generateGoFiles(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			schemas = []generator.TableSchema{
+				{
+					Table:    &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "users"},
+					Metadata: &bigquery.TableMetadata{FullID: "p:d.users", Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}}},
+				},
+				{
+					Table:    &bigquery.Table{ProjectID: "p", DatasetID: "d", TableID: "events"},
+					Metadata: &bigquery.TableMetadata{FullID: "p:d.events", Schema: bigquery.Schema{{Name: "created_at", Type: bigquery.TimestampFieldType}}},
+				},
+			}
+			testDir      = t.TempDir()
+			mainPath     = filepath.Join(testDir, "bqschema.generated.go")
+			eventsPath   = filepath.Join(testDir, "events.generated.go")
+			tableOutputs = map[string]string{"events": eventsPath}
+		)
+		files, err := generator.GenerateGoFiles(schemas, mainPath, tableOutputs, generator.Options{EmitTypeRegistry: true, NoGoimports: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 {
+			t.Fatalf("generator.GenerateGoFiles: want=2 files current=%d", len(files))
+		}
+
+		mainCode, eventsCode := string(files[mainPath]), string(files[eventsPath])
+		for _, want := range []string{"//go:generate ", "type Users struct {", "\"events\": reflect.TypeOf(Events{}),"} {
+			if !strings.Contains(mainCode, want) {
+				t.Error("generator.GenerateGoFiles: " + want + " not found: " + mainCode)
+			}
+		}
+		if strings.Contains(mainCode, "type Events struct {") || strings.Contains(mainCode, "\"time\"") {
+			t.Error("generator.GenerateGoFiles: Events in the main file: " + mainCode)
+		}
+		for _, want := range []string{"import \"time\"", "type Events struct {"} {
+			if !strings.Contains(eventsCode, want) {
+				t.Error("generator.GenerateGoFiles: " + want + " not found: " + eventsCode)
+			}
+		}
+		if strings.Contains(eventsCode, "//go:generate ") || strings.Contains(eventsCode, "TypeRegistry") {
+			t.Error("generator.GenerateGoFiles: package-level declarations in the routed file: " + eventsCode)
+		}
+
+		if err := typecheckGoCode(mainPath, files[mainPath], files); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+var errTypecheck = errors.New("generated code does not type-check")
+
+// typecheckGoCode type-checks the generated code as filePath, together with the other Go files of the package in
+// the directory of filePath. The files in otherCodes (e.g. the other generated files) are checked by the contents
+// instead of the ones in the directory. The imported packages are loaded from source, so that the packages of type
+// overrides are checked as well. It returns errTypecheck with all the diagnostics.
+func typecheckGoCode(filePath string, generatedCode []byte, otherCodes map[string][]byte) (err error) {
+	fset := token.NewFileSet()
+
+	var file *ast.File
+	file, err = parser.ParseFile(fset, filePath, generatedCode, 0)
+	if err != nil {
+		return fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	dir := filepath.Dir(filePath)
+	files := []*ast.File{file}
+	exclude := map[string]bool{filepath.Base(filePath): true}
+	for otherPath, otherCode := range otherCodes {
+		if filepath.Dir(otherPath) != dir || otherPath == filePath {
+			continue
+		}
+		exclude[filepath.Base(otherPath)] = true
+
+		var otherFile *ast.File
+		otherFile, err = parser.ParseFile(fset, otherPath, otherCode, 0)
+		if err != nil {
+			return fmt.Errorf("parser.ParseFile: %w", err)
+		}
+		if otherFile.Name.Name == file.Name.Name {
+			files = append(files, otherFile)
+		}
+	}
+
+	var packageFiles []*ast.File
+	packageFiles, err = parsePackageFiles(fset, dir, exclude, file.Name.Name)
+	if err != nil {
+		return fmt.Errorf("parsePackageFiles: %w", err)
+	}
+	files = append(files, packageFiles...)
+
+	var diagnostics []string
+	conf := types.Config{
+		// The source importer resolves the imports from the directory of the file,
+		// i.e. by the go.mod of the output directory.
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			diagnostics = append(diagnostics, err.Error())
+		},
+	}
+	// The errors are collected by conf.Error
+	_, _ = conf.Check(file.Name.Name, fset, files, nil)
+
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("%w:\n%s", errTypecheck, strings.Join(diagnostics, "\n"))
+	}
+
+	return nil
+}
+
+// parsePackageFiles parses the non-test Go files of the package pkgName in dir, except the files named in exclude.
+// The files excluded by build constraints are skipped.
+func parsePackageFiles(fset *token.FileSet, dir string, exclude map[string]bool, pkgName string) (files []*ast.File, err error) {
+	var paths []string
+	paths, err = filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Glob: %w", err)
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if exclude[name] || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		var match bool
+		match, err = build.Default.MatchFile(dir, name)
+		if err != nil {
+			return nil, fmt.Errorf("build.Default.MatchFile: %w", err)
+		}
+		if !match {
+			continue
+		}
+
+		var file *ast.File
+		file, err = parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("parser.ParseFile: %w", err)
+		}
+		if file.Name.Name != pkgName {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_typecheckGoCode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		testDir := t.TempDir()
+		// NOTE: a file of the same package that the generated code refers to
+		if err := ioutil.WriteFile(filepath.Join(testDir, "record.go"), []byte("package bqschema\n\ntype Record interface{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(testDir, "other_test.go"), []byte("package bqschema_test\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		generatedCode := []byte("package bqschema\n\nimport \"time\"\n\ntype Users struct {\n\tCreated_at time.Time\n}\n\nvar _ Record = (*Users)(nil)\n")
+		if err := typecheckGoCode(filepath.Join(testDir, "bqschema.generated.go"), generatedCode, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_otherCodes", func(t *testing.T) {
+		testDir := t.TempDir()
+		// NOTE: an outdated file in the directory that is replaced by the other generated code
+		if err := ioutil.WriteFile(filepath.Join(testDir, "users.go"), []byte("package bqschema\n\ntype Users struct{}\n\ntype Removed struct{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		otherCodes := map[string][]byte{
+			filepath.Join(testDir, "users.go"):      []byte("package bqschema\n\ntype Users struct {\n\tId int64\n}\n"),
+			filepath.Join(t.TempDir(), "orders.go"): []byte("package bqschema\n\ntype Users struct{}\n"),
+		}
+		generatedCode := []byte("package bqschema\n\nvar _ = Users{Id: 1}\n")
+		if err := typecheckGoCode(filepath.Join(testDir, "bqschema.generated.go"), generatedCode, otherCodes); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		generatedCode := []byte("package bqschema\n\nimport \"time\"\n\ntype Users struct {\n\tCreated_at time.Tim\n}\n")
+		err := typecheckGoCode(filepath.Join(t.TempDir(), "bqschema.generated.go"), generatedCode, nil)
+		if !errors.Is(err, errTypecheck) || !strings.Contains(err.Error(), "bqschema.generated.go:6:") {
+			t.Errorf("typecheckGoCode: want=%v current=%v", errTypecheck, err)
+		}
+	})
+}